@@ -19,7 +19,7 @@ interface ApiService {
 
     // 游客登录
     @POST("api/auth/guest")
-    suspend fun guestLogin(): Response<ApiResponse<LoginResponse>>
+    suspend fun guestLogin(@Body request: GuestLoginRequest): Response<ApiResponse<LoginResponse>>
 
     // 菜单
     @GET("api/menus")
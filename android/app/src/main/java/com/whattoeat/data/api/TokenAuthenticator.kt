@@ -0,0 +1,106 @@
+package com.whattoeat.data.api
+
+import com.google.gson.Gson
+import com.google.gson.reflect.TypeToken
+import com.whattoeat.data.api.models.ApiResponse
+import com.whattoeat.data.api.models.LoginResponse
+import com.whattoeat.data.api.models.RefreshRequest
+import com.whattoeat.data.datastore.SettingsDataStore
+import kotlinx.coroutines.runBlocking
+import okhttp3.Authenticator
+import okhttp3.MediaType.Companion.toMediaType
+import okhttp3.OkHttpClient
+import okhttp3.Request
+import okhttp3.RequestBody.Companion.toRequestBody
+import okhttp3.Response
+import okhttp3.Route
+import java.util.concurrent.TimeUnit
+
+/**
+ * 访问令牌过期（401）时用刷新令牌换一组新令牌，然后重试原请求
+ * 刷新令牌也被服务端拒绝时说明登录确实失效，清空本地登录信息
+ */
+class TokenAuthenticator(
+    private val settingsDataStore: SettingsDataStore
+) : Authenticator {
+
+    companion object {
+        // 这些接口的 401 表示凭据错误，不是令牌过期
+        private val PUBLIC_AUTH_PATHS = setOf(
+            "/api/auth/register",
+            "/api/auth/login",
+            "/api/auth/guest",
+            "/api/auth/refresh",
+            "/api/auth/password-reset"
+        )
+        private const val REFRESH_PATH = "/api/auth/refresh"
+    }
+
+    private val gson = Gson()
+
+    // 刷新请求走单独的客户端，避免再次经过认证拦截器和本 Authenticator
+    private val refreshClient = OkHttpClient.Builder()
+        .connectTimeout(30, TimeUnit.SECONDS)
+        .readTimeout(30, TimeUnit.SECONDS)
+        .writeTimeout(30, TimeUnit.SECONDS)
+        .build()
+
+    override fun authenticate(route: Route?, response: Response): Request? {
+        val request = response.request
+        if (request.url.encodedPath in PUBLIC_AUTH_PATHS) return null
+        // 用新令牌重试后仍然 401，不再继续
+        if (response.priorResponse != null) return null
+
+        val failedToken = request.header("Authorization")?.removePrefix("Bearer ") ?: return null
+
+        // 多个请求同时过期时只刷新一次，其余请求直接用刷新后的令牌重试
+        synchronized(this) {
+            val currentToken = runBlocking { settingsDataStore.getToken() } ?: return null
+            if (currentToken != failedToken) {
+                return request.withToken(currentToken)
+            }
+
+            val refreshToken = runBlocking { settingsDataStore.getRefreshToken() } ?: return null
+            val refreshUrl = request.url.newBuilder()
+                .encodedPath(REFRESH_PATH)
+                .query(null)
+                .build()
+            val refreshRequest = Request.Builder()
+                .url(refreshUrl)
+                .post(
+                    gson.toJson(RefreshRequest(refreshToken))
+                        .toRequestBody("application/json; charset=utf-8".toMediaType())
+                )
+                .build()
+
+            val loginResponse = try {
+                refreshClient.newCall(refreshRequest).execute().use { refreshResponse ->
+                    if (refreshResponse.code == 401) {
+                        runBlocking { settingsDataStore.clearUserInfo() }
+                        return null
+                    }
+                    if (!refreshResponse.isSuccessful) return null
+                    val type = object : TypeToken<ApiResponse<LoginResponse>>() {}.type
+                    val body: ApiResponse<LoginResponse>? =
+                        gson.fromJson(refreshResponse.body?.string(), type)
+                    body?.data
+                }
+            } catch (e: Exception) {
+                // 网络问题不代表登录失效，保留本地令牌
+                null
+            } ?: return null
+
+            runBlocking {
+                settingsDataStore.setToken(loginResponse.token)
+                settingsDataStore.setRefreshToken(loginResponse.refreshToken)
+            }
+            return request.withToken(loginResponse.token)
+        }
+    }
+
+    private fun Request.withToken(token: String): Request {
+        return newBuilder()
+            .header("Authorization", "Bearer $token")
+            .build()
+    }
+}
@@ -50,6 +50,7 @@ data class DecisionRecord(
 // 登录响应
 data class LoginResponse(
     val token: String,
+    @SerializedName("refresh_token") val refreshToken: String,
     @SerializedName("user_id") val userId: Long,
     val username: String
 )
@@ -78,6 +79,15 @@ data class LoginRequest(
     val password: String
 )
 
+// 游客登录请求：同一设备始终使用同一个游客
+data class RefreshRequest(
+    @SerializedName("refresh_token") val refreshToken: String
+)
+
+data class GuestLoginRequest(
+    @SerializedName("device_id") val deviceId: String
+)
+
 data class RegisterRequest(
     val username: String,
     val password: String
@@ -11,6 +11,7 @@ import dagger.hilt.android.qualifiers.ApplicationContext
 import kotlinx.coroutines.flow.Flow
 import kotlinx.coroutines.flow.first
 import kotlinx.coroutines.flow.map
+import java.util.UUID
 import javax.inject.Inject
 import javax.inject.Singleton
 
@@ -23,14 +24,12 @@ class SettingsDataStore @Inject constructor(
     companion object {
         private val KEY_SERVER_HOST = stringPreferencesKey("server_host")
         private val KEY_TOKEN = stringPreferencesKey("token")
+        private val KEY_REFRESH_TOKEN = stringPreferencesKey("refresh_token")
         private val KEY_USER_ID = longPreferencesKey("user_id")
         private val KEY_USERNAME = stringPreferencesKey("username")
+        private val KEY_DEVICE_ID = stringPreferencesKey("device_id")
 
         const val DEFAULT_SERVER_HOST = "http://10.0.2.2:8080" // Android 模拟器 localhost
-
-        // 游客用户信息
-        const val GUEST_USER_ID = 1L
-        const val GUEST_USERNAME = "guest"
     }
 
     // 服务器地址
@@ -67,6 +66,21 @@ class SettingsDataStore @Inject constructor(
         }
     }
 
+    // 刷新令牌（访问令牌过期后用它换新令牌）
+    suspend fun getRefreshToken(): String? {
+        return context.dataStore.data.first()[KEY_REFRESH_TOKEN]
+    }
+
+    suspend fun setRefreshToken(refreshToken: String?) {
+        context.dataStore.edit { preferences ->
+            if (refreshToken != null) {
+                preferences[KEY_REFRESH_TOKEN] = refreshToken
+            } else {
+                preferences.remove(KEY_REFRESH_TOKEN)
+            }
+        }
+    }
+
     // 用户信息
     val userId: Flow<Long?> = context.dataStore.data.map { preferences ->
         preferences[KEY_USER_ID]
@@ -86,11 +100,24 @@ class SettingsDataStore @Inject constructor(
     suspend fun clearUserInfo() {
         context.dataStore.edit { preferences ->
             preferences.remove(KEY_TOKEN)
+            preferences.remove(KEY_REFRESH_TOKEN)
             preferences.remove(KEY_USER_ID)
             preferences.remove(KEY_USERNAME)
         }
     }
 
+    // 设备标识（首次使用时随机生成，退出登录后保留，游客登录时用于找回同一个游客）
+    suspend fun getOrCreateDeviceId(): String {
+        context.dataStore.data.first()[KEY_DEVICE_ID]?.let { return it }
+        var deviceId = ""
+        context.dataStore.edit { preferences ->
+            deviceId = preferences[KEY_DEVICE_ID] ?: UUID.randomUUID().toString().also {
+                preferences[KEY_DEVICE_ID] = it
+            }
+        }
+        return deviceId
+    }
+
     // 检查是否已登录
     suspend fun isLoggedIn(): Boolean {
         return getToken() != null
@@ -52,6 +52,7 @@ class WhatToEatRepository @Inject constructor(
                 response.body()?.data?.let { loginResponse ->
                     // 保存登录信息
                     settingsDataStore.setToken(loginResponse.token)
+                    settingsDataStore.setRefreshToken(loginResponse.refreshToken)
                     settingsDataStore.setUserInfo(loginResponse.userId, loginResponse.username)
                     Result.Success(loginResponse)
                 } ?: Result.Error("登录失败")
@@ -66,11 +67,13 @@ class WhatToEatRepository @Inject constructor(
     // 游客登录
     suspend fun guestLogin(): Result<LoginResponse> {
         return try {
-            val response = apiService.guestLogin()
+            val deviceId = settingsDataStore.getOrCreateDeviceId()
+            val response = apiService.guestLogin(GuestLoginRequest(deviceId))
             if (response.isSuccessful && response.body()?.code == 0) {
                 response.body()?.data?.let { loginResponse ->
                     // 保存登录信息
                     settingsDataStore.setToken(loginResponse.token)
+                    settingsDataStore.setRefreshToken(loginResponse.refreshToken)
                     settingsDataStore.setUserInfo(loginResponse.userId, loginResponse.username)
                     Result.Success(loginResponse)
                 } ?: Result.Error("游客登录失败")
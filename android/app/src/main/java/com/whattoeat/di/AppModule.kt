@@ -2,6 +2,7 @@ package com.whattoeat.di
 
 import android.content.Context
 import com.whattoeat.data.api.ApiService
+import com.whattoeat.data.api.TokenAuthenticator
 import com.whattoeat.data.datastore.SettingsDataStore
 import dagger.Module
 import dagger.Provides
@@ -108,6 +109,7 @@ object AppModule {
             .addInterceptor(loggingInterceptor)
             .addInterceptor(authInterceptor)
             .addInterceptor(dynamicBaseUrlInterceptor)
+            .authenticator(TokenAuthenticator(settingsDataStore))
             .connectTimeout(30, TimeUnit.SECONDS)
             .readTimeout(30, TimeUnit.SECONDS)
             .writeTimeout(30, TimeUnit.SECONDS)
@@ -1,6 +1,7 @@
 package com.whattoeat.di
 
 import com.whattoeat.data.api.ApiService
+import com.whattoeat.data.api.TokenAuthenticator
 import com.whattoeat.data.datastore.SettingsDataStore
 import kotlinx.coroutines.runBlocking
 import okhttp3.Interceptor
@@ -36,6 +37,7 @@ object ApiServiceFactory {
         val okHttpClient = OkHttpClient.Builder()
             .addInterceptor(loggingInterceptor)
             .addInterceptor(authInterceptor)
+            .authenticator(TokenAuthenticator(settingsDataStore))
             .connectTimeout(30, TimeUnit.SECONDS)
             .readTimeout(30, TimeUnit.SECONDS)
             .writeTimeout(30, TimeUnit.SECONDS)
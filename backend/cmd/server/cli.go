@@ -0,0 +1,241 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"what-to-eat/config"
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+	"what-to-eat/internal/service"
+	"what-to-eat/pkg/timezone"
+)
+
+// 子命令名称
+const (
+	commandServe  = "serve"
+	commandImport = "import"
+	commandExport = "export"
+	commandSeed   = "seed"
+	commandPrune  = "prune"
+)
+
+// parseCommand 解析子命令，未指定时默认为 serve
+func parseCommand(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return commandServe, args
+	}
+	return args[0], args[1:]
+}
+
+// runCommand 执行命令行子命令
+func runCommand(command string, args []string) error {
+	switch command {
+	case commandImport:
+		return runImport(args)
+	case commandExport:
+		return runExport(args)
+	case commandSeed:
+		return runSeed(args)
+	case commandPrune:
+		return runPrune(args)
+	default:
+		printUsage()
+		return fmt.Errorf("unknown command: %s", command)
+	}
+}
+
+// printUsage 打印命令行用法
+func printUsage() {
+	fmt.Fprintln(os.Stderr, `用法: server [command] [flags]
+
+命令:
+  serve     启动 HTTP 服务（默认）
+  import    从 CSV/JSON/YAML 文件批量导入餐厅和菜品
+  export    导出全部餐厅和菜品为 CSV/JSON/YAML
+  seed      导入内置种子数据包或自定义种子文件（已存在的条目会跳过）
+  prune     按保留策略把过期的决策记录汇总为月度统计后删除`)
+}
+
+// newMenuService 为命令行创建菜单服务
+func newMenuService() *service.MenuService {
+	db := repository.GetDB()
+	return service.NewMenuService(repository.NewMenuRepository(db), repository.NewRestaurantRepository(db))
+}
+
+// runImport 批量导入菜单
+// 示例: server import -file menus.csv -dry-run
+func runImport(args []string) error {
+	fs := flag.NewFlagSet(commandImport, flag.ExitOnError)
+	file := fs.String("file", "", "导入文件路径（必填）")
+	format := fs.String("format", "", "文件格式：csv, json, yaml（默认按文件后缀推断）")
+	dryRun := fs.Bool("dry-run", false, "试运行，只输出报告不写入数据库")
+	fs.Parse(args)
+
+	if *file == "" {
+		fs.Usage()
+		return fmt.Errorf("missing -file")
+	}
+
+	var (
+		datasetFormat service.DatasetFormat
+		err           error
+	)
+	if *format != "" {
+		datasetFormat, err = service.ParseDatasetFormat(*format)
+	} else {
+		datasetFormat, err = service.DetectDatasetFormat(*file)
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	report, err := newMenuService().Import(datasetFormat, f, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	printImportReport(report)
+	return nil
+}
+
+// runExport 导出菜单
+// 示例: server export -format yaml -o menus.yaml
+func runExport(args []string) error {
+	fs := flag.NewFlagSet(commandExport, flag.ExitOnError)
+	format := fs.String("format", "", "文件格式：csv, json, yaml（默认按输出文件后缀推断，否则为 json）")
+	output := fs.String("o", "", "输出文件路径（默认输出到标准输出）")
+	fs.Parse(args)
+
+	var (
+		datasetFormat = service.FormatJSON
+		err           error
+	)
+	if *format != "" {
+		datasetFormat, err = service.ParseDatasetFormat(*format)
+	} else if *output != "" {
+		datasetFormat, err = service.DetectDatasetFormat(*output)
+	}
+	if err != nil {
+		return err
+	}
+
+	dataset, err := newMenuService().Export()
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return service.EncodeDataset(datasetFormat, os.Stdout, dataset)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := service.EncodeDataset(datasetFormat, f, dataset); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d restaurants to %s\n", len(dataset.Restaurants), *output)
+	return nil
+}
+
+// runSeed 导入种子数据
+// 示例: server seed -pack western 或 server seed -file seeds.yaml -dry-run
+func runSeed(args []string) error {
+	fs := flag.NewFlagSet(commandSeed, flag.ExitOnError)
+	pack := fs.String("pack", service.DefaultSeedPack, "内置数据包名称")
+	file := fs.String("file", "", "自定义种子文件（CSV/JSON/YAML），设置后忽略 -pack")
+	dryRun := fs.Bool("dry-run", false, "试运行，只输出报告不写入数据库")
+	list := fs.Bool("list", false, "列出全部内置数据包")
+	fs.Parse(args)
+
+	if *list {
+		for _, name := range service.SeedPacks() {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	report, err := newMenuService().Seed(service.SeedSource{Pack: *pack, File: *file}, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	printImportReport(report)
+	return nil
+}
+
+// runPrune 按保留策略汇总并删除过期的决策记录
+// 示例: server prune -keep-days 365 -dry-run
+func runPrune(args []string) error {
+	cfg := config.GetConfig()
+	fs := flag.NewFlagSet(commandPrune, flag.ExitOnError)
+	keepDays := fs.Int("keep-days", cfg.Retention.KeepDays, "保留原始记录的天数（默认使用 retention.keep_days）")
+	batchSize := fs.Int("batch-size", cfg.Retention.BatchSize, "每个事务汇总和删除的记录数")
+	dryRun := fs.Bool("dry-run", false, "试运行，只统计将要汇总的记录数")
+	fs.Parse(args)
+
+	if *keepDays <= 0 {
+		fs.Usage()
+		return fmt.Errorf("retention is disabled, set -keep-days or retention.keep_days")
+	}
+
+	defaultLocation, err := timezone.Load(cfg.Server.TimeZone)
+	if err != nil {
+		return err
+	}
+	db := repository.GetDB()
+	timeZones := service.NewTimeZoneService(repository.NewUserRepository(db), defaultLocation)
+	retention := service.NewRetentionService(repository.NewDecisionRepository(db), timeZones, service.RetentionPolicy{
+		KeepDays:  *keepDays,
+		BatchSize: *batchSize,
+	})
+
+	report, err := retention.Prune(time.Now(), *dryRun)
+	if err != nil {
+		return err
+	}
+
+	mode := ""
+	if report.DryRun {
+		mode = "[dry-run] "
+	}
+	fmt.Printf("%susers: %d, records: %d, aggregates: %d\n", mode, report.Users, report.Records, report.Aggregates)
+	return nil
+}
+
+// printImportReport 输出导入报告
+func printImportReport(report *model.ImportReport) {
+	for _, row := range report.Rows {
+		line := fmt.Sprintf("%5d  %-8s %s", row.Line, row.Status, row.RestaurantName)
+		if row.DishName != "" {
+			line += " / " + row.DishName
+		}
+		if row.Message != "" {
+			line += "  (" + row.Message + ")"
+		}
+		fmt.Println(line)
+	}
+
+	mode := ""
+	if report.DryRun {
+		mode = "[dry-run] "
+	}
+	fmt.Printf("%stotal: %d, created: %d, skipped: %d, failed: %d\n",
+		mode, report.Total, report.Created, report.Skipped, report.Failed)
+}
@@ -2,17 +2,23 @@ package main
 
 import (
 	"net"
+	"os"
 	"path/filepath"
+	"time"
+	_ "time/tzdata" // 内置时区数据库，部署环境没有 zoneinfo 时也能加载用户时区
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
 	"what-to-eat/config"
 	"what-to-eat/internal/handler"
+	"what-to-eat/internal/model"
 	"what-to-eat/internal/repository"
 	"what-to-eat/internal/service"
 	"what-to-eat/pkg/logger"
 	"what-to-eat/pkg/middleware"
+	"what-to-eat/pkg/storage"
+	"what-to-eat/pkg/timezone"
 )
 
 // getLocalIP 获取本机IP地址
@@ -28,6 +34,22 @@ func getLocalIP() string {
 }
 
 func main() {
+	cfg := setup()
+	defer logger.Sync()
+
+	// 解析子命令（默认启动 HTTP 服务）
+	command, args := parseCommand(os.Args[1:])
+	if command == commandServe {
+		runServer(cfg)
+		return
+	}
+	if err := runCommand(command, args); err != nil {
+		logger.Fatal("Command failed", zap.String("command", command), zap.Error(err))
+	}
+}
+
+// setup 加载配置、初始化日志和数据库
+func setup() *config.Config {
 	// 加载配置
 	configPath := filepath.Join("config", "config.yaml")
 	cfg, err := config.LoadConfig(configPath)
@@ -46,7 +68,6 @@ func main() {
 		logger.InitDefault()
 		logger.Error("Failed to init logger, using default", zap.Error(err))
 	}
-	defer logger.Sync()
 
 	logger.Info("Config loaded",
 		zap.String("port", cfg.Server.Port),
@@ -59,6 +80,11 @@ func main() {
 		logger.Fatal("Failed to initialize database", zap.Error(err))
 	}
 
+	return cfg
+}
+
+// runServer 启动 HTTP 服务
+func runServer(cfg *config.Config) {
 	db := repository.GetDB()
 
 	// 初始化 Repository
@@ -66,16 +92,108 @@ func main() {
 	restaurantRepo := repository.NewRestaurantRepository(db)
 	menuRepo := repository.NewMenuRepository(db)
 	decisionRepo := repository.NewDecisionRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	prefRepo := repository.NewPreferenceRepository(db)
+	statsRepo := repository.NewStatsRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
+
+	// 用户未设置时区时使用的默认时区
+	defaultLocation, err := timezone.Load(cfg.Server.TimeZone)
+	if err != nil {
+		logger.Fatal("Invalid default time zone", zap.String("time_zone", cfg.Server.TimeZone), zap.Error(err))
+	}
+
+	// 密码策略
+	passwordPolicy, err := service.NewPasswordPolicy(cfg.Password.MinLength, cfg.Password.MinClasses, cfg.Password.CommonFile)
+	if err != nil {
+		logger.Fatal("Invalid password policy", zap.String("common_file", cfg.Password.CommonFile), zap.Error(err))
+	}
 
 	// 初始化 Service
-	authService := service.NewAuthService(userRepo, cfg.JWT.Secret)
+	authService := service.NewAuthService(userRepo, tokenRepo, service.TokenConfig{
+		Secret:     cfg.JWT.Secret,
+		AccessTTL:  time.Duration(cfg.JWT.AccessExpireMinutes) * time.Minute,
+		RefreshTTL: time.Duration(cfg.JWT.RefreshExpireDays) * 24 * time.Hour,
+		ResetTTL:   time.Duration(cfg.Password.ResetExpireHours) * time.Hour,
+	}, passwordPolicy)
 	menuService := service.NewMenuService(menuRepo, restaurantRepo)
-	decisionService := service.NewDecisionService(decisionRepo, menuRepo)
+	timeZoneService := service.NewTimeZoneService(userRepo, defaultLocation)
+	decisionService := service.NewDecisionService(decisionRepo, menuRepo, prefRepo, timeZoneService, service.DecisionWeights{
+		RecentCount: cfg.Decision.RecentCount,
+		Dish:        cfg.Decision.DishWeight,
+		Restaurant:  cfg.Decision.RestaurantWeight,
+		Cuisine:     cfg.Decision.CuisineWeight,
+		Favorite:    cfg.Decision.FavoriteWeight,
+	})
+	restaurantService := service.NewRestaurantService(restaurantRepo)
+	searchService := service.NewSearchService(restaurantRepo, menuRepo)
+	mergeService := service.NewMergeService(restaurantRepo, menuRepo, decisionRepo, prefRepo, auditRepo)
+	prefService := service.NewPreferenceService(prefRepo, menuRepo, restaurantRepo)
+	statsService := service.NewStatsService(statsRepo, timeZoneService)
+	teamService := service.NewTeamService(teamRepo, statsRepo, timeZoneService)
+	userService := service.NewUserService(userRepo, authService, auditRepo)
+	calendarService := service.NewCalendarService(userRepo, decisionRepo, timeZoneService)
+	retentionService := service.NewRetentionService(decisionRepo, timeZoneService, service.RetentionPolicy{
+		KeepDays:  cfg.Retention.KeepDays,
+		BatchSize: cfg.Retention.BatchSize,
+	})
+
+	// 还没有管理员时把配置中的用户名设为管理员（之后以用户管理接口的调整为准）
+	if promoted, err := userRepo.BootstrapAdmins(cfg.Admin.Usernames); err != nil {
+		logger.Fatal("Failed to promote admins", zap.Error(err))
+	} else if promoted > 0 {
+		logger.Info("Admins promoted", zap.Int64("users", promoted))
+	}
+
+	// 首次启动时导入种子数据
+	if cfg.Seed.Enabled {
+		report, err := menuService.SeedIfEmpty(service.SeedSource{Pack: cfg.Seed.Pack, File: cfg.Seed.File})
+		if err != nil {
+			logger.Fatal("Failed to seed data", zap.Error(err))
+		}
+		if report != nil {
+			logger.Info("Seed data imported",
+				zap.String("pack", cfg.Seed.Pack),
+				zap.String("file", cfg.Seed.File),
+				zap.Int("created", report.Created),
+				zap.Int("failed", report.Failed),
+			)
+		}
+	}
+
+	// 按保留策略定期汇总过期的决策记录
+	if retentionService.Enabled() && cfg.Retention.IntervalHours > 0 {
+		go runRetention(retentionService, time.Duration(cfg.Retention.IntervalHours)*time.Hour)
+	}
+
+	// 定期删除过期的刷新令牌和长期不活跃的游客
+	go runAuthCleanup(authService, cfg.Guest.InactiveDays, time.Hour)
+
+	// 初始化文件存储（目前只有本地存储实现）
+	if cfg.Storage.Type != "local" {
+		logger.Fatal("Unsupported storage type", zap.String("type", cfg.Storage.Type))
+	}
+	localStorage, err := storage.NewLocalStorage(cfg.Storage.LocalDir, cfg.Storage.BaseURL)
+	if err != nil {
+		logger.Fatal("Failed to initialize storage", zap.Error(err))
+	}
+	imageService := service.NewImageService(localStorage, menuRepo, restaurantRepo,
+		cfg.Storage.MaxUploadSize<<20, cfg.Storage.ThumbnailSize)
 
 	// 初始化 Handler
 	authHandler := handler.NewAuthHandler(authService)
 	menuHandler := handler.NewMenuHandler(menuService)
+	restaurantHandler := handler.NewRestaurantHandler(restaurantService)
 	decisionHandler := handler.NewDecisionHandler(decisionService)
+	searchHandler := handler.NewSearchHandler(searchService)
+	adminHandler := handler.NewAdminHandler(mergeService, userService)
+	imageHandler := handler.NewImageHandler(imageService)
+	prefHandler := handler.NewPreferenceHandler(prefService)
+	statsHandler := handler.NewStatsHandler(statsService)
+	teamHandler := handler.NewTeamHandler(teamService)
+	timeZoneHandler := handler.NewTimeZoneHandler(timeZoneService)
+	calendarHandler := handler.NewCalendarHandler(calendarService)
 
 	// 设置 Gin 模式
 	gin.SetMode(cfg.Server.Mode)
@@ -88,6 +206,9 @@ func main() {
 	// 全局中间件
 	r.Use(middleware.CORS())
 
+	// 上传的图片（静态文件）
+	r.Static(cfg.Storage.BaseURL, localStorage.Dir())
+
 	// 公开路由（无需认证）
 	api := r.Group("/api")
 	{
@@ -95,28 +216,109 @@ func main() {
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
-			auth.POST("/guest", authHandler.GuestLogin) // 游客登录
+			auth.POST("/guest", authHandler.GuestLogin)             // 游客登录
+			auth.POST("/refresh", authHandler.Refresh)              // 凭刷新令牌换取新的访问令牌
+			auth.POST("/password-reset", authHandler.ResetPassword) // 凭管理员签发的一次性令牌重置密码
 		}
+
+		// 日历订阅（凭链接中的秘密令牌只读访问，日历应用无法携带 JWT）
+		api.GET("/calendar/:token", calendarHandler.Feed)
 	}
 
 	// 需要认证的路由
 	protected := api.Group("")
-	protected.Use(middleware.JWTAuth(cfg.JWT.Secret))
+	protected.Use(middleware.JWTAuth(cfg.JWT.Secret, authService))
 	{
-		// 菜单管理
+		// 退出登录
+		protected.POST("/auth/logout", authHandler.Logout)
+		protected.POST("/auth/logout-all", authHandler.LogoutAll) // 退出所有设备
+		protected.POST("/auth/upgrade", authHandler.Upgrade)      // 游客升级为注册用户
+
+		// 菜单管理（收藏和屏蔽只影响当前用户，所有角色都可以使用）
 		menus := protected.Group("/menus")
 		{
 			menus.GET("", menuHandler.List)
-			menus.POST("", menuHandler.Create)
-			menus.DELETE("/:id", menuHandler.Delete)
+			menus.GET("/export", menuHandler.Export)
+			menus.POST("/:id/favorite", prefHandler.AddFavorite)
+			menus.DELETE("/:id/favorite", prefHandler.RemoveFavorite)
+			menus.POST("/:id/block", prefHandler.BlockMenu)
+			menus.DELETE("/:id/block", prefHandler.UnblockMenu)
 		}
 
 		// 餐厅列表（用于下拉选择）
 		protected.GET("/restaurants", menuHandler.ListRestaurants)
+		protected.GET("/cuisines", restaurantHandler.Cuisines)
+
+		// 编辑共享菜单（游客只读）
+		editors := protected.Group("")
+		editors.Use(middleware.RequireRole(model.RoleAdmin, model.RoleMember))
+		{
+			editors.POST("/menus", menuHandler.Create)
+			editors.POST("/menus/import", menuHandler.Import)
+			editors.PUT("/menus/:id/nutrition", menuHandler.UpdateNutrition)
+			editors.PUT("/menus/:id/role", menuHandler.UpdateRole)
+			editors.POST("/menus/:id/image", imageHandler.UploadMenuImage)
+			editors.POST("/restaurants/:id/image", imageHandler.UploadRestaurantImage)
+			editors.PUT("/restaurants/:id/cuisine", restaurantHandler.UpdateCuisine)
+		}
+		protected.POST("/restaurants/:id/block", prefHandler.BlockRestaurant)
+		protected.DELETE("/restaurants/:id/block", prefHandler.UnblockRestaurant)
+
+		// 收藏和屏蔽（只影响当前用户的决策）
+		protected.GET("/favorites", prefHandler.ListFavorites)
+		protected.GET("/blocks", prefHandler.ListBlocks)
+
+		// 搜索（餐厅和菜品，支持拼音和模糊匹配）
+		protected.GET("/search", searchHandler.Search)
 
 		// 决策
 		protected.POST("/decide", decisionHandler.Decide)
 		protected.GET("/history", decisionHandler.History)
+		protected.GET("/history/export", decisionHandler.ExportHistory)
+		protected.POST("/history", decisionHandler.AddHistory)
+		protected.PUT("/history/:id", decisionHandler.UpdateHistory)
+		protected.DELETE("/history/:id", decisionHandler.DeleteHistory)
+		protected.POST("/history/:id/confirm", decisionHandler.Confirm)
+		protected.GET("/nutrition/summary", decisionHandler.NutritionSummary)
+
+		// 统计
+		protected.GET("/stats", statsHandler.Stats)
+
+		// 用户时区（按天、按月的日期边界都按该时区计算）
+		protected.GET("/me/timezone", timeZoneHandler.Get)
+		protected.PUT("/me/timezone", timeZoneHandler.Update)
+
+		// 修改密码（需要旧密码，其他设备随即退出登录）
+		protected.PUT("/me/password", authHandler.ChangePassword)
+
+		// 日历订阅链接
+		protected.POST("/calendar/token", calendarHandler.CreateToken)
+		protected.DELETE("/calendar/token", calendarHandler.RevokeToken)
+
+		// 团队（成员之间共享排行榜和统计）
+		teams := protected.Group("/teams")
+		{
+			teams.GET("", teamHandler.List)
+			teams.POST("", teamHandler.Create)
+			teams.POST("/join", teamHandler.Join)
+			teams.DELETE("/:id/members/me", teamHandler.Leave)
+			teams.GET("/:id/stats", teamHandler.Stats)
+		}
+
+		// 管理员操作
+		admin := protected.Group("/admin")
+		admin.Use(middleware.RequireRole(model.RoleAdmin))
+		{
+			admin.POST("/restaurants/:id/merge", adminHandler.MergeRestaurant)
+			admin.POST("/menus/:id/merge", adminHandler.MergeMenu)
+			admin.GET("/users", adminHandler.ListUsers)
+			admin.PUT("/users/:id/role", adminHandler.UpdateUserRole)
+			admin.POST("/users/:id/logout", adminHandler.RevokeUserSessions)
+			admin.POST("/users/:id/password-reset", adminHandler.CreatePasswordReset)
+		}
+
+		// 删除共享菜品（管理员）
+		protected.DELETE("/menus/:id", middleware.RequireRole(model.RoleAdmin), menuHandler.Delete)
 	}
 
 	// 健康检查
@@ -147,3 +349,47 @@ func main() {
 		logger.Fatal("Failed to start server", zap.Error(err))
 	}
 }
+
+// runRetention 启动后立即执行一次清理，之后每隔 interval 执行一次（出错只记录日志，下次继续）
+func runRetention(retentionService *service.RetentionService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		report, err := retentionService.Prune(time.Now(), false)
+		if err != nil {
+			logger.Error("Failed to prune decision records", zap.Error(err))
+		} else if report.Records > 0 {
+			logger.Info("Decision records pruned",
+				zap.Int("users", report.Users),
+				zap.Int64("records", report.Records),
+				zap.Int64("aggregates", report.Aggregates),
+			)
+		}
+		<-ticker.C
+	}
+}
+
+// runAuthCleanup 定期删除过期的刷新令牌，以及超过 guestInactiveDays 天没有使用的游客（为 0 时不清理游客）
+func runAuthCleanup(authService *service.AuthService, guestInactiveDays int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		deleted, err := authService.PurgeExpiredTokens(now)
+		if err != nil {
+			logger.Error("Failed to purge expired refresh tokens", zap.Error(err))
+		} else if deleted > 0 {
+			logger.Info("Expired refresh tokens purged", zap.Int64("deleted", deleted))
+		}
+
+		if guestInactiveDays > 0 {
+			guests, err := authService.PurgeInactiveGuests(now.AddDate(0, 0, -guestInactiveDays))
+			if err != nil {
+				logger.Error("Failed to purge inactive guests", zap.Error(err))
+			} else if guests > 0 {
+				logger.Info("Inactive guests purged", zap.Int("guests", guests))
+			}
+		}
+		<-ticker.C
+	}
+}
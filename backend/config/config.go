@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -9,10 +10,68 @@ import (
 
 // Config 应用配置
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Log      LogConfig      `mapstructure:"log"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	JWT       JWTConfig       `mapstructure:"jwt"`
+	Log       LogConfig       `mapstructure:"log"`
+	Admin     AdminConfig     `mapstructure:"admin"`
+	Storage   StorageConfig   `mapstructure:"storage"`
+	Decision  DecisionConfig  `mapstructure:"decision"`
+	Seed      SeedConfig      `mapstructure:"seed"`
+	Retention RetentionConfig `mapstructure:"retention"`
+	Guest     GuestConfig     `mapstructure:"guest"`
+	Password  PasswordConfig  `mapstructure:"password"`
+}
+
+// PasswordConfig 密码策略（注册、修改和重置密码时检查）
+type PasswordConfig struct {
+	MinLength        int    `mapstructure:"min_length"`         // 最小长度（字符数）
+	MinClasses       int    `mapstructure:"min_classes"`        // 至少包含几类字符（小写字母、大写字母、数字、符号）
+	CommonFile       string `mapstructure:"common_file"`        // 追加的常见弱密码文件（每行一个），为空表示只使用内置列表
+	ResetExpireHours int    `mapstructure:"reset_expire_hours"` // 管理员签发的一次性重置令牌有效期（小时）
+}
+
+// GuestConfig 游客配置
+type GuestConfig struct {
+	InactiveDays int `mapstructure:"inactive_days"` // 游客超过这么多天没有登录或刷新令牌即删除（连同全部数据），0 表示不清理
+}
+
+// RetentionConfig 决策记录保留策略：超过 KeepDays 天的记录所在的整月按月汇总后删除
+type RetentionConfig struct {
+	KeepDays      int `mapstructure:"keep_days"`      // 保留原始记录的天数，0 表示永久保留
+	BatchSize     int `mapstructure:"batch_size"`     // 每个事务汇总和删除的记录数
+	IntervalHours int `mapstructure:"interval_hours"` // 服务运行时自动清理的间隔（小时），0 表示只通过 prune 命令清理
+}
+
+// SeedConfig 种子数据配置（服务首次启动、还没有任何菜品时导入）
+type SeedConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // 是否在首次启动时导入
+	Pack    string `mapstructure:"pack"`    // 内置数据包名称
+	File    string `mapstructure:"file"`    // 自定义数据文件（YAML/JSON/CSV），设置后忽略 pack
+}
+
+// DecisionConfig 决策算法配置
+// 候选菜品与最近 RecentCount 次决策重复时按最具体的一级降低权重：同一菜品 > 同一餐厅 > 同一菜系
+type DecisionConfig struct {
+	RecentCount      int     `mapstructure:"recent_count"`      // 参与比较的最近决策次数
+	DishWeight       float64 `mapstructure:"dish_weight"`       // 最近吃过同一菜品时的权重
+	RestaurantWeight float64 `mapstructure:"restaurant_weight"` // 最近吃过同一餐厅（其他菜品）时的权重
+	CuisineWeight    float64 `mapstructure:"cuisine_weight"`    // 最近吃过同一菜系（其他餐厅）时的权重
+	FavoriteWeight   float64 `mapstructure:"favorite_weight"`   // 收藏菜品的权重倍数
+}
+
+// StorageConfig 文件存储配置
+type StorageConfig struct {
+	Type          string `mapstructure:"type"`            // 存储类型，目前只支持 local
+	LocalDir      string `mapstructure:"local_dir"`       // 本地存储目录
+	BaseURL       string `mapstructure:"base_url"`        // 静态文件访问路径
+	MaxUploadSize int64  `mapstructure:"max_upload_size"` // 单个图片大小上限（MB）
+	ThumbnailSize int    `mapstructure:"thumbnail_size"`  // 缩略图最长边（像素）
+}
+
+// AdminConfig 管理员配置
+type AdminConfig struct {
+	Usernames []string `mapstructure:"usernames"` // 还没有管理员时启动设为管理员的用户名
 }
 
 // LogConfig 日志配置
@@ -24,8 +83,9 @@ type LogConfig struct {
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port string `mapstructure:"port"`
-	Mode string `mapstructure:"mode"` // debug, release, test
+	Port     string `mapstructure:"port"`
+	Mode     string `mapstructure:"mode"`      // debug, release, test
+	TimeZone string `mapstructure:"time_zone"` // 用户未设置时区时使用的默认时区（IANA 名称，Local 表示服务器所在时区）
 }
 
 // DatabaseConfig 数据库配置
@@ -38,12 +98,14 @@ type DatabaseConfig struct {
 	MaxIdleConns    int    `mapstructure:"max_idle_conns"`
 	MaxOpenConns    int    `mapstructure:"max_open_conns"`
 	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime"` // 秒
+	LegacyTimeZone  string `mapstructure:"legacy_time_zone"`  // 升级前写入数据时服务器所在的时区（IANA 名称或 +08:00 形式的偏移），只在转换旧数据时使用
 }
 
 // JWTConfig JWT 配置
 type JWTConfig struct {
-	Secret     string `mapstructure:"secret"`
-	ExpireTime int    `mapstructure:"expire_time"` // 小时
+	Secret              string `mapstructure:"secret"`
+	AccessExpireMinutes int    `mapstructure:"access_expire_minutes"` // 访问令牌有效期（分钟）
+	RefreshExpireDays   int    `mapstructure:"refresh_expire_days"`   // 刷新令牌有效期（天），每次刷新重新计算
 }
 
 var AppConfig *Config
@@ -74,16 +136,47 @@ func LoadConfig(configPath string) (*Config, error) {
 	if err := v.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	if err := applyDeprecatedJWT(v, &config.JWT); err != nil {
+		return nil, err
+	}
 
 	AppConfig = &config
 	return &config, nil
 }
 
+// applyDeprecatedJWT 兼容早期版本的 jwt.expire_time（小时）
+// 只配置了 expire_time 时换算为访问令牌有效期并提示改用新配置；与 access_expire_minutes 同时配置时无法判断以哪个为准，直接报错
+func applyDeprecatedJWT(v *viper.Viper, cfg *JWTConfig) error {
+	if !v.IsSet("jwt.expire_time") {
+		return nil
+	}
+	if isExplicitlySet(v, "jwt.access_expire_minutes") {
+		return fmt.Errorf("jwt.expire_time is deprecated and conflicts with jwt.access_expire_minutes: remove jwt.expire_time")
+	}
+	hours := v.GetInt("jwt.expire_time")
+	if hours <= 0 {
+		return fmt.Errorf("jwt.expire_time must be positive, got %q", v.GetString("jwt.expire_time"))
+	}
+	cfg.AccessExpireMinutes = hours * 60
+	fmt.Printf("Warning: jwt.expire_time is deprecated, use jwt.access_expire_minutes instead (now %d minutes)\n", cfg.AccessExpireMinutes)
+	return nil
+}
+
+// isExplicitlySet 判断配置项是否由配置文件或环境变量显式设置（不含默认值）
+func isExplicitlySet(v *viper.Viper, key string) bool {
+	if v.InConfig(key) {
+		return true
+	}
+	_, ok := os.LookupEnv("APP_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_")))
+	return ok
+}
+
 // setDefaults 设置默认配置值
 func setDefaults(v *viper.Viper) {
 	// Server
 	v.SetDefault("server.port", "8080")
 	v.SetDefault("server.mode", "debug")
+	v.SetDefault("server.time_zone", "Local")
 
 	// Database
 	v.SetDefault("database.host", "localhost")
@@ -94,10 +187,48 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("database.max_idle_conns", 10)
 	v.SetDefault("database.max_open_conns", 100)
 	v.SetDefault("database.conn_max_lifetime", 3600)
+	v.SetDefault("database.legacy_time_zone", "")
 
 	// JWT
 	v.SetDefault("jwt.secret", "your-secret-key-change-in-production")
-	v.SetDefault("jwt.expire_time", 24)
+	v.SetDefault("jwt.access_expire_minutes", 15)
+	v.SetDefault("jwt.refresh_expire_days", 30)
+
+	// Admin
+	v.SetDefault("admin.usernames", []string{})
+
+	// Storage
+	v.SetDefault("storage.type", "local")
+	v.SetDefault("storage.local_dir", "uploads")
+	v.SetDefault("storage.base_url", "/uploads")
+	v.SetDefault("storage.max_upload_size", 5)
+	v.SetDefault("storage.thumbnail_size", 320)
+
+	// Decision
+	v.SetDefault("decision.recent_count", 3)
+	v.SetDefault("decision.dish_weight", 0.5)
+	v.SetDefault("decision.restaurant_weight", 0.7)
+	v.SetDefault("decision.cuisine_weight", 0.85)
+	v.SetDefault("decision.favorite_weight", 2.0)
+
+	// Seed
+	v.SetDefault("seed.enabled", true)
+	v.SetDefault("seed.pack", "default")
+	v.SetDefault("seed.file", "")
+
+	// Retention
+	v.SetDefault("retention.keep_days", 0)
+	v.SetDefault("retention.batch_size", 500)
+	v.SetDefault("retention.interval_hours", 24)
+
+	// Guest
+	v.SetDefault("guest.inactive_days", 30)
+
+	// Password
+	v.SetDefault("password.min_length", 8)
+	v.SetDefault("password.min_classes", 2)
+	v.SetDefault("password.common_file", "")
+	v.SetDefault("password.reset_expire_hours", 24)
 
 	// Log
 	v.SetDefault("log.level", "info")
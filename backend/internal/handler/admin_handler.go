@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/service"
+	"what-to-eat/pkg/logger"
+	"what-to-eat/pkg/middleware"
+)
+
+type AdminHandler struct {
+	mergeService *service.MergeService
+	userService  *service.UserService
+}
+
+func NewAdminHandler(mergeService *service.MergeService, userService *service.UserService) *AdminHandler {
+	return &AdminHandler{mergeService: mergeService, userService: userService}
+}
+
+// MergeRestaurant 合并餐厅
+// @Summary 将 source_id 餐厅合并到路径中的餐厅
+// @Tags 管理
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path int true "目标餐厅ID"
+// @Param request body model.MergeRequest true "源餐厅"
+// @Success 200 {object} model.Response{data=model.MergeResult}
+// @Router /api/admin/restaurants/{id}/merge [post]
+func (h *AdminHandler) MergeRestaurant(c *gin.Context) {
+	targetID, req, ok := bindMergeRequest(c, "无效的餐厅ID")
+	if !ok {
+		return
+	}
+
+	result, err := h.mergeService.MergeRestaurants(middleware.GetUserID(c), targetID, req.SourceID)
+	if err != nil {
+		respondMergeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(result))
+}
+
+// MergeMenu 合并菜品
+// @Summary 将 source_id 菜品合并到路径中的菜品
+// @Tags 管理
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path int true "目标菜品ID"
+// @Param request body model.MergeRequest true "源菜品"
+// @Success 200 {object} model.Response{data=model.MergeResult}
+// @Router /api/admin/menus/{id}/merge [post]
+func (h *AdminHandler) MergeMenu(c *gin.Context) {
+	targetID, req, ok := bindMergeRequest(c, "无效的菜品ID")
+	if !ok {
+		return
+	}
+
+	result, err := h.mergeService.MergeMenus(middleware.GetUserID(c), targetID, req.SourceID)
+	if err != nil {
+		respondMergeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(result))
+}
+
+// ListUsers 用户列表
+// @Summary 分页查询用户（可按角色和用户名筛选）
+// @Tags 管理
+// @Security Bearer
+// @Produce json
+// @Param role query string false "角色（admin, member, guest）"
+// @Param q query string false "用户名关键词"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} model.Response{data=model.UserListResponse}
+// @Router /api/admin/users [get]
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	var req model.UserListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	resp, err := h.userService.List(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Error(500, "获取用户列表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(resp))
+}
+
+// UpdateUserRole 设置用户角色
+// @Summary 设置用户角色（admin 或 member），该用户需要重新登录
+// @Tags 管理
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param request body model.UpdateRoleRequest true "角色"
+// @Success 200 {object} model.Response{data=model.User}
+// @Router /api/admin/users/{id}/role [put]
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "无效的用户ID"))
+		return
+	}
+
+	var req model.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	user, err := h.userService.UpdateRole(middleware.GetUserID(c), userID, req.Role)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(user))
+}
+
+// RevokeUserSessions 结束用户的全部会话
+// @Summary 强制用户在所有设备上退出登录
+// @Tags 管理
+// @Security Bearer
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} model.Response
+// @Router /api/admin/users/{id}/logout [post]
+func (h *AdminHandler) RevokeUserSessions(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "无效的用户ID"))
+		return
+	}
+
+	sessions, err := h.userService.RevokeSessions(userID)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(gin.H{"sessions": sessions}))
+}
+
+// CreatePasswordReset 签发密码重置令牌
+// @Summary 为用户签发一次性密码重置令牌（之前的令牌失效），令牌只返回一次
+// @Tags 管理
+// @Security Bearer
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} model.Response{data=model.PasswordResetResponse}
+// @Router /api/admin/users/{id}/password-reset [post]
+func (h *AdminHandler) CreatePasswordReset(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "无效的用户ID"))
+		return
+	}
+
+	resp, err := h.userService.CreatePasswordReset(middleware.GetUserID(c), userID)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(resp))
+}
+
+// respondUserError 输出用户管理错误
+func respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrChangeOwnRole), errors.Is(err, service.ErrGuestRole):
+		c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+	default:
+		logger.Error("User management failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.Error(500, "操作失败"))
+	}
+}
+
+// bindMergeRequest 解析路径中的目标ID和请求体
+func bindMergeRequest(c *gin.Context, invalidIDMsg string) (int64, *model.MergeRequest, bool) {
+	targetID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, invalidIDMsg))
+		return 0, nil, false
+	}
+
+	var req model.MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return 0, nil, false
+	}
+	return targetID, &req, true
+}
+
+// respondMergeError 输出合并错误
+func respondMergeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMergeSelf):
+		c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+	case errors.Is(err, service.ErrRestaurantNotFound), errors.Is(err, service.ErrMenuNotFound):
+		c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+	default:
+		logger.Error("Merge failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.Error(500, "合并失败"))
+	}
+}
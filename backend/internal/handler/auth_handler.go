@@ -8,6 +8,7 @@ import (
 
 	"what-to-eat/internal/model"
 	"what-to-eat/internal/service"
+	"what-to-eat/pkg/middleware"
 )
 
 type AuthHandler struct {
@@ -39,6 +40,10 @@ func (h *AuthHandler) Register(c *gin.Context) {
 			c.JSON(http.StatusConflict, model.Error(409, err.Error()))
 			return
 		}
+		if errors.Is(err, service.ErrReservedUsername) || errors.Is(err, service.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, model.Error(500, "注册失败"))
 		return
 	}
@@ -78,21 +83,209 @@ func (h *AuthHandler) Login(c *gin.Context) {
 }
 
 // GuestLogin 游客登录
-// @Summary 游客登录
+// @Summary 游客登录（每个设备一个匿名游客）
 // @Tags 认证
+// @Accept json
 // @Produce json
+// @Param request body model.GuestLoginRequest true "设备标识"
 // @Success 200 {object} model.Response{data=model.LoginResponse}
 // @Router /api/auth/guest [post]
 func (h *AuthHandler) GuestLogin(c *gin.Context) {
-	resp, err := h.authService.GuestLogin()
+	var req model.GuestLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	resp, err := h.authService.GuestLogin(req.DeviceID)
 	if err != nil {
-		if errors.Is(err, service.ErrUserNotFound) {
-			c.JSON(http.StatusInternalServerError, model.Error(500, "游客账号未初始化"))
+		c.JSON(http.StatusInternalServerError, model.Error(500, "游客登录失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(resp))
+}
+
+// Refresh 刷新令牌
+// @Summary 凭刷新令牌换取新的访问令牌（刷新令牌同时轮换，旧令牌失效）
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body model.RefreshRequest true "刷新令牌"
+// @Success 200 {object} model.Response{data=model.LoginResponse}
+// @Router /api/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req model.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	resp, err := h.authService.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, model.Error(401, err.Error()))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, model.Error(500, "游客登录失败"))
+		c.JSON(http.StatusInternalServerError, model.Error(500, "刷新失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(resp))
+}
+
+// Logout 退出登录
+// @Summary 退出当前登录会话（刷新令牌作废，访问令牌随即失效）
+// @Tags 认证
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} model.Response
+// @Router /api/auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	if err := h.authService.Logout(userID, middleware.GetSessionID(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, model.Error(500, "退出登录失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(nil))
+}
+
+// LogoutAll 退出所有设备
+// @Summary 退出当前用户在所有设备上的登录会话
+// @Tags 认证
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} model.Response
+// @Router /api/auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	sessions, err := h.authService.LogoutAll(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Error(500, "退出登录失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(gin.H{"sessions": sessions}))
+}
+
+// Upgrade 游客升级
+// @Summary 游客升级为注册用户，或合并到已有账号（保留历史记录、收藏和设置）
+// @Tags 认证
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param request body model.UpgradeRequest true "用户名和密码（merge 为 true 时是已有账号的密码）"
+// @Success 200 {object} model.Response{data=model.LoginResponse}
+// @Router /api/auth/upgrade [post]
+func (h *AuthHandler) Upgrade(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	var req model.UpgradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	resp, err := h.authService.Upgrade(userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotGuest), errors.Is(err, service.ErrReservedUsername),
+			errors.Is(err, service.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+		case errors.Is(err, service.ErrUserExists):
+			c.JSON(http.StatusConflict, model.Error(409, err.Error()))
+		case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrInvalidPassword):
+			c.JSON(http.StatusUnauthorized, model.Error(401, "用户名或密码错误"))
+		default:
+			c.JSON(http.StatusInternalServerError, model.Error(500, "升级失败"))
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, model.Success(resp))
 }
+
+// ChangePassword 修改密码
+// @Summary 修改密码（需要旧密码），其他设备随即退出登录，返回当前设备的新令牌
+// @Tags 认证
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param request body model.ChangePasswordRequest true "旧密码和新密码"
+// @Success 200 {object} model.Response{data=model.LoginResponse}
+// @Router /api/me/password [put]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	var req model.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	resp, err := h.authService.ChangePassword(userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrWeakPassword), errors.Is(err, service.ErrGuestRole):
+			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+		case errors.Is(err, service.ErrInvalidPassword):
+			c.JSON(http.StatusBadRequest, model.Error(400, "旧密码错误"))
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, model.Error(500, "修改密码失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(resp))
+}
+
+// ResetPassword 重置密码
+// @Summary 凭管理员签发的一次性令牌设置新密码（令牌随即失效，所有设备退出登录）
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body model.ResetPasswordRequest true "重置令牌和新密码"
+// @Success 200 {object} model.Response
+// @Router /api/auth/password-reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req model.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	if err := h.authService.ResetPassword(&req); err != nil {
+		switch {
+		case errors.Is(err, service.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+		case errors.Is(err, service.ErrInvalidResetToken):
+			c.JSON(http.StatusUnauthorized, model.Error(401, err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, model.Error(500, "重置密码失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(nil))
+}
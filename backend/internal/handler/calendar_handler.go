@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/service"
+	"what-to-eat/pkg/middleware"
+)
+
+type CalendarHandler struct {
+	calendarService *service.CalendarService
+}
+
+func NewCalendarHandler(calendarService *service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{calendarService: calendarService}
+}
+
+// CreateToken 生成日历订阅链接
+// @Summary 生成日历订阅链接（之前的链接立即失效）
+// @Tags 日历
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} model.Response{data=model.CalendarTokenResponse}
+// @Router /api/calendar/token [post]
+func (h *CalendarHandler) CreateToken(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	token, err := h.calendarService.CreateToken(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Error(500, "生成订阅链接失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(model.CalendarTokenResponse{
+		Token: token,
+		URL:   feedURL(c, token),
+	}))
+}
+
+// RevokeToken 撤销日历订阅链接
+// @Summary 撤销日历订阅链接
+// @Tags 日历
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} model.Response
+// @Router /api/calendar/token [delete]
+func (h *CalendarHandler) RevokeToken(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	if err := h.calendarService.RevokeToken(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, model.Error(500, "撤销订阅链接失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(nil))
+}
+
+// Feed 日历订阅内容（无需登录，凭链接中的令牌只读访问）
+// @Summary 日历订阅（iCalendar），包含已确认的用餐和计划
+// @Tags 日历
+// @Produce text/calendar
+// @Param token path string true "订阅令牌（可带 .ics 后缀）"
+// @Success 200 {string} string "iCalendar 内容"
+// @Router /api/calendar/{token} [get]
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	body, err := h.calendarService.Feed(token)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCalendarToken) {
+			c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.Error(500, "生成日历失败"))
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", body)
+}
+
+// feedURL 根据当前请求的地址拼出订阅地址（支持反向代理设置的 X-Forwarded-Proto）
+func feedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + "/api/calendar/" + token + ".ics"
+}
@@ -2,7 +2,10 @@ package handler
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -38,15 +41,18 @@ func (h *DecisionHandler) Decide(c *gin.Context) {
 	}
 
 	var req model.DecideRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		// 允许空body
-		req = model.DecideRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		// 允许空body，其余解析或校验错误直接返回
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
 	}
 
-	resp, err := h.decisionService.Decide(userID, req.MenuIDs)
+	resp, err := h.decisionService.Decide(userID, &req)
 	if err != nil {
 		logger.Error("Decision failed", zap.Int64("userID", userID), zap.Error(err))
-		if errors.Is(err, service.ErrNoMenus) {
+		if errors.Is(err, service.ErrNoMenus) || errors.Is(err, service.ErrNoMenusWithinCalories) ||
+			errors.Is(err, service.ErrInvalidCombo) || errors.Is(err, service.ErrNoComboCandidates) ||
+			errors.Is(err, service.ErrComboExceedCalories) || errors.Is(err, service.ErrAllMenusBlocked) {
 			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
 			return
 		}
@@ -58,10 +64,16 @@ func (h *DecisionHandler) Decide(c *gin.Context) {
 }
 
 // History 获取历史记录
-// @Summary 获取最近5天的决策历史
+// @Summary 按决策时间倒序分页获取决策历史
 // @Tags 决策
 // @Security Bearer
 // @Produce json
+// @Param from query string false "开始日期（2006-01-02）"
+// @Param to query string false "结束日期（2006-01-02）"
+// @Param limit query int false "每页数量，默认 20，最大 100"
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param restaurant_id query int false "只看某个餐厅"
+// @Param meal_slot query string false "用餐时段：breakfast, lunch, dinner, late_night"
 // @Success 200 {object} model.Response{data=model.HistoryResponse}
 // @Router /api/history [get]
 func (h *DecisionHandler) History(c *gin.Context) {
@@ -71,11 +83,241 @@ func (h *DecisionHandler) History(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.decisionService.GetHistory(userID)
+	var req model.HistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	resp, err := h.decisionService.GetHistory(userID, &req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidDateRange) || errors.Is(err, service.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, model.Error(500, "获取历史记录失败"))
 		return
 	}
 
 	c.JSON(http.StatusOK, model.Success(resp))
 }
+
+// ExportHistory 导出决策历史
+// @Summary 导出全部决策历史（CSV/JSON Lines，每个菜品一行，逐行输出）
+// @Tags 决策
+// @Security Bearer
+// @Produce text/csv
+// @Param format query string false "导出格式：csv, jsonl（默认 csv）"
+// @Param from query string false "开始日期 2006-01-02"
+// @Param to query string false "结束日期 2006-01-02（包含当天）"
+// @Success 200 {file} file
+// @Router /api/history/export [get]
+func (h *DecisionHandler) ExportHistory(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	var req model.HistoryExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	export, err := h.decisionService.PrepareHistoryExport(userID, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDateRange) {
+			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.Error(500, "导出历史记录失败"))
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.Filename()))
+	c.Status(http.StatusOK)
+	// 已经开始输出，出错时只能记录日志（客户端会收到不完整的文件）
+	if err := export.Stream(c.Writer); err != nil {
+		logger.Error("Export history failed", zap.Int64("user_id", userID), zap.Error(err))
+	}
+}
+
+// AddHistory 手动添加决策记录
+// @Summary 手动添加决策记录（没有随机就去吃了，同样参与最近决策的权重计算）
+// @Tags 决策
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param request body model.CreateHistoryRequest true "决策记录"
+// @Success 200 {object} model.Response{data=model.DecisionRecord}
+// @Router /api/history [post]
+func (h *DecisionHandler) AddHistory(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	var req model.CreateHistoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	record, err := h.decisionService.AddHistory(userID, &req)
+	if err != nil {
+		respondHistoryError(c, err, "添加记录失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(record))
+}
+
+// UpdateHistory 修改决策记录
+// @Summary 修改决策记录（只修改提供的字段）
+// @Tags 决策
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path int true "决策记录ID"
+// @Param request body model.UpdateHistoryRequest true "要修改的字段"
+// @Success 200 {object} model.Response{data=model.DecisionRecord}
+// @Router /api/history/{id} [put]
+func (h *DecisionHandler) UpdateHistory(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "无效的记录ID"))
+		return
+	}
+
+	var req model.UpdateHistoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	record, err := h.decisionService.UpdateHistory(userID, id, &req)
+	if err != nil {
+		respondHistoryError(c, err, "修改记录失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(record))
+}
+
+// DeleteHistory 删除决策记录
+// @Summary 删除决策记录
+// @Tags 决策
+// @Security Bearer
+// @Produce json
+// @Param id path int true "决策记录ID"
+// @Success 200 {object} model.Response
+// @Router /api/history/{id} [delete]
+func (h *DecisionHandler) DeleteHistory(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "无效的记录ID"))
+		return
+	}
+
+	if err := h.decisionService.DeleteHistory(userID, id); err != nil {
+		respondHistoryError(c, err, "删除记录失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(nil))
+}
+
+// respondHistoryError 将决策记录的错误转换为响应
+func respondHistoryError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrComboMenuChange):
+		c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+	case errors.Is(err, service.ErrDecisionNotFound), errors.Is(err, service.ErrMenuNotFound):
+		c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, model.Error(500, msg))
+	}
+}
+
+// Confirm 确认决策记录
+// @Summary 确认决策（表示确实吃了，确认后计入营养统计）
+// @Tags 决策
+// @Security Bearer
+// @Produce json
+// @Param id path int true "决策记录ID"
+// @Success 200 {object} model.Response
+// @Router /api/history/{id}/confirm [post]
+func (h *DecisionHandler) Confirm(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "无效的记录ID"))
+		return
+	}
+
+	if err := h.decisionService.Confirm(userID, id); err != nil {
+		if errors.Is(err, service.ErrDecisionNotFound) {
+			c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.Error(500, "确认失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(nil))
+}
+
+// NutritionSummary 营养汇总
+// @Summary 按天汇总已确认决策的营养信息
+// @Tags 决策
+// @Security Bearer
+// @Produce json
+// @Param from query string false "开始日期（2006-01-02），默认 7 天前"
+// @Param to query string false "结束日期（2006-01-02），默认今天"
+// @Success 200 {object} model.Response{data=model.NutritionSummaryResponse}
+// @Router /api/nutrition/summary [get]
+func (h *DecisionHandler) NutritionSummary(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	var req model.NutritionSummaryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	resp, err := h.decisionService.NutritionSummary(userID, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDateRange) {
+			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.Error(500, "获取营养汇总失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(resp))
+}
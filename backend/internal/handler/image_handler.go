@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/service"
+	"what-to-eat/pkg/logger"
+)
+
+// multipartOverhead 请求体中除图片外的 multipart 边界和表单头允许占用的字节数
+const multipartOverhead = 64 << 10
+
+type ImageHandler struct {
+	imageService *service.ImageService
+}
+
+func NewImageHandler(imageService *service.ImageService) *ImageHandler {
+	return &ImageHandler{imageService: imageService}
+}
+
+// UploadMenuImage 上传菜品图片
+// @Summary 上传菜品图片（JPEG/PNG/GIF，自动生成缩略图）
+// @Tags 图片
+// @Security Bearer
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "菜单ID"
+// @Param image formData file true "图片文件"
+// @Success 200 {object} model.Response{data=model.Menu}
+// @Router /api/menus/{id}/image [post]
+func (h *ImageHandler) UploadMenuImage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "无效的菜单ID"))
+		return
+	}
+
+	f, ok := h.openImage(c)
+	if !ok {
+		return
+	}
+	defer f.Close()
+
+	menu, err := h.imageService.UploadMenuImage(id, f)
+	if err != nil {
+		respondImageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(menu))
+}
+
+// UploadRestaurantImage 上传餐厅图片
+// @Summary 上传餐厅图片（JPEG/PNG/GIF，自动生成缩略图）
+// @Tags 图片
+// @Security Bearer
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "餐厅ID"
+// @Param image formData file true "图片文件"
+// @Success 200 {object} model.Response{data=model.Restaurant}
+// @Router /api/restaurants/{id}/image [post]
+func (h *ImageHandler) UploadRestaurantImage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "无效的餐厅ID"))
+		return
+	}
+
+	f, ok := h.openImage(c)
+	if !ok {
+		return
+	}
+	defer f.Close()
+
+	restaurant, err := h.imageService.UploadRestaurantImage(id, f)
+	if err != nil {
+		respondImageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(restaurant))
+}
+
+// openImage 读取上传的图片文件，失败时已输出错误响应
+// 请求体在解析前限制大小，超过上限时不会先把整个请求缓存到内存或临时文件
+func (h *ImageHandler) openImage(c *gin.Context) (multipart.File, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.imageService.MaxSize()+multipartOverhead)
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, model.Error(413, service.ErrImageTooLarge.Error()))
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, model.Error(400, "缺少图片文件"))
+		return nil, false
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "读取图片失败"))
+		return nil, false
+	}
+	return f, true
+}
+
+// respondImageError 输出图片上传错误
+func respondImageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMenuNotFound), errors.Is(err, service.ErrRestaurantNotFound):
+		c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+	case errors.Is(err, service.ErrImageTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, model.Error(413, err.Error()))
+	case errors.Is(err, service.ErrUnsupportedImage), errors.Is(err, service.ErrImageDimensionLimit):
+		c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+	default:
+		logger.Error("Upload image failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.Error(500, "上传图片失败"))
+	}
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"what-to-eat/internal/service"
+)
+
+func TestImageHandler_UploadTooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewImageHandler(service.NewImageService(nil, nil, nil, 1024, 0))
+	r := gin.New()
+	r.POST("/api/menus/:id/image", h.UploadMenuImage)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("image", "big.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(bytes.Repeat([]byte{0xff}, 1024+multipartOverhead+1))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/menus/1/image", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -2,21 +2,32 @@ package handler
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 
 	"what-to-eat/internal/model"
 	"what-to-eat/internal/service"
+	"what-to-eat/pkg/logger"
 )
 
+// menuCreator 创建菜单用到的服务方法
+type menuCreator interface {
+	FindSimilar(req *model.CreateMenuRequest) ([]model.NameSuggestion, error)
+	Create(req *model.CreateMenuRequest) (*model.Menu, bool, error)
+}
+
 type MenuHandler struct {
 	menuService *service.MenuService
+	creator     menuCreator
 }
 
 func NewMenuHandler(menuService *service.MenuService) *MenuHandler {
-	return &MenuHandler{menuService: menuService}
+	return &MenuHandler{menuService: menuService, creator: menuService}
 }
 
 // List 获取菜单列表
@@ -44,6 +55,7 @@ func (h *MenuHandler) List(c *gin.Context) {
 // @Produce json
 // @Param request body model.CreateMenuRequest true "菜单信息"
 // @Success 200 {object} model.Response{data=model.Menu}
+// @Failure 409 {object} model.Response{data=[]model.NameSuggestion} "存在名称相似的已有条目（force=true 可强制创建）"
 // @Router /api/menus [post]
 func (h *MenuHandler) Create(c *gin.Context) {
 	var req model.CreateMenuRequest
@@ -52,12 +64,32 @@ func (h *MenuHandler) Create(c *gin.Context) {
 		return
 	}
 
-	menu, isNewRestaurant, err := h.menuService.Create(&req)
+	// 创建前查找相似的已有条目，未确认时不创建，由客户端选择已有条目或带 force 重新提交
+	if !req.Force {
+		suggestions, err := h.creator.FindSimilar(&req)
+		if err != nil {
+			logger.Warn("Find similar names failed", zap.Error(err))
+		}
+		if len(suggestions) > 0 {
+			c.JSON(http.StatusConflict, model.Response{
+				Code:    409,
+				Message: "发现名称相似的已有条目，是否要使用已有条目？",
+				Data:    suggestions,
+			})
+			return
+		}
+	}
+
+	menu, isNewRestaurant, err := h.creator.Create(&req)
 	if err != nil {
 		if errors.Is(err, service.ErrMenuExists) {
 			c.JSON(http.StatusConflict, model.Error(409, err.Error()))
 			return
 		}
+		if errors.Is(err, service.ErrInvalidCuisine) {
+			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, model.Error(500, "创建菜单失败"))
 		return
 	}
@@ -92,6 +124,79 @@ func (h *MenuHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, model.Success(nil))
 }
 
+// UpdateNutrition 更新菜品营养信息
+// @Summary 更新菜品营养信息（未传的字段会被清空）
+// @Tags 菜单
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path int true "菜单ID"
+// @Param request body model.Nutrition true "营养信息"
+// @Success 200 {object} model.Response{data=model.Menu}
+// @Router /api/menus/{id}/nutrition [put]
+func (h *MenuHandler) UpdateNutrition(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "无效的菜单ID"))
+		return
+	}
+
+	var req model.Nutrition
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	menu, err := h.menuService.UpdateNutrition(id, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrMenuNotFound) {
+			c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+			return
+		}
+		logger.Error("Update nutrition failed", zap.Int64("menuID", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.Error(500, "更新营养信息失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(menu))
+}
+
+// UpdateRole 设置菜品角色
+// @Summary 设置菜品角色（main, side, drink, dessert，为空表示清除）
+// @Tags 菜单
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path int true "菜单ID"
+// @Param request body model.UpdateMenuRoleRequest true "菜品角色"
+// @Success 200 {object} model.Response{data=model.Menu}
+// @Router /api/menus/{id}/role [put]
+func (h *MenuHandler) UpdateRole(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "无效的菜单ID"))
+		return
+	}
+
+	var req model.UpdateMenuRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	menu, err := h.menuService.UpdateRole(id, req.Role)
+	if err != nil {
+		if errors.Is(err, service.ErrMenuNotFound) {
+			c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.Error(500, "设置菜品角色失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(menu))
+}
+
 // ListRestaurants 获取餐厅列表（用于下拉选择）
 // @Summary 获取餐厅列表
 // @Tags 菜单
@@ -108,3 +213,102 @@ func (h *MenuHandler) ListRestaurants(c *gin.Context) {
 
 	c.JSON(http.StatusOK, model.Success(restaurants))
 }
+
+// 导入文件大小上限
+const maxImportSize = 5 << 20
+
+// Import 批量导入菜单
+// @Summary 批量导入餐厅和菜品（CSV/JSON/YAML）
+// @Tags 菜单
+// @Security Bearer
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file false "导入文件（也可以直接放在请求体中）"
+// @Param format query string false "文件格式：csv, json, yaml（默认按文件后缀推断）"
+// @Param dry_run query bool false "试运行，只返回报告不写入"
+// @Success 200 {object} model.Response{data=model.ImportReport}
+// @Router /api/menus/import [post]
+func (h *MenuHandler) Import(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	var (
+		reader   io.Reader = c.Request.Body
+		filename string
+	)
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, model.Error(400, "读取导入文件失败"))
+			return
+		}
+		defer f.Close()
+		reader = f
+		filename = file.Filename
+	}
+
+	format, err := resolveDatasetFormat(c.Query("format"), filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	report, err := h.menuService.Import(format, reader, dryRun)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, model.Error(413, "导入文件过大"))
+			return
+		}
+		if errors.Is(err, service.ErrUnsupportedFormat) || errors.Is(err, service.ErrInvalidCSVHeader) ||
+			errors.Is(err, service.ErrInvalidDataset) {
+			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.Error(500, "导入失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(report))
+}
+
+// Export 导出菜单
+// @Summary 导出全部餐厅和菜品（CSV/JSON/YAML）
+// @Tags 菜单
+// @Security Bearer
+// @Produce json
+// @Param format query string false "文件格式：csv, json, yaml（默认 json）"
+// @Success 200 {file} file
+// @Router /api/menus/export [get]
+func (h *MenuHandler) Export(c *gin.Context) {
+	format, err := service.ParseDatasetFormat(c.DefaultQuery("format", string(service.FormatJSON)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+		return
+	}
+
+	dataset, err := h.menuService.Export()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Error(500, "导出菜单失败"))
+		return
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="menus.%s"`, format))
+	c.Status(http.StatusOK)
+	if err := service.EncodeDataset(format, c.Writer, dataset); err != nil {
+		logger.Error("Export menus failed", zap.Error(err))
+	}
+}
+
+// resolveDatasetFormat 优先使用显式指定的格式，否则按文件名后缀推断
+func resolveDatasetFormat(format, filename string) (service.DatasetFormat, error) {
+	if format != "" {
+		return service.ParseDatasetFormat(format)
+	}
+	if filename != "" {
+		return service.DetectDatasetFormat(filename)
+	}
+	return "", service.ErrUnsupportedFormat
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"what-to-eat/internal/model"
+)
+
+// fakeMenuCreator 记录 Create 调用次数的假服务
+type fakeMenuCreator struct {
+	suggestions []model.NameSuggestion
+	created     int
+}
+
+func (f *fakeMenuCreator) FindSimilar(req *model.CreateMenuRequest) ([]model.NameSuggestion, error) {
+	return f.suggestions, nil
+}
+
+func (f *fakeMenuCreator) Create(req *model.CreateMenuRequest) (*model.Menu, bool, error) {
+	f.created++
+	return &model.Menu{ID: 10, DishName: req.DishName}, true, nil
+}
+
+func postCreateMenu(h *MenuHandler, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/menus", h.Create)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/menus", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMenuHandler_Create_SimilarConflict(t *testing.T) {
+	fake := &fakeMenuCreator{suggestions: []model.NameSuggestion{
+		{Type: model.SearchTypeRestaurant, ID: 1, Name: "麦当劳"},
+	}}
+	h := &MenuHandler{creator: fake}
+
+	w := postCreateMenu(h, `{"restaurant_name":"麦当捞","dish_name":"巨无霸"}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if fake.created != 0 {
+		t.Errorf("Create called %d times, want 0", fake.created)
+	}
+
+	var resp struct {
+		Code int                    `json:"code"`
+		Data []model.NameSuggestion `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Code != 409 || len(resp.Data) != 1 || resp.Data[0].ID != 1 {
+		t.Errorf("response = %+v, want code 409 with suggestion 1", resp)
+	}
+}
+
+func TestMenuHandler_Create_Force(t *testing.T) {
+	fake := &fakeMenuCreator{suggestions: []model.NameSuggestion{
+		{Type: model.SearchTypeRestaurant, ID: 1, Name: "麦当劳"},
+	}}
+	h := &MenuHandler{creator: fake}
+
+	w := postCreateMenu(h, `{"restaurant_name":"麦当捞","dish_name":"巨无霸","force":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if fake.created != 1 {
+		t.Errorf("Create called %d times, want 1", fake.created)
+	}
+}
+
+func TestMenuHandler_Create_NoSuggestions(t *testing.T) {
+	fake := &fakeMenuCreator{}
+	h := &MenuHandler{creator: fake}
+
+	w := postCreateMenu(h, `{"restaurant_name":"肯德基","dish_name":"原味鸡"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if fake.created != 1 {
+		t.Errorf("Create called %d times, want 1", fake.created)
+	}
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/service"
+	"what-to-eat/pkg/middleware"
+)
+
+type PreferenceHandler struct {
+	prefService *service.PreferenceService
+}
+
+func NewPreferenceHandler(prefService *service.PreferenceService) *PreferenceHandler {
+	return &PreferenceHandler{prefService: prefService}
+}
+
+// ListFavorites 获取收藏的菜品
+// @Summary 获取当前用户收藏的菜品
+// @Tags 偏好
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} model.Response{data=[]model.Menu}
+// @Router /api/favorites [get]
+func (h *PreferenceHandler) ListFavorites(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	menus, err := h.prefService.ListFavorites(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Error(500, "获取收藏失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(menus))
+}
+
+// AddFavorite 收藏菜品
+// @Summary 收藏菜品（决策时提高该菜品的权重，只影响当前用户）
+// @Tags 偏好
+// @Security Bearer
+// @Produce json
+// @Param id path int true "菜品ID"
+// @Success 200 {object} model.Response
+// @Router /api/menus/{id}/favorite [post]
+func (h *PreferenceHandler) AddFavorite(c *gin.Context) {
+	userID, id, ok := bindPreferenceTarget(c, "无效的菜品ID")
+	if !ok {
+		return
+	}
+
+	if err := h.prefService.AddFavorite(userID, id); err != nil {
+		respondPreferenceError(c, err, "收藏失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(nil))
+}
+
+// RemoveFavorite 取消收藏
+// @Summary 取消收藏菜品
+// @Tags 偏好
+// @Security Bearer
+// @Produce json
+// @Param id path int true "菜品ID"
+// @Success 200 {object} model.Response
+// @Router /api/menus/{id}/favorite [delete]
+func (h *PreferenceHandler) RemoveFavorite(c *gin.Context) {
+	userID, id, ok := bindPreferenceTarget(c, "无效的菜品ID")
+	if !ok {
+		return
+	}
+
+	if err := h.prefService.RemoveFavorite(userID, id); err != nil {
+		respondPreferenceError(c, err, "取消收藏失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(nil))
+}
+
+// ListBlocks 获取屏蔽列表
+// @Summary 获取当前用户屏蔽的菜品和餐厅
+// @Tags 偏好
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} model.Response{data=model.BlockListResponse}
+// @Router /api/blocks [get]
+func (h *PreferenceHandler) ListBlocks(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	resp, err := h.prefService.ListBlocks(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Error(500, "获取屏蔽列表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(resp))
+}
+
+// BlockMenu 屏蔽菜品
+// @Summary 屏蔽菜品（决策时不再选中，只影响当前用户）
+// @Tags 偏好
+// @Security Bearer
+// @Produce json
+// @Param id path int true "菜品ID"
+// @Success 200 {object} model.Response
+// @Router /api/menus/{id}/block [post]
+func (h *PreferenceHandler) BlockMenu(c *gin.Context) {
+	h.block(c, model.BlockTargetMenu, "无效的菜品ID")
+}
+
+// UnblockMenu 取消屏蔽菜品
+// @Summary 取消屏蔽菜品
+// @Tags 偏好
+// @Security Bearer
+// @Produce json
+// @Param id path int true "菜品ID"
+// @Success 200 {object} model.Response
+// @Router /api/menus/{id}/block [delete]
+func (h *PreferenceHandler) UnblockMenu(c *gin.Context) {
+	h.unblock(c, model.BlockTargetMenu, "无效的菜品ID")
+}
+
+// BlockRestaurant 屏蔽餐厅
+// @Summary 屏蔽餐厅（决策时不再选中该餐厅的任何菜品，只影响当前用户）
+// @Tags 偏好
+// @Security Bearer
+// @Produce json
+// @Param id path int true "餐厅ID"
+// @Success 200 {object} model.Response
+// @Router /api/restaurants/{id}/block [post]
+func (h *PreferenceHandler) BlockRestaurant(c *gin.Context) {
+	h.block(c, model.BlockTargetRestaurant, "无效的餐厅ID")
+}
+
+// UnblockRestaurant 取消屏蔽餐厅
+// @Summary 取消屏蔽餐厅
+// @Tags 偏好
+// @Security Bearer
+// @Produce json
+// @Param id path int true "餐厅ID"
+// @Success 200 {object} model.Response
+// @Router /api/restaurants/{id}/block [delete]
+func (h *PreferenceHandler) UnblockRestaurant(c *gin.Context) {
+	h.unblock(c, model.BlockTargetRestaurant, "无效的餐厅ID")
+}
+
+func (h *PreferenceHandler) block(c *gin.Context, targetType, invalidIDMsg string) {
+	userID, id, ok := bindPreferenceTarget(c, invalidIDMsg)
+	if !ok {
+		return
+	}
+
+	if err := h.prefService.Block(userID, targetType, id); err != nil {
+		respondPreferenceError(c, err, "屏蔽失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(nil))
+}
+
+func (h *PreferenceHandler) unblock(c *gin.Context, targetType, invalidIDMsg string) {
+	userID, id, ok := bindPreferenceTarget(c, invalidIDMsg)
+	if !ok {
+		return
+	}
+
+	if err := h.prefService.Unblock(userID, targetType, id); err != nil {
+		respondPreferenceError(c, err, "取消屏蔽失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(nil))
+}
+
+// bindPreferenceTarget 获取当前用户和路径中的目标ID，失败时已写入响应
+func bindPreferenceTarget(c *gin.Context, invalidIDMsg string) (int64, int64, bool) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return 0, 0, false
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, invalidIDMsg))
+		return 0, 0, false
+	}
+
+	return userID, id, true
+}
+
+// respondPreferenceError 将收藏和屏蔽的错误转换为响应
+func respondPreferenceError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrMenuNotFound), errors.Is(err, service.ErrRestaurantNotFound):
+		c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, model.Error(500, msg))
+	}
+}
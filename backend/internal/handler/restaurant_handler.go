@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -53,6 +54,10 @@ func (h *RestaurantHandler) Create(c *gin.Context) {
 
 	restaurant, err := h.restaurantService.Create(&req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidCuisine) {
+			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, model.Error(500, "创建餐厅失败"))
 		return
 	}
@@ -82,3 +87,53 @@ func (h *RestaurantHandler) Delete(c *gin.Context) {
 
 	c.JSON(http.StatusOK, model.Success(nil))
 }
+
+// UpdateCuisine 设置餐厅菜系
+// @Summary 设置餐厅菜系（为空表示清除分类）
+// @Tags 餐厅
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path int true "餐厅ID"
+// @Param request body model.UpdateCuisineRequest true "菜系"
+// @Success 200 {object} model.Response{data=model.Restaurant}
+// @Router /api/restaurants/{id}/cuisine [put]
+func (h *RestaurantHandler) UpdateCuisine(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "无效的餐厅ID"))
+		return
+	}
+
+	var req model.UpdateCuisineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	restaurant, err := h.restaurantService.UpdateCuisine(id, req.Cuisine)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCuisine):
+			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+		case errors.Is(err, service.ErrRestaurantNotFound):
+			c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, model.Error(500, "设置菜系失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(restaurant))
+}
+
+// Cuisines 获取菜系列表
+// @Summary 获取全部菜系
+// @Tags 餐厅
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} model.Response{data=[]model.CuisineOption}
+// @Router /api/cuisines [get]
+func (h *RestaurantHandler) Cuisines(c *gin.Context) {
+	c.JSON(http.StatusOK, model.Success(model.Cuisines))
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/service"
+)
+
+type SearchHandler struct {
+	searchService *service.SearchService
+}
+
+func NewSearchHandler(searchService *service.SearchService) *SearchHandler {
+	return &SearchHandler{searchService: searchService}
+}
+
+// Search 搜索餐厅和菜品
+// @Summary 搜索餐厅和菜品（支持拼音和模糊匹配）
+// @Tags 搜索
+// @Security Bearer
+// @Produce json
+// @Param q query string true "关键词，如 麦当劳、maidanglao、mdl"
+// @Param type query string false "搜索范围：all, restaurant, dish（默认 all）"
+// @Param page query int false "页码（默认 1）"
+// @Param page_size query int false "每页数量（默认 20，最大 100）"
+// @Success 200 {object} model.Response{data=model.SearchResponse}
+// @Router /api/search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	var req model.SearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	resp, err := h.searchService.Search(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Error(500, "搜索失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(resp))
+}
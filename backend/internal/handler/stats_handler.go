@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/service"
+	"what-to-eat/pkg/middleware"
+)
+
+type StatsHandler struct {
+	statsService *service.StatsService
+}
+
+func NewStatsHandler(statsService *service.StatsService) *StatsHandler {
+	return &StatsHandler{statsService: statsService}
+}
+
+// Stats 个人统计
+// @Summary 获取个人统计（最常吃的菜品和餐厅、星期分布、连续记录和多样性）
+// @Tags 统计
+// @Security Bearer
+// @Produce json
+// @Param top query int false "最常吃的菜品/餐厅数量，默认 5"
+// @Param weeks query int false "多样性统计的周数，默认 8"
+// @Success 200 {object} model.Response{data=model.StatsResponse}
+// @Router /api/stats [get]
+func (h *StatsHandler) Stats(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	var req model.StatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	resp, err := h.statsService.GetStats(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Error(500, "获取统计失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(resp))
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/service"
+	"what-to-eat/pkg/middleware"
+)
+
+type TeamHandler struct {
+	teamService *service.TeamService
+}
+
+func NewTeamHandler(teamService *service.TeamService) *TeamHandler {
+	return &TeamHandler{teamService: teamService}
+}
+
+// Create 创建团队
+// @Summary 创建团队（创建者自动加入，返回邀请码）
+// @Tags 团队
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param request body model.CreateTeamRequest true "团队信息"
+// @Success 200 {object} model.Response{data=model.Team}
+// @Router /api/teams [post]
+func (h *TeamHandler) Create(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	var req model.CreateTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	team, err := h.teamService.Create(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Error(500, "创建团队失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(team))
+}
+
+// List 获取我加入的团队
+// @Summary 获取当前用户加入的团队
+// @Tags 团队
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} model.Response{data=[]model.Team}
+// @Router /api/teams [get]
+func (h *TeamHandler) List(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	teams, err := h.teamService.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Error(500, "获取团队列表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(teams))
+}
+
+// Join 加入团队
+// @Summary 凭邀请码加入团队
+// @Tags 团队
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param request body model.JoinTeamRequest true "邀请码"
+// @Success 200 {object} model.Response{data=model.Team}
+// @Router /api/teams/join [post]
+func (h *TeamHandler) Join(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	var req model.JoinTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	team, err := h.teamService.Join(userID, req.InviteCode)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInviteCode) {
+			c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.Error(500, "加入团队失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(team))
+}
+
+// Leave 退出团队
+// @Summary 退出团队
+// @Tags 团队
+// @Security Bearer
+// @Produce json
+// @Param id path int true "团队ID"
+// @Success 200 {object} model.Response
+// @Router /api/teams/{id}/members/me [delete]
+func (h *TeamHandler) Leave(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "无效的团队ID"))
+		return
+	}
+
+	if err := h.teamService.Leave(userID, teamID); err != nil {
+		c.JSON(http.StatusInternalServerError, model.Error(500, "退出团队失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(nil))
+}
+
+// Stats 团队统计
+// @Summary 获取团队排行榜、本月最常去的餐厅和按天的热力图（只有成员可以查看）
+// @Tags 团队
+// @Security Bearer
+// @Produce json
+// @Param id path int true "团队ID"
+// @Param month query string false "统计月份（2006-01），默认本月"
+// @Param top query int false "排行榜人数，默认 5"
+// @Success 200 {object} model.Response{data=model.TeamStatsResponse}
+// @Router /api/teams/{id}/stats [get]
+func (h *TeamHandler) Stats(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "无效的团队ID"))
+		return
+	}
+
+	var req model.TeamStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	resp, err := h.teamService.Stats(userID, teamID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidMonth):
+			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+		case errors.Is(err, service.ErrNotTeamMember):
+			c.JSON(http.StatusForbidden, model.Error(403, err.Error()))
+		case errors.Is(err, service.ErrTeamNotFound):
+			c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, model.Error(500, "获取团队统计失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(resp))
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/service"
+	"what-to-eat/pkg/middleware"
+	"what-to-eat/pkg/timezone"
+)
+
+type TimeZoneHandler struct {
+	timeZoneService *service.TimeZoneService
+}
+
+func NewTimeZoneHandler(timeZoneService *service.TimeZoneService) *TimeZoneHandler {
+	return &TimeZoneHandler{timeZoneService: timeZoneService}
+}
+
+// Get 获取时区设置
+// @Summary 获取当前用户的时区设置
+// @Tags 用户
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} model.Response{data=model.TimeZoneResponse}
+// @Router /api/me/timezone [get]
+func (h *TimeZoneHandler) Get(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	resp, err := h.timeZoneService.Get(userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, model.Error(404, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.Error(500, "获取时区失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(resp))
+}
+
+// Update 设置时区
+// @Summary 设置当前用户的时区（"今天"、按天/按月统计都按该时区计算）
+// @Tags 用户
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param request body model.UpdateTimeZoneRequest true "IANA 时区名称，为空表示使用默认时区"
+// @Success 200 {object} model.Response{data=model.TimeZoneResponse}
+// @Router /api/me/timezone [put]
+func (h *TimeZoneHandler) Update(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, model.Error(401, "用户未登录"))
+		return
+	}
+
+	var req model.UpdateTimeZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Error(400, "参数错误: "+err.Error()))
+		return
+	}
+
+	resp, err := h.timeZoneService.Update(userID, req.TimeZone)
+	if err != nil {
+		if errors.Is(err, timezone.ErrInvalid) {
+			c.JSON(http.StatusBadRequest, model.Error(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.Error(500, "设置时区失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(resp))
+}
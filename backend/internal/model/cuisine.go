@@ -0,0 +1,54 @@
+package model
+
+// 菜系分类
+const (
+	CuisineFastFood  = "fast_food"
+	CuisineSichuan   = "sichuan"
+	CuisineHunan     = "hunan"
+	CuisineCantonese = "cantonese"
+	CuisineNoodles   = "noodles"
+	CuisineHotpot    = "hotpot"
+	CuisineBBQ       = "bbq"
+	CuisineJapanese  = "japanese"
+	CuisineKorean    = "korean"
+	CuisineWestern   = "western"
+	CuisineSnack     = "snack"
+	CuisineDessert   = "dessert"
+	CuisineOther     = "other"
+)
+
+// Cuisines 全部菜系（按展示顺序）
+var Cuisines = []CuisineOption{
+	{Key: CuisineFastFood, Label: "快餐"},
+	{Key: CuisineSichuan, Label: "川菜"},
+	{Key: CuisineHunan, Label: "湘菜"},
+	{Key: CuisineCantonese, Label: "粤菜"},
+	{Key: CuisineNoodles, Label: "面食"},
+	{Key: CuisineHotpot, Label: "火锅"},
+	{Key: CuisineBBQ, Label: "烧烤"},
+	{Key: CuisineJapanese, Label: "日料"},
+	{Key: CuisineKorean, Label: "韩餐"},
+	{Key: CuisineWestern, Label: "西餐"},
+	{Key: CuisineSnack, Label: "小吃"},
+	{Key: CuisineDessert, Label: "甜品饮品"},
+	{Key: CuisineOther, Label: "其他"},
+}
+
+// CuisineOption 菜系选项
+type CuisineOption struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// IsValidCuisine 判断是否为已知菜系，空字符串表示未分类，也视为有效
+func IsValidCuisine(cuisine string) bool {
+	if cuisine == "" {
+		return true
+	}
+	for _, c := range Cuisines {
+		if c.Key == cuisine {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package model
+
+// MenuDataset 菜单数据集（批量导入/导出的通用格式，JSON/YAML 使用）
+type MenuDataset struct {
+	Restaurants []DatasetRestaurant `json:"restaurants" yaml:"restaurants"`
+}
+
+// DatasetRestaurant 数据集中的餐厅
+type DatasetRestaurant struct {
+	Name         string        `json:"name" yaml:"name"`
+	Cuisine      string        `json:"cuisine,omitempty" yaml:"cuisine,omitempty"`
+	ImageURL     string        `json:"image_url,omitempty" yaml:"image_url,omitempty"`
+	ThumbnailURL string        `json:"thumbnail_url,omitempty" yaml:"thumbnail_url,omitempty"`
+	Dishes       []DatasetDish `json:"dishes,omitempty" yaml:"dishes,omitempty"`
+}
+
+// DatasetDish 数据集中的菜品
+type DatasetDish struct {
+	Name         string     `json:"name" yaml:"name"`
+	Role         string     `json:"role,omitempty" yaml:"role,omitempty"`
+	Nutrition    *Nutrition `json:"nutrition,omitempty" yaml:"nutrition,omitempty"`
+	ImageURL     string     `json:"image_url,omitempty" yaml:"image_url,omitempty"`
+	ThumbnailURL string     `json:"thumbnail_url,omitempty" yaml:"thumbnail_url,omitempty"`
+}
+
+// ImportRow 导入行（数据集展开后的一条 餐厅/菜品 记录）
+type ImportRow struct {
+	Line            int    // 行号（CSV 为文件行号，JSON/YAML 为展开后的序号）
+	RestaurantName  string // 餐厅名称
+	Cuisine         string // 餐厅菜系（可选）
+	DishName        string // 菜品名称（为空表示只确保餐厅存在）
+	Role            string // 菜品角色（可选）
+	Nutrition       *Nutrition
+	RestaurantImage Image  // 餐厅图片（可选）
+	DishImage       Image  // 菜品图片（可选）
+	Error           string // 解析错误（如数值格式不正确），不为空时该行直接记为失败
+}
+
+// Image 图片地址（导入导出时使用，图片文件本身不随数据集迁移）
+type Image struct {
+	URL          string
+	ThumbnailURL string
+}
+
+// IsEmpty 是否没有图片
+func (i Image) IsEmpty() bool {
+	return i.URL == "" && i.ThumbnailURL == ""
+}
+
+// 导入行状态
+const (
+	ImportStatusCreated = "created"
+	ImportStatusSkipped = "skipped"
+	ImportStatusError   = "error"
+)
+
+// ImportRowResult 单行导入结果
+type ImportRowResult struct {
+	Line           int    `json:"line"`
+	RestaurantName string `json:"restaurant_name"`
+	DishName       string `json:"dish_name,omitempty"`
+	Status         string `json:"status"` // created, skipped, error
+	Message        string `json:"message,omitempty"`
+	MenuID         int64  `json:"menu_id,omitempty"`
+}
+
+// ImportReport 批量导入报告
+type ImportReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
@@ -4,45 +4,300 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+
+	"what-to-eat/pkg/normalize"
 )
 
 // User 用户模型
 type User struct {
-	ID           int64     `json:"id" gorm:"primaryKey;autoIncrement"`
-	Username     string    `json:"username" gorm:"type:varchar(50);not null;uniqueIndex"`
-	PasswordHash string    `json:"-" gorm:"type:varchar(255);not null"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Username     string     `json:"username" gorm:"type:varchar(50);not null;uniqueIndex"`
+	PasswordHash string     `json:"-" gorm:"type:varchar(255);not null"`
+	TimeZone     string     `json:"time_zone" gorm:"type:varchar(64);not null;default:''"`        // IANA 时区名称，为空表示使用服务器默认时区
+	CalendarKey  string     `json:"-" gorm:"type:varchar(64);not null;default:'';index"`          // 日历订阅令牌的 SHA-256，为空表示未开启订阅
+	Role         string     `json:"role" gorm:"type:varchar(16);not null;default:'member';index"` // admin, member, guest
+	DeviceKey    *string    `json:"-" gorm:"type:varchar(64);uniqueIndex"`                        // 游客绑定的设备标识的 SHA-256，注册用户为空
+	ResetKey     *string    `json:"-" gorm:"type:varchar(64);uniqueIndex"`                        // 管理员签发的一次性密码重置令牌的 SHA-256
+	ResetExpires *time.Time `json:"-"`                                                            // 密码重置令牌的过期时间
+	LastSeenAt   *time.Time `json:"last_seen_at"`                                                 // 最近一次登录或刷新令牌的时间
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// 用户角色：管理员可以管理用户和合并、删除菜品；成员可以编辑共享菜单；游客（每个设备一个，长期不活跃会被清理）对共享菜单只读
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	RoleGuest  = "guest"
+)
+
+// IsGuest 是否为游客
+func (u *User) IsGuest() bool {
+	return u.Role == RoleGuest
 }
 
-// Restaurant 餐厅模型
+// Restaurant 餐厅模型 - 支持软删除
 type Restaurant struct {
-	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name      string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Menus     []Menu    `json:"menus,omitempty" gorm:"foreignKey:RestaurantID;constraint:false"`
+	ID           int64          `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name         string         `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"`
+	NameKey      string         `json:"-" gorm:"type:varchar(100);not null;default:'';index:idx_name_key"`   // 规范化后的名称，用于查重
+	NamePinyin   string         `json:"-" gorm:"type:varchar(600);not null;default:''"`                      // 名称的拼音全拼，用于搜索
+	NameInitials string         `json:"-" gorm:"type:varchar(100);not null;default:''"`                      // 名称的拼音首字母，用于搜索
+	Cuisine      string         `json:"cuisine,omitempty" gorm:"type:varchar(32);not null;default:'';index"` // 菜系（见 Cuisines），为空表示未分类
+	MergedIntoID *int64         `json:"-" gorm:"index"`                                                      // 被合并到的餐厅ID（合并后源餐厅软删除）
+	ImageKey     string         `json:"-" gorm:"type:varchar(255);not null;default:''"`                      // 图片存储键
+	ImageURL     string         `json:"image_url,omitempty" gorm:"type:varchar(255);not null;default:''"`
+	ThumbnailURL string         `json:"thumbnail_url,omitempty" gorm:"type:varchar(255);not null;default:''"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"` // 软删除字段
+	Menus        []Menu         `json:"menus,omitempty" gorm:"foreignKey:RestaurantID;constraint:false"`
 }
 
 // Menu 菜单模型（菜品）- 支持软删除
 type Menu struct {
 	ID           int64          `json:"id" gorm:"primaryKey;autoIncrement"`
-	RestaurantID int64          `json:"restaurant_id" gorm:"not null;index:idx_restaurant"`
+	RestaurantID int64          `json:"restaurant_id" gorm:"not null;index:idx_restaurant;index:idx_restaurant_dish_key"`
 	DishName     string         `json:"dish_name" gorm:"type:varchar(100);not null"`
+	DishNameKey  string         `json:"-" gorm:"type:varchar(100);not null;default:'';index:idx_restaurant_dish_key"` // 规范化后的菜品名，用于查重
+	DishPinyin   string         `json:"-" gorm:"type:varchar(600);not null;default:''"`                               // 菜品名的拼音全拼，用于搜索
+	DishInitials string         `json:"-" gorm:"type:varchar(100);not null;default:''"`                               // 菜品名的拼音首字母，用于搜索
+	ImageKey     string         `json:"-" gorm:"type:varchar(255);not null;default:''"`                               // 图片存储键
+	ImageURL     string         `json:"image_url,omitempty" gorm:"type:varchar(255);not null;default:''"`
+	ThumbnailURL string         `json:"thumbnail_url,omitempty" gorm:"type:varchar(255);not null;default:''"`
+	Role         string         `json:"role,omitempty" gorm:"type:varchar(16);not null;default:''"` // 菜品角色（main, side, drink, dessert），组合决策时使用
+	Nutrition    Nutrition      `json:"nutrition" gorm:"embedded"`                                  // 营养信息（可选）
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"` // 软删除字段
 	Restaurant   Restaurant     `json:"restaurant,omitempty" gorm:"foreignKey:RestaurantID;constraint:false"`
 }
 
+// 菜品角色
+const (
+	MenuRoleMain    = "main"    // 主食/主菜
+	MenuRoleSide    = "side"    // 小食/配菜
+	MenuRoleDrink   = "drink"   // 饮品
+	MenuRoleDessert = "dessert" // 甜点
+)
+
+// IsValidMenuRole 判断是否为已知菜品角色，空字符串表示未设置，也视为有效
+func IsValidMenuRole(role string) bool {
+	switch role {
+	case "", MenuRoleMain, MenuRoleSide, MenuRoleDrink, MenuRoleDessert:
+		return true
+	}
+	return false
+}
+
+// Nutrition 营养信息（每份，均为可选）
+type Nutrition struct {
+	Kcal    *int     `json:"kcal,omitempty" yaml:"kcal,omitempty" binding:"omitempty,min=0,max=10000"`      // 热量（千卡）
+	Protein *float64 `json:"protein,omitempty" yaml:"protein,omitempty" binding:"omitempty,min=0,max=1000"` // 蛋白质（克）
+	Carbs   *float64 `json:"carbs,omitempty" yaml:"carbs,omitempty" binding:"omitempty,min=0,max=1000"`     // 碳水化合物（克）
+	Fat     *float64 `json:"fat,omitempty" yaml:"fat,omitempty" binding:"omitempty,min=0,max=1000"`         // 脂肪（克）
+}
+
+// IsEmpty 是否没有任何营养信息
+func (n *Nutrition) IsEmpty() bool {
+	return n == nil || (n.Kcal == nil && n.Protein == nil && n.Carbs == nil && n.Fat == nil)
+}
+
+// 决策模式
+const (
+	DecideModeSingle = "single" // 单个菜品
+	DecideModeCombo  = "combo"  // 组合：同一餐厅的多个菜品
+)
+
+// 用餐时段（按决策时间的小时划分）
+const (
+	MealSlotBreakfast = "breakfast"  // 05:00-10:00
+	MealSlotLunch     = "lunch"      // 10:00-15:00
+	MealSlotDinner    = "dinner"     // 15:00-21:00
+	MealSlotLateNight = "late_night" // 21:00-05:00
+)
+
+// MealSlotHours 用餐时段的小时范围 [Start, End)，Start > End 表示跨越午夜
+var MealSlotHours = map[string]struct{ Start, End int }{
+	MealSlotBreakfast: {5, 10},
+	MealSlotLunch:     {10, 15},
+	MealSlotDinner:    {15, 21},
+	MealSlotLateNight: {21, 5},
+}
+
+// MealSlotOf 返回时间所属的用餐时段
+func MealSlotOf(t time.Time) string {
+	hour := t.Hour()
+	for _, slot := range []string{MealSlotBreakfast, MealSlotLunch, MealSlotDinner} {
+		if h := MealSlotHours[slot]; hour >= h.Start && hour < h.End {
+			return slot
+		}
+	}
+	return MealSlotLateNight
+}
+
+// MealSlotWindow 返回时间所属用餐时段的起止时间（与 t 同一时区）
+// 夜宵跨越午夜，凌晨的记录属于前一天 21:00 开始的时段
+func MealSlotWindow(t time.Time) (start, end time.Time) {
+	h := MealSlotHours[MealSlotOf(t)]
+	day := t.Day()
+	if h.Start > h.End && t.Hour() < h.End {
+		day--
+	}
+	start = time.Date(t.Year(), t.Month(), day, h.Start, 0, 0, 0, t.Location())
+	endDay := day
+	if h.Start > h.End {
+		endDay++
+	}
+	end = time.Date(t.Year(), t.Month(), endDay, h.End, 0, 0, 0, t.Location())
+	return start, end
+}
+
+// 决策记录来源
+const (
+	DecisionOriginSpin   = "spin"   // 随机决策
+	DecisionOriginManual = "manual" // 手动补记（没有随机就去吃了）
+	DecisionOriginPlan   = "plan"   // 计划
+	DecisionOriginGroup  = "group"  // 团体聚餐
+)
+
 // DecisionRecord 决策记录模型
+// 所有来源的记录都参与最近决策的权重计算
 type DecisionRecord struct {
+	ID           int64          `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID       int64          `json:"user_id" gorm:"not null;index:idx_user_decided"`
+	MenuID       int64          `json:"menu_id" gorm:"not null"` // 单个菜品，组合决策时为第一个菜品
+	Mode         string         `json:"mode" gorm:"type:varchar(16);not null;default:'single'"`
+	DecidedAt    time.Time      `json:"decided_at" gorm:"index:idx_user_decided"`
+	ConfirmedAt  *time.Time     `json:"confirmed_at,omitempty"`                                      // 确认时间（确认后计入营养统计）
+	RecentRepeat bool           `json:"recent_repeat" gorm:"not null;default:false"`                 // 选中了最近决策中出现过的菜品（降低权重后仍被选中）
+	Origin       string         `json:"origin" gorm:"type:varchar(16);not null;default:'spin'"`      // 来源（spin, manual, plan, group）
+	Note         string         `json:"note,omitempty" gorm:"type:varchar(255);not null;default:''"` // 手动记录的原因备注
+	User         User           `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:false"`
+	Menu         Menu           `json:"menu,omitempty" gorm:"foreignKey:MenuID;constraint:false"`
+	Items        []DecisionItem `json:"items,omitempty" gorm:"foreignKey:DecisionID;constraint:false"` // 组合决策的全部菜品
+}
+
+// DecisionItem 组合决策中的单个菜品
+type DecisionItem struct {
+	ID         int64  `json:"id" gorm:"primaryKey;autoIncrement"`
+	DecisionID int64  `json:"decision_id" gorm:"not null;index"`
+	MenuID     int64  `json:"menu_id" gorm:"not null;index"`
+	Role       string `json:"role,omitempty" gorm:"type:varchar(16);not null;default:''"` // 按哪个角色要求选出，为空表示补足数量时选出
+	Menu       Menu   `json:"menu,omitempty" gorm:"foreignKey:MenuID;constraint:false"`
+}
+
+// DecisionAggregate 按月汇总的决策统计
+// 超过保留期限的决策记录汇总到这里后删除，个人和团队统计把汇总和原始记录合并计算；月份和星期按汇总时用户的时区计算
+type DecisionAggregate struct {
+	ID      int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID  int64     `json:"user_id" gorm:"not null;uniqueIndex:idx_user_month_menu_weekday,priority:1"`
+	Month   time.Time `json:"month" gorm:"type:date;not null;uniqueIndex:idx_user_month_menu_weekday,priority:2"` // 当月第一天
+	MenuID  int64     `json:"menu_id" gorm:"not null;index;uniqueIndex:idx_user_month_menu_weekday,priority:3"`
+	Weekday int       `json:"weekday" gorm:"type:tinyint;not null;uniqueIndex:idx_user_month_menu_weekday,priority:4"` // 0 表示星期日
+	Meals   int64     `json:"meals" gorm:"not null;default:0"`                                                         // 以该菜品为主记录菜品的用餐次数（组合决策为第一个菜品）
+	Dishes  int64     `json:"dishes" gorm:"not null;default:0"`                                                        // 吃过该菜品的次数（组合决策的每个菜品各计一次）
+	Repeats int64     `json:"repeats" gorm:"not null;default:0"`                                                       // Meals 中选中了最近吃过的菜品的次数
+}
+
+// Menus 决策包含的全部菜品（单个菜品决策只有一个）
+func (r *DecisionRecord) Menus() []Menu {
+	if len(r.Items) == 0 {
+		return []Menu{r.Menu}
+	}
+	menus := make([]Menu, 0, len(r.Items))
+	for _, item := range r.Items {
+		menus = append(menus, item.Menu)
+	}
+	return menus
+}
+
+// UserFavorite 用户收藏的菜品（提高该用户决策时的权重）
+type UserFavorite struct {
 	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement"`
-	UserID    int64     `json:"user_id" gorm:"not null;index:idx_user_decided"`
-	MenuID    int64     `json:"menu_id" gorm:"not null"`
-	DecidedAt time.Time `json:"decided_at" gorm:"index:idx_user_decided"`
-	User      User      `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:false"`
-	Menu      Menu      `json:"menu,omitempty" gorm:"foreignKey:MenuID;constraint:false"`
+	UserID    int64     `json:"user_id" gorm:"not null;uniqueIndex:idx_user_favorite"`
+	MenuID    int64     `json:"menu_id" gorm:"not null;uniqueIndex:idx_user_favorite;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 屏蔽目标类型
+const (
+	BlockTargetMenu       = "menu"
+	BlockTargetRestaurant = "restaurant"
+)
+
+// UserBlock 用户屏蔽的菜品或餐厅（从该用户的决策候选中移除）
+type UserBlock struct {
+	ID         int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID     int64     `json:"user_id" gorm:"not null;uniqueIndex:idx_user_block"`
+	TargetType string    `json:"target_type" gorm:"type:varchar(16);not null;uniqueIndex:idx_user_block;index:idx_block_target"` // menu, restaurant
+	TargetID   int64     `json:"target_id" gorm:"not null;uniqueIndex:idx_user_block;index:idx_block_target"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Team 团队（成员之间共享排行榜和统计）
+type Team struct {
+	ID         int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name       string    `json:"name" gorm:"type:varchar(100);not null"`
+	InviteCode string    `json:"invite_code" gorm:"type:varchar(32);not null;uniqueIndex"` // 邀请码，凭邀请码加入团队
+	OwnerID    int64     `json:"owner_id" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TeamMember 团队成员
+type TeamMember struct {
+	ID       int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	TeamID   int64     `json:"team_id" gorm:"not null;uniqueIndex:idx_team_member"`
+	UserID   int64     `json:"user_id" gorm:"not null;uniqueIndex:idx_team_member;index"`
+	JoinedAt time.Time `json:"joined_at" gorm:"autoCreateTime"`
+}
+
+// AuditLog 审计日志（记录管理员的合并等操作）
+type AuditLog struct {
+	ID         int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ActorID    int64     `json:"actor_id" gorm:"not null;index"`
+	Action     string    `json:"action" gorm:"type:varchar(50);not null"`      // 操作类型，如 merge_restaurant
+	TargetType string    `json:"target_type" gorm:"type:varchar(50);not null"` // 目标类型，如 restaurant, menu
+	TargetID   int64     `json:"target_id" gorm:"not null"`
+	SourceID   int64     `json:"source_id"`
+	Detail     string    `json:"detail" gorm:"type:text"` // 操作详情（JSON）
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+// RefreshToken 刷新令牌（数据库只保存令牌的 SHA-256）
+// 每次刷新都会把旧令牌标记为已使用并签发同一家族的新令牌；已使用的令牌再次出现说明可能被盗用，整个家族随即作废
+type RefreshToken struct {
+	ID        int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    int64      `json:"user_id" gorm:"not null;index"`
+	FamilyID  string     `json:"family_id" gorm:"type:varchar(64);not null;index"` // 同一次登录轮换出的令牌属于同一家族
+	TokenHash string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`    // 已轮换的时间，为空表示仍可使用
+	RevokedAt *time.Time `json:"revoked_at"` // 作废的时间
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// SchemaMigration 已执行的数据迁移（只记录无法由 AutoMigrate 完成、且只能执行一次的数据转换）
+type SchemaMigration struct {
+	Version   string    `json:"version" gorm:"type:varchar(64);primaryKey"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// BeforeSave 保存前规范化餐厅名称并生成查重键和拼音搜索键
+func (r *Restaurant) BeforeSave(tx *gorm.DB) error {
+	r.Name = normalize.Name(r.Name)
+	r.NameKey = normalize.Key(r.Name)
+	r.NamePinyin, r.NameInitials = normalize.Pinyin(r.NameKey)
+	return nil
+}
+
+// BeforeSave 保存前规范化菜品名称并生成查重键和拼音搜索键
+func (m *Menu) BeforeSave(tx *gorm.DB) error {
+	m.DishName = normalize.Name(m.DishName)
+	m.DishNameKey = normalize.Key(m.DishName)
+	m.DishPinyin, m.DishInitials = normalize.Pinyin(m.DishNameKey)
+	return nil
 }
 
 // TableName 指定表名
@@ -61,3 +316,39 @@ func (Menu) TableName() string {
 func (DecisionRecord) TableName() string {
 	return "decision_records"
 }
+
+func (DecisionItem) TableName() string {
+	return "decision_items"
+}
+
+func (DecisionAggregate) TableName() string {
+	return "decision_aggregates"
+}
+
+func (UserFavorite) TableName() string {
+	return "user_favorites"
+}
+
+func (UserBlock) TableName() string {
+	return "user_blocks"
+}
+
+func (Team) TableName() string {
+	return "teams"
+}
+
+func (TeamMember) TableName() string {
+	return "team_members"
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
@@ -2,6 +2,9 @@ package model
 
 import (
 	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
 )
 
 func TestUser_TableName(t *testing.T) {
@@ -200,3 +203,101 @@ func TestCreateRestaurantRequest_Validation(t *testing.T) {
 		})
 	}
 }
+
+func TestIsValidCuisine(t *testing.T) {
+	tests := []struct {
+		cuisine string
+		valid   bool
+	}{
+		{cuisine: "", valid: true},
+		{cuisine: CuisineFastFood, valid: true},
+		{cuisine: CuisineHotpot, valid: true},
+		{cuisine: "burger", valid: false},
+		{cuisine: "Fast_Food", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cuisine, func(t *testing.T) {
+			if got := IsValidCuisine(tt.cuisine); got != tt.valid {
+				t.Errorf("IsValidCuisine(%q) = %v, want %v", tt.cuisine, got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestMealSlotOf(t *testing.T) {
+	tests := []struct {
+		hour int
+		want string
+	}{
+		{4, MealSlotLateNight},
+		{5, MealSlotBreakfast},
+		{9, MealSlotBreakfast},
+		{10, MealSlotLunch},
+		{14, MealSlotLunch},
+		{15, MealSlotDinner},
+		{20, MealSlotDinner},
+		{21, MealSlotLateNight},
+		{0, MealSlotLateNight},
+	}
+
+	for _, tt := range tests {
+		at := time.Date(2024, 3, 1, tt.hour, 30, 0, 0, time.UTC)
+		if got := MealSlotOf(at); got != tt.want {
+			t.Errorf("MealSlotOf(%02d:30) = %v, want %v", tt.hour, got, tt.want)
+		}
+	}
+}
+
+func TestCreateHistoryRequest_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   CreateHistoryRequest
+		valid bool
+	}{
+		{name: "manual by default", req: CreateHistoryRequest{MenuID: 1}, valid: true},
+		{name: "group with note", req: CreateHistoryRequest{MenuID: 1, Origin: DecisionOriginGroup, Note: "部门聚餐"}, valid: true},
+		{name: "missing menu", req: CreateHistoryRequest{Origin: DecisionOriginManual}, valid: false},
+		{name: "spin is not manual", req: CreateHistoryRequest{MenuID: 1, Origin: DecisionOriginSpin}, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := binding.Validator.ValidateStruct(&tt.req)
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateStruct() error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestMealSlotWindow(t *testing.T) {
+	tests := []struct {
+		at        time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			at:        time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+			wantStart: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC),
+		},
+		{
+			at:        time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2024, 3, 1, 21, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 3, 2, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			at:        time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC), // 凌晨属于前一天的夜宵
+			wantStart: time.Date(2024, 2, 29, 21, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 3, 1, 5, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		start, end := MealSlotWindow(tt.at)
+		if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+			t.Errorf("MealSlotWindow(%v) = [%v, %v), want [%v, %v)", tt.at, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package model
 
-// RegisterRequest 注册请求
+import "time"
+
+// RegisterRequest 注册请求（密码需要符合密码策略）
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=2,max=50"`
-	Password string `json:"password" binding:"required,min=6,max=50"`
+	Password string `json:"password" binding:"required"`
 }
 
 // LoginRequest 登录请求
@@ -12,20 +14,166 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// GuestLoginRequest 游客登录请求
+// DeviceID 由客户端首次启动时随机生成并持久保存，同一设备始终使用同一个游客
+type GuestLoginRequest struct {
+	DeviceID string `json:"device_id" binding:"required,min=16,max=128"`
+}
+
+// UpgradeRequest 游客升级请求
+// Merge 为 false 时游客转为以 Username 注册的新用户；为 true 时合并到已有账号 Username（Password 为该账号的密码）
+type UpgradeRequest struct {
+	Username string `json:"username" binding:"required,min=2,max=50"`
+	Password string `json:"password" binding:"required"` // 注册新账号时需要符合密码策略
+	Merge    bool   `json:"merge"`
+}
+
+// ChangePasswordRequest 修改密码请求（新密码需要符合密码策略）
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ResetPasswordRequest 凭管理员签发的一次性令牌重置密码
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 // CreateRestaurantRequest 创建餐厅请求
 type CreateRestaurantRequest struct {
-	Name string `json:"name" binding:"required,min=1,max=100"`
+	Name    string `json:"name" binding:"required,min=1,max=100"`
+	Cuisine string `json:"cuisine"` // 菜系（可选）
+}
+
+// UpdateCuisineRequest 设置餐厅菜系请求（为空表示清除分类）
+type UpdateCuisineRequest struct {
+	Cuisine string `json:"cuisine"`
 }
 
 // CreateMenuRequest 创建菜单请求（同时包含餐厅信息）
 type CreateMenuRequest struct {
-	RestaurantID   int64  `json:"restaurant_id"`                                    // 餐厅ID（可选，如果提供则使用现有餐厅）
-	RestaurantName string `json:"restaurant_name" binding:"required,min=1,max=100"` // 餐厅名称（必填，用于查找或创建）
-	DishName       string `json:"dish_name" binding:"required,min=1,max=100"`       // 菜品名称（必填）
+	RestaurantID   int64      `json:"restaurant_id"`                                          // 餐厅ID（可选，如果提供则使用现有餐厅）
+	RestaurantName string     `json:"restaurant_name" binding:"required,min=1,max=100"`       // 餐厅名称（必填，用于查找或创建）
+	DishName       string     `json:"dish_name" binding:"required,min=1,max=100"`             // 菜品名称（必填）
+	Nutrition      *Nutrition `json:"nutrition"`                                              // 营养信息（可选）
+	Cuisine        string     `json:"cuisine"`                                                // 餐厅菜系（可选，仅在餐厅尚未分类时生效）
+	Role           string     `json:"role" binding:"omitempty,oneof=main side drink dessert"` // 菜品角色（可选）
+	Force          bool       `json:"force"`                                                  // 确认创建（忽略相似名称提示）
+}
+
+// UpdateMenuRoleRequest 设置菜品角色请求（为空表示清除）
+type UpdateMenuRoleRequest struct {
+	Role string `json:"role" binding:"omitempty,oneof=main side drink dessert"`
 }
 
 // DecideRequest 决策请求
 type DecideRequest struct {
 	// 可选：指定参与决策的菜单ID列表，为空则使用全部菜单
 	MenuIDs []int64 `json:"menu_ids"`
+	// 可选：热量上限（千卡），设置后只在已填写热量的菜品中选择；组合模式下限制整单总热量
+	MaxKcal *int `json:"max_kcal" binding:"omitempty,min=1"`
+	// 可选：决策模式，single（默认）选一个菜品，combo 选同一餐厅的一组菜品
+	Mode string `json:"mode" binding:"omitempty,oneof=single combo"`
+	// 可选：组合模式的菜品数量，默认取 2 和角色要求数量中的较大值
+	Size int `json:"size" binding:"omitempty,min=1,max=10"`
+	// 可选：组合模式的角色要求，每项选出一个对应角色的菜品，如 ["main", "side"]
+	Roles []string `json:"roles" binding:"omitempty,max=10,dive,oneof=main side drink dessert"`
+}
+
+// NutritionSummaryRequest 营养汇总请求（查询参数，日期格式 2006-01-02，包含首尾）
+type NutritionSummaryRequest struct {
+	From string `form:"from"` // 开始日期，默认 7 天前
+	To   string `form:"to"`   // 结束日期，默认今天
+}
+
+// HistoryRequest 历史记录查询请求（查询参数，日期格式 2006-01-02，包含首尾）
+type HistoryRequest struct {
+	From         string `form:"from"`                                                                  // 开始日期（可选）
+	To           string `form:"to"`                                                                    // 结束日期（可选）
+	Limit        int    `form:"limit" binding:"omitempty,min=1,max=100"`                               // 每页数量，默认 20
+	Cursor       string `form:"cursor"`                                                                // 上一页返回的 next_cursor
+	RestaurantID int64  `form:"restaurant_id" binding:"omitempty,min=1"`                               // 只看某个餐厅
+	MealSlot     string `form:"meal_slot" binding:"omitempty,oneof=breakfast lunch dinner late_night"` // 只看某个用餐时段
+}
+
+// HistoryExportRequest 导出决策历史请求（查询参数）
+type HistoryExportRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv jsonl"` // 导出格式，默认 csv
+	From   string `form:"from"`                                       // 开始日期（可选）
+	To     string `form:"to"`                                         // 结束日期（可选，包含当天）
+}
+
+// CreateHistoryRequest 手动添加决策记录请求
+type CreateHistoryRequest struct {
+	MenuID    int64      `json:"menu_id" binding:"required,min=1"`
+	DecidedAt *time.Time `json:"decided_at"`                                         // 用餐时间，默认当前时间
+	Origin    string     `json:"origin" binding:"omitempty,oneof=manual plan group"` // 来源，默认 manual
+	Note      string     `json:"note" binding:"max=255"`                             // 原因备注
+}
+
+// UpdateHistoryRequest 修改决策记录请求（只修改提供的字段）
+type UpdateHistoryRequest struct {
+	MenuID    *int64     `json:"menu_id" binding:"omitempty,min=1"` // 组合决策不能修改菜品
+	DecidedAt *time.Time `json:"decided_at"`
+	Origin    *string    `json:"origin" binding:"omitempty,oneof=spin manual plan group"`
+	Note      *string    `json:"note" binding:"omitempty,max=255"`
+}
+
+// StatsRequest 统计请求（查询参数）
+type StatsRequest struct {
+	Top   int `form:"top" binding:"omitempty,min=1,max=50"`   // 最常吃的菜品/餐厅数量，默认 5
+	Weeks int `form:"weeks" binding:"omitempty,min=1,max=52"` // 多样性统计的周数，默认 8
+}
+
+// CreateTeamRequest 创建团队请求
+type CreateTeamRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+// JoinTeamRequest 加入团队请求
+type JoinTeamRequest struct {
+	InviteCode string `json:"invite_code" binding:"required,max=32"`
+}
+
+// TeamStatsRequest 团队统计请求（查询参数）
+type TeamStatsRequest struct {
+	Month string `form:"month"`                                // 统计月份（2006-01），默认本月
+	Top   int    `form:"top" binding:"omitempty,min=1,max=50"` // 排行榜人数，默认 5
+}
+
+// MergeRequest 合并请求（将 source 合并到路径中的目标）
+type MergeRequest struct {
+	SourceID int64 `json:"source_id" binding:"required,min=1"`
+}
+
+// SearchRequest 搜索请求（查询参数）
+type SearchRequest struct {
+	Query    string `form:"q" binding:"required,max=100"`                       // 搜索关键词（支持拼音全拼和首字母）
+	Type     string `form:"type" binding:"omitempty,oneof=all restaurant dish"` // 搜索范围，默认 all
+	Page     int    `form:"page" binding:"omitempty,min=1"`                     // 页码，从 1 开始
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`        // 每页数量，默认 20
+}
+
+// UserListRequest 用户列表请求（查询参数，管理员使用）
+type UserListRequest struct {
+	Role     string `form:"role" binding:"omitempty,oneof=admin member guest"` // 按角色筛选
+	Query    string `form:"q" binding:"max=50"`                                // 按用户名模糊匹配
+	Page     int    `form:"page" binding:"omitempty,min=1"`                    // 页码，从 1 开始
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`       // 每页数量，默认 20
+}
+
+// UpdateRoleRequest 设置用户角色请求（游客只能通过升级成为注册用户）
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin member"`
+}
+
+// UpdateTimeZoneRequest 设置时区请求
+type UpdateTimeZoneRequest struct {
+	TimeZone string `json:"time_zone" binding:"max=64"` // IANA 时区名称，如 Asia/Shanghai；为空表示使用服务器默认时区
 }
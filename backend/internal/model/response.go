@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // Response 通用响应结构
 type Response struct {
 	Code    int         `json:"code"`
@@ -9,21 +11,198 @@ type Response struct {
 
 // LoginResponse 登录响应
 type LoginResponse struct {
-	Token    string `json:"token"`
-	UserID   int64  `json:"user_id"`
-	Username string `json:"username"`
+	Token        string `json:"token"`         // 访问令牌（JWT）
+	ExpiresIn    int64  `json:"expires_in"`    // 访问令牌有效期（秒）
+	RefreshToken string `json:"refresh_token"` // 刷新令牌，只能使用一次
+	UserID       int64  `json:"user_id"`
+	Username     string `json:"username"`
+	Role         string `json:"role"` // admin, member, guest
 }
 
 // DecideResponse 决策响应
 type DecideResponse struct {
-	Menu    Menu   `json:"menu"`
-	Message string `json:"message"`
+	Mode       string         `json:"mode"`
+	Menu       Menu           `json:"menu"`                 // 选中的菜品，组合模式下为第一个菜品
+	Restaurant *Restaurant    `json:"restaurant,omitempty"` // 组合模式选中的餐厅
+	Items      []DecisionItem `json:"items,omitempty"`      // 组合模式选中的全部菜品
+	TotalKcal  *int           `json:"total_kcal,omitempty"` // 组合模式的总热量（所有菜品都填写了热量时返回）
+	Message    string         `json:"message"`
 }
 
-// HistoryResponse 历史记录响应
+// HistoryResponse 历史记录响应（按决策时间倒序分页）
 type HistoryResponse struct {
-	Records []DecisionRecord `json:"records"`
-	Total   int64            `json:"total"`
+	Records    []DecisionRecord `json:"records"`
+	Total      int64            `json:"total"`                 // 符合筛选条件的记录总数（不受游标影响）
+	NextCursor string           `json:"next_cursor,omitempty"` // 下一页游标，为空表示没有更多
+	HasMore    bool             `json:"has_more"`
+}
+
+// BlockListResponse 用户屏蔽列表
+type BlockListResponse struct {
+	Menus       []Menu       `json:"menus"`
+	Restaurants []Restaurant `json:"restaurants"`
+}
+
+// 搜索结果类型
+const (
+	SearchTypeRestaurant = "restaurant"
+	SearchTypeDish       = "dish"
+)
+
+// SearchResult 搜索结果条目
+type SearchResult struct {
+	Type           string  `json:"type"` // restaurant, dish
+	ID             int64   `json:"id"`
+	Name           string  `json:"name"`
+	RestaurantID   int64   `json:"restaurant_id,omitempty"`   // 菜品所属餐厅
+	RestaurantName string  `json:"restaurant_name,omitempty"` // 菜品所属餐厅名称
+	MatchType      string  `json:"match_type"`                // exact, prefix, substring, pinyin, initials, fuzzy
+	Score          float64 `json:"score"`
+}
+
+// SearchResponse 搜索响应
+type SearchResponse struct {
+	Items    []SearchResult `json:"items"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
+// PasswordResetResponse 密码重置令牌（只在签发时返回一次，交给用户后凭令牌设置新密码）
+type PasswordResetResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UserListResponse 用户列表
+type UserListResponse struct {
+	Users    []User `json:"users"`
+	Total    int64  `json:"total"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+}
+
+// NameSuggestion 相似名称提示（创建时发现疑似重复的已有条目）
+type NameSuggestion struct {
+	Type         string `json:"type"` // restaurant, dish
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	RestaurantID int64  `json:"restaurant_id,omitempty"`
+}
+
+// NutritionTotals 营养合计
+type NutritionTotals struct {
+	Kcal    int     `json:"kcal"`
+	Protein float64 `json:"protein"`
+	Carbs   float64 `json:"carbs"`
+	Fat     float64 `json:"fat"`
+}
+
+// NutritionDay 单日营养汇总
+type NutritionDay struct {
+	Date         string `json:"date"`
+	Meals        int    `json:"meals"`         // 已确认的用餐次数
+	UnknownMeals int    `json:"unknown_meals"` // 菜品未填写热量的用餐次数
+	NutritionTotals
+}
+
+// NutritionSummaryResponse 营养汇总响应
+type NutritionSummaryResponse struct {
+	From         string          `json:"from"`
+	To           string          `json:"to"`
+	Days         []NutritionDay  `json:"days"`
+	Total        NutritionTotals `json:"total"`
+	Meals        int             `json:"meals"`
+	UnknownMeals int             `json:"unknown_meals"`
+	AvgDailyKcal float64         `json:"avg_daily_kcal"` // 按有记录的天数平均
+}
+
+// DishCount 菜品统计
+type DishCount struct {
+	MenuID         int64  `json:"menu_id"`
+	DishName       string `json:"dish_name"`
+	RestaurantID   int64  `json:"restaurant_id"`
+	RestaurantName string `json:"restaurant_name"`
+	Count          int64  `json:"count"`
+}
+
+// RestaurantCount 餐厅统计
+type RestaurantCount struct {
+	RestaurantID   int64  `json:"restaurant_id"`
+	RestaurantName string `json:"restaurant_name"`
+	Count          int64  `json:"count"`
+}
+
+// WeekdayCount 按星期统计（0 表示星期日）
+type WeekdayCount struct {
+	Weekday int   `json:"weekday"`
+	Count   int64 `json:"count"`
+}
+
+// VarietyWeek 单周的多样性
+type VarietyWeek struct {
+	Week        string  `json:"week"` // ISO 周，如 2024-W09
+	Meals       int64   `json:"meals"`
+	Dishes      int64   `json:"dishes"`      // 不同菜品数
+	Restaurants int64   `json:"restaurants"` // 不同餐厅数
+	Score       float64 `json:"score"`       // 不同餐厅数 / 用餐次数，越接近 1 越多样
+}
+
+// StatsResponse 个人统计
+type StatsResponse struct {
+	TotalMeals       int64             `json:"total_meals"`
+	TopDishes        []DishCount       `json:"top_dishes"`
+	TopRestaurants   []RestaurantCount `json:"top_restaurants"`
+	Weekdays         []WeekdayCount    `json:"weekdays"`
+	LongestStreak    int               `json:"longest_streak"` // 最长的连续不重复餐厅的用餐次数
+	CurrentStreak    int               `json:"current_streak"` // 截至最近一次用餐的连续不重复餐厅次数
+	Variety          []VarietyWeek     `json:"variety"`        // 按周的多样性，最近的在前
+	RecentRepeats    int64             `json:"recent_repeats"` // 降低权重后仍选中最近吃过的菜品的次数
+	RecentRepeatRate float64           `json:"recent_repeat_rate"`
+}
+
+// LeaderboardEntry 排行榜条目
+type LeaderboardEntry struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Value    int64  `json:"value"`
+}
+
+// HeatmapCell 团队热力图的单元格：某一天某家餐厅的用餐次数
+type HeatmapCell struct {
+	Date           string `json:"date"`
+	RestaurantID   int64  `json:"restaurant_id"`
+	RestaurantName string `json:"restaurant_name"`
+	Count          int64  `json:"count"`
+}
+
+// TeamStatsResponse 团队统计
+type TeamStatsResponse struct {
+	Team               Team               `json:"team"`
+	Month              string             `json:"month"`
+	Members            int                `json:"members"`
+	TotalMeals         int64              `json:"total_meals"`
+	MostAdventurous    []LeaderboardEntry `json:"most_adventurous"`    // 吃过的不同菜品最多
+	MostLoyal          []LeaderboardEntry `json:"most_loyal"`          // 重复吃同一菜品的次数最多
+	FavoriteRestaurant *RestaurantCount   `json:"favorite_restaurant"` // 本月团队最常去的餐厅
+	TopRestaurants     []RestaurantCount  `json:"top_restaurants"`
+	Heatmap            []HeatmapCell      `json:"heatmap"` // 按天和餐厅的用餐次数
+}
+
+// MergeResult 合并结果
+type MergeResult struct {
+	TargetID         int64           `json:"target_id"`
+	SourceID         int64           `json:"source_id"`
+	MovedDishes      int             `json:"moved_dishes"`            // 移动到目标餐厅的菜品数
+	MergedDishes     []DishCollision `json:"merged_dishes,omitempty"` // 名称冲突后合并的菜品
+	RepointedRecords int64           `json:"repointed_records"`       // 重新指向的决策记录数
+}
+
+// DishCollision 合并餐厅时的同名菜品
+type DishCollision struct {
+	SourceMenuID int64  `json:"source_menu_id"`
+	TargetMenuID int64  `json:"target_menu_id"`
+	DishName     string `json:"dish_name"`
 }
 
 // Success 成功响应
@@ -42,3 +221,32 @@ func Error(code int, message string) Response {
 		Message: message,
 	}
 }
+
+// TimeZoneResponse 用户时区设置
+type TimeZoneResponse struct {
+	TimeZone  string `json:"time_zone"` // 用户设置的时区，为空表示使用默认时区
+	Effective string `json:"effective"` // 实际生效的时区
+	Offset    string `json:"offset"`    // 当前的 UTC 偏移，如 +08:00
+}
+
+// CalendarTokenResponse 日历订阅令牌（只在生成时返回一次）
+type CalendarTokenResponse struct {
+	Token string `json:"token"`
+	URL   string `json:"url"` // 订阅地址，可以直接添加到日历应用
+}
+
+// HistoryExportRow 导出的决策历史（每个菜品一行，组合决策的每个菜品各一行）
+// 时间按用户时区输出；菜品或餐厅已删除时仍包含名称
+type HistoryExportRow struct {
+	DecisionID int64  `json:"decision_id"`
+	DecidedAt  string `json:"decided_at"` // RFC 3339
+	Date       string `json:"date"`
+	MealSlot   string `json:"meal_slot"`
+	Mode       string `json:"mode"`
+	Origin     string `json:"origin"`
+	Confirmed  bool   `json:"confirmed"`
+	Restaurant string `json:"restaurant"`
+	Dish       string `json:"dish"`
+	Kcal       *int   `json:"kcal,omitempty"`
+	Note       string `json:"note,omitempty"`
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"what-to-eat/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// WithTx 返回绑定到指定事务的仓库
+func (r *AuditRepository) WithTx(tx *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: tx}
+}
+
+// Create 创建审计日志
+func (r *AuditRepository) Create(log *model.AuditLog) error {
+	return r.db.Create(log).Error
+}
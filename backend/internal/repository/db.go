@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -14,6 +16,7 @@ import (
 	"what-to-eat/config"
 	"what-to-eat/internal/model"
 	"what-to-eat/pkg/logger"
+	"what-to-eat/pkg/normalize"
 )
 
 var DB *gorm.DB
@@ -23,7 +26,7 @@ var DB *gorm.DB
 // ============================================================================
 
 // InitDB 初始化数据库连接
-// 执行顺序：1. 确保数据库存在 -> 2. 连接数据库 -> 3. 自动迁移表结构 -> 4. 初始化默认数据
+// 执行顺序：1. 确保数据库存在 -> 2. 连接数据库 -> 3. 自动迁移表结构 -> 4. 转换旧数据 -> 5. 停用旧的共享游客账号
 func InitDB(cfg *config.DatabaseConfig) error {
 	// 1. 确保数据库存在（不存在则创建）
 	if err := ensureDatabase(cfg); err != nil {
@@ -40,9 +43,14 @@ func InitDB(cfg *config.DatabaseConfig) error {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	// 4. 初始化默认数据（仅首次启动）
-	if err := initDefaultData(); err != nil {
-		return fmt.Errorf("failed to init default data: %w", err)
+	// 4. 把早期版本按服务器本地时间写入的时间转换为 UTC
+	if err := migrateUTCTimestamps(cfg.LegacyTimeZone); err != nil {
+		return fmt.Errorf("failed to convert timestamps to UTC: %w", err)
+	}
+
+	// 5. 早期版本预置的共享游客账号（guest/guest123）改为普通游客，不能再登录
+	if err := migrateLegacyGuest(); err != nil {
+		return fmt.Errorf("failed to migrate legacy guest account: %w", err)
 	}
 
 	logger.Info("Database initialized successfully",
@@ -54,7 +62,7 @@ func InitDB(cfg *config.DatabaseConfig) error {
 
 // ensureDatabase 确保数据库存在，不存在则创建
 func ensureDatabase(cfg *config.DatabaseConfig) error {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/?charset=utf8mb4&parseTime=True&loc=Local",
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/?charset=utf8mb4&parseTime=True&loc=UTC&time_zone=%%27%%2B00%%3A00%%27",
 		cfg.User, cfg.Password, cfg.Host, cfg.Port)
 
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
@@ -81,7 +89,7 @@ func ensureDatabase(cfg *config.DatabaseConfig) error {
 
 // connectDatabase 连接到指定数据库
 func connectDatabase(cfg *config.DatabaseConfig) error {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC&time_zone=%%27%%2B00%%3A00%%27",
 		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName)
 
 	gormLogger := &zapGormLogger{SlowThreshold: time.Second}
@@ -90,6 +98,9 @@ func connectDatabase(cfg *config.DatabaseConfig) error {
 	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
 		Logger:                                   gormLogger,
 		DisableForeignKeyConstraintWhenMigrating: true, // 禁用外键约束
+		NowFunc: func() time.Time { // 时间统一以 UTC 存储，按用户时区换算日期边界
+			return time.Now().UTC()
+		},
 	})
 	if err != nil {
 		return err
@@ -109,78 +120,213 @@ func connectDatabase(cfg *config.DatabaseConfig) error {
 }
 
 // autoMigrate 自动迁移表结构
-// 表创建顺序：users -> restaurants -> menus -> decision_records
+// 表创建顺序：users -> restaurants -> menus -> decision_records -> decision_items -> decision_aggregates -> user_favorites -> user_blocks -> teams -> team_members -> audit_logs -> refresh_tokens -> schema_migrations
 func autoMigrate() error {
-	return DB.AutoMigrate(
+	if err := DB.AutoMigrate(
 		&model.User{},
 		&model.Restaurant{},
 		&model.Menu{},
 		&model.DecisionRecord{},
-	)
+		&model.DecisionItem{},
+		&model.DecisionAggregate{},
+		&model.UserFavorite{},
+		&model.UserBlock{},
+		&model.Team{},
+		&model.TeamMember{},
+		&model.AuditLog{},
+		&model.RefreshToken{},
+		&model.SchemaMigration{},
+	); err != nil {
+		return err
+	}
+	return backfillNameKeys()
 }
 
-// ============================================================================
-// 默认数据初始化
-// ============================================================================
-
-// initDefaultData 初始化默认数据（仅当数据库为空时执行）
-func initDefaultData() error {
-	// 先初始化游客用户
-	if err := initGuestUser(); err != nil {
-		return fmt.Errorf("failed to init guest user: %w", err)
+// backfillNameKeys 为升级前已有的餐厅和菜品补齐规范化查重键和拼音搜索键
+func backfillNameKeys() error {
+	var restaurants []model.Restaurant
+	if err := DB.Unscoped().Where("name_key = '' OR name_pinyin = ''").Find(&restaurants).Error; err != nil {
+		return err
+	}
+	for _, r := range restaurants {
+		key := normalize.Key(r.Name)
+		full, initials := normalize.Pinyin(key)
+		if err := DB.Unscoped().Model(&model.Restaurant{}).Where("id = ?", r.ID).
+			UpdateColumns(map[string]interface{}{"name_key": key, "name_pinyin": full, "name_initials": initials}).Error; err != nil {
+			return err
+		}
 	}
 
-	// 检查是否已有菜单数据
-	var menuCount int64
-	if err := DB.Model(&model.Menu{}).Count(&menuCount).Error; err != nil {
+	var menus []model.Menu
+	if err := DB.Unscoped().Where("dish_name_key = '' OR dish_pinyin = ''").Find(&menus).Error; err != nil {
 		return err
 	}
+	for _, m := range menus {
+		key := normalize.Key(m.DishName)
+		full, initials := normalize.Pinyin(key)
+		if err := DB.Unscoped().Model(&model.Menu{}).Where("id = ?", m.ID).
+			UpdateColumns(map[string]interface{}{"dish_name_key": key, "dish_pinyin": full, "dish_initials": initials}).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(restaurants) > 0 || len(menus) > 0 {
+		logger.Info("Backfilled name keys",
+			zap.Int("restaurants", len(restaurants)),
+			zap.Int("menus", len(menus)),
+		)
+	}
+	return nil
+}
+
+// utcMigrationVersion 时间转换为 UTC 的迁移标记
+const utcMigrationVersion = "utc_timestamps"
+
+// utcTimestampColumns 需要转换为 UTC 的时间列（按表分组）
+// decision_aggregates.month 是按月份归档的日期，不是时间点，不需要转换
+var utcTimestampColumns = []struct {
+	table   string
+	columns []string
+}{
+	{"users", []string{"created_at", "updated_at", "last_seen_at", "reset_expires"}},
+	{"restaurants", []string{"created_at", "updated_at", "deleted_at"}},
+	{"menus", []string{"created_at", "updated_at", "deleted_at"}},
+	{"decision_records", []string{"decided_at", "confirmed_at"}},
+	{"user_favorites", []string{"created_at"}},
+	{"user_blocks", []string{"created_at"}},
+	{"teams", []string{"created_at", "updated_at"}},
+	{"team_members", []string{"joined_at"}},
+	{"audit_logs", []string{"created_at"}},
+	{"refresh_tokens", []string{"expires_at", "used_at", "revoked_at", "created_at"}},
+}
 
-	if menuCount > 0 {
-		logger.Debug("Default data already exists, skipping initialization")
+// migrateUTCTimestamps 把早期版本按服务器本地时间写入的时间一次性转换为 UTC
+// 转换和迁移标记在同一个事务中写入，已有标记（包括全新安装）时不会重复转换；
+// 有旧数据但没有配置 legacyTimeZone 时拒绝启动，避免日期边界、历史筛选和保留期限按错误的时间计算
+func migrateUTCTimestamps(legacyTimeZone string) error {
+	var applied int64
+	if err := DB.Model(&model.SchemaMigration{}).Where("version = ?", utcMigrationVersion).Count(&applied).Error; err != nil {
+		return err
+	}
+	if applied > 0 {
 		return nil
 	}
 
-	// 默认餐厅和菜品数据
-	defaultData := []struct {
-		Restaurant string
-		Dishes     []string
-	}{
-		{"麦当劳", []string{"巨无霸", "麦辣鸡腿堡", "薯条", "麦旋风"}},
-		{"肯德基", []string{"原味鸡", "香辣鸡腿堡", "上校鸡块", "蛋挞"}},
-		{"沙县小吃", []string{"拌面", "蒸饺", "炖罐", "扁肉"}},
-		{"兰州拉面", []string{"牛肉面", "刀削面", "炒面", "凉面"}},
-		{"黄焖鸡米饭", []string{"黄焖鸡米饭", "黄焖排骨", "黄焖豆腐"}},
-		{"海底捞", []string{"麻辣锅底", "番茄锅底", "菌汤锅底"}},
+	hasData, err := hasTimestampData()
+	if err != nil {
+		return err
+	}
+	if !hasData {
+		// 全新安装，所有时间从一开始就以 UTC 写入
+		return markMigration(DB, utcMigrationVersion)
 	}
 
-	// 使用事务批量插入
-	return DB.Transaction(func(tx *gorm.DB) error {
-		for _, data := range defaultData {
-			// 创建餐厅
-			restaurant := model.Restaurant{Name: data.Restaurant}
-			if err := tx.Create(&restaurant).Error; err != nil {
-				return fmt.Errorf("failed to create restaurant %s: %w", data.Restaurant, err)
-			}
+	if legacyTimeZone == "" {
+		return errors.New("existing data was written in server local time: set database.legacy_time_zone " +
+			"(IANA name such as Asia/Shanghai, or an offset such as +08:00) to the time zone the server used before upgrading")
+	}
+	// CONVERT_TZ 不认识的时区返回 NULL，先检查避免把时间列清空
+	var probe sql.NullString
+	if err := DB.Raw("SELECT CONVERT_TZ('2000-01-01 00:00:00', ?, '+00:00')", legacyTimeZone).Scan(&probe).Error; err != nil {
+		return err
+	}
+	if !probe.Valid {
+		return fmt.Errorf("MySQL cannot convert from time zone %q: load the time zone tables or use an offset such as +08:00", legacyTimeZone)
+	}
 
-			// 批量创建菜品
-			menus := make([]model.Menu, len(data.Dishes))
-			for i, dish := range data.Dishes {
-				menus[i] = model.Menu{
-					RestaurantID: restaurant.ID,
-					DishName:     dish,
-				}
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		for _, t := range utcTimestampColumns {
+			sets := make([]string, len(t.columns))
+			args := make([]interface{}, len(t.columns))
+			for i, column := range t.columns {
+				sets[i] = fmt.Sprintf("`%s` = CONVERT_TZ(`%s`, ?, '+00:00')", column, column)
+				args[i] = legacyTimeZone
 			}
-			if err := tx.Create(&menus).Error; err != nil {
-				return fmt.Errorf("failed to create menus for %s: %w", data.Restaurant, err)
+			if err := tx.Exec(fmt.Sprintf("UPDATE `%s` SET %s", t.table, strings.Join(sets, ", ")), args...).Error; err != nil {
+				return fmt.Errorf("convert %s: %w", t.table, err)
 			}
 		}
+		return markMigration(tx, utcMigrationVersion)
+	})
+	if err != nil {
+		return err
+	}
 
-		logger.Info("Initialized default data",
-			zap.Int("restaurants", len(defaultData)),
-		)
+	logger.Info("Converted timestamps to UTC", zap.String("legacy_time_zone", legacyTimeZone))
+	return nil
+}
+
+// hasTimestampData 判断是否有任何表中已有数据
+func hasTimestampData() (bool, error) {
+	for _, t := range utcTimestampColumns {
+		var exists bool
+		if err := DB.Raw(fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM `%s`)", t.table)).Scan(&exists).Error; err != nil {
+			return false, err
+		}
+		if exists {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// legacyGuestMigrationVersion 停用共享游客账号的迁移标记
+const legacyGuestMigrationVersion = "legacy_guest_account"
+
+// 早期版本预置的共享游客账号
+const (
+	legacyGuestUserID   int64 = 1
+	legacyGuestUsername       = "guest"
+)
+
+// migrateLegacyGuest 停用早期版本预置的共享游客账号
+// 该账号的密码是公开的，升级后会得到默认的 member 角色；改为游客角色并清空密码后无法再登录，
+// 按最近活动时间和其他游客一样被定期清理（连同混在一起的历史记录）
+func migrateLegacyGuest() error {
+	var applied int64
+	if err := DB.Model(&model.SchemaMigration{}).Where("version = ?", legacyGuestMigrationVersion).Count(&applied).Error; err != nil {
+		return err
+	}
+	if applied > 0 {
 		return nil
+	}
+
+	var converted int64
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND username = ?", legacyGuestUserID, legacyGuestUsername).
+			Updates(map[string]interface{}{
+				"role":          model.RoleGuest,
+				"password_hash": "",
+				"device_key":    nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		converted = result.RowsAffected
+		if converted == 0 {
+			return markMigration(tx, legacyGuestMigrationVersion)
+		}
+		if err := tx.Model(&model.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", legacyGuestUserID).
+			Update("revoked_at", time.Now().UTC()).Error; err != nil {
+			return err
+		}
+		return markMigration(tx, legacyGuestMigrationVersion)
 	})
+	if err != nil {
+		return err
+	}
+
+	if converted > 0 {
+		logger.Info("Disabled legacy shared guest account", zap.Int64("id", legacyGuestUserID))
+	}
+	return nil
+}
+
+// markMigration 记录迁移已执行
+func markMigration(tx *gorm.DB, version string) error {
+	return tx.Create(&model.SchemaMigration{Version: version, AppliedAt: time.Now().UTC()}).Error
 }
 
 // GetDB 获取数据库实例
@@ -234,46 +380,3 @@ func (l *zapGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (s
 
 	logger.Debug("GORM SQL", fields...)
 }
-
-// ============================================================================
-// 游客用户初始化
-// ============================================================================
-
-// GuestUserID 游客用户ID
-const GuestUserID int64 = 1
-
-// GuestUsername 游客用户名
-const GuestUsername = "guest"
-
-// initGuestUser 初始化游客用户（id=1, username=guest）
-func initGuestUser() error {
-	var user model.User
-	err := DB.First(&user, GuestUserID).Error
-	if err == nil {
-		// 游客用户已存在
-		logger.Debug("Guest user already exists", zap.Int64("id", GuestUserID))
-		return nil
-	}
-
-	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return err
-	}
-
-	// 创建游客用户
-	// 密码哈希为 "guest123" 使用 bcrypt 生成
-	guestUser := model.User{
-		ID:           GuestUserID,
-		Username:     GuestUsername,
-		PasswordHash: "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy",
-	}
-
-	if err := DB.Create(&guestUser).Error; err != nil {
-		return fmt.Errorf("failed to create guest user: %w", err)
-	}
-
-	logger.Info("Guest user initialized",
-		zap.Int64("id", GuestUserID),
-		zap.String("username", GuestUsername),
-	)
-	return nil
-}
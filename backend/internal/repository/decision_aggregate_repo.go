@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"time"
+
+	"what-to-eat/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserIDsWithRecordsBefore 获取在 before 之前有决策记录的用户
+func (r *DecisionRepository) UserIDsWithRecordsBefore(before time.Time) ([]int64, error) {
+	var ids []int64
+	err := r.db.Model(&model.DecisionRecord{}).
+		Where("decided_at < ?", before).
+		Distinct().
+		Order("user_id").
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// CountBefore 统计用户在 before 之前的决策记录数量
+func (r *DecisionRepository) CountBefore(userID int64, before time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.DecisionRecord{}).
+		Where("user_id = ? AND decided_at < ?", userID, before).
+		Count(&count).Error
+	return count, err
+}
+
+// GetOldestBefore 获取用户在 before 之前最早的 limit 条决策记录（只加载组合决策的菜品ID）
+func (r *DecisionRepository) GetOldestBefore(userID int64, before time.Time, limit int) ([]model.DecisionRecord, error) {
+	var records []model.DecisionRecord
+	err := r.db.Where("user_id = ? AND decided_at < ?", userID, before).
+		Order("decided_at ASC, id ASC").
+		Limit(limit).
+		Preload("Items").
+		Find(&records).Error
+	return records, err
+}
+
+// ReplaceWithAggregates 在一个事务中累加月度汇总，并删除已汇总的决策记录及其组合菜品
+func (r *DecisionRepository) ReplaceWithAggregates(recordIDs []int64, aggregates []model.DecisionAggregate) error {
+	if len(recordIDs) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(aggregates) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"meals":   gorm.Expr("meals + VALUES(meals)"),
+					"dishes":  gorm.Expr("dishes + VALUES(dishes)"),
+					"repeats": gorm.Expr("repeats + VALUES(repeats)"),
+				}),
+			}).Create(&aggregates).Error
+			if err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("decision_id IN ?", recordIDs).Delete(&model.DecisionItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", recordIDs).Delete(&model.DecisionRecord{}).Error
+	})
+}
+
+// repointAggregates 将月度汇总中的 fromMenuID 合并到 toMenuID（同一用户、月份和星期的汇总相加）
+func (r *DecisionRepository) repointAggregates(fromMenuID, toMenuID int64) error {
+	err := r.db.Exec("INSERT INTO decision_aggregates (user_id, month, menu_id, weekday, meals, dishes, repeats) "+
+		"SELECT user_id, month, ?, weekday, meals, dishes, repeats FROM decision_aggregates WHERE menu_id = ? "+
+		"ON DUPLICATE KEY UPDATE meals = decision_aggregates.meals + VALUES(meals), "+
+		"dishes = decision_aggregates.dishes + VALUES(dishes), repeats = decision_aggregates.repeats + VALUES(repeats)",
+		toMenuID, fromMenuID).Error
+	if err != nil {
+		return err
+	}
+	return r.db.Where("menu_id = ?", fromMenuID).Delete(&model.DecisionAggregate{}).Error
+}
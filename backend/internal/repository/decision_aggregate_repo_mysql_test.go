@@ -0,0 +1,115 @@
+//go:build mysql
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"what-to-eat/internal/model"
+)
+
+// aggregatesOf 按星期排序返回某个菜品的月度汇总
+func aggregatesOf(t *testing.T, db *gorm.DB, menuID int64) []model.DecisionAggregate {
+	t.Helper()
+	var aggregates []model.DecisionAggregate
+	if err := db.Where("menu_id = ?", menuID).Order("user_id, weekday").Find(&aggregates).Error; err != nil {
+		t.Fatal(err)
+	}
+	return aggregates
+}
+
+func TestDecisionRepository_ReplaceWithAggregates(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewDecisionRepository(db)
+
+	user := model.User{Username: "alice", PasswordHash: "x"}
+	mustCreate(t, db, &user)
+	burger := createMenu(t, db, "麦当劳", "巨无霸")
+	cola := createMenu(t, db, "可口可乐", "可乐")
+	month := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	decidedAt := time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC) // 星期五
+
+	// 上一次汇总留下的数据
+	mustCreate(t, db, &model.DecisionAggregate{UserID: user.ID, Month: month, MenuID: burger.ID, Weekday: 5, Meals: 2, Dishes: 3, Repeats: 1})
+
+	single := model.DecisionRecord{UserID: user.ID, MenuID: burger.ID, DecidedAt: decidedAt}
+	mustCreate(t, db, &single)
+	combo := model.DecisionRecord{UserID: user.ID, MenuID: burger.ID, Mode: model.DecideModeCombo, DecidedAt: decidedAt.Add(time.Hour)}
+	mustCreate(t, db, &combo)
+	mustCreate(t, db, &[]model.DecisionItem{{DecisionID: combo.ID, MenuID: burger.ID}, {DecisionID: combo.ID, MenuID: cola.ID}})
+	kept := model.DecisionRecord{UserID: user.ID, MenuID: cola.ID, DecidedAt: decidedAt.AddDate(0, 1, 0)}
+	mustCreate(t, db, &kept)
+
+	err := repo.ReplaceWithAggregates([]int64{single.ID, combo.ID}, []model.DecisionAggregate{
+		{UserID: user.ID, Month: month, MenuID: burger.ID, Weekday: 5, Meals: 2, Dishes: 2, Repeats: 1},
+		{UserID: user.ID, Month: month, MenuID: cola.ID, Weekday: 5, Meals: 0, Dishes: 1},
+	})
+	if err != nil {
+		t.Fatalf("ReplaceWithAggregates: %v", err)
+	}
+
+	if got := aggregatesOf(t, db, burger.ID); len(got) != 1 || got[0].Meals != 4 || got[0].Dishes != 5 || got[0].Repeats != 2 {
+		t.Errorf("burger aggregates = %+v, want one row with meals 4, dishes 5, repeats 2", got)
+	}
+	if got := aggregatesOf(t, db, cola.ID); len(got) != 1 || got[0].Meals != 0 || got[0].Dishes != 1 {
+		t.Errorf("cola aggregates = %+v, want one row with meals 0, dishes 1", got)
+	}
+
+	var records []model.DecisionRecord
+	if err := db.Order("id").Find(&records).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].ID != kept.ID {
+		t.Errorf("remaining records = %+v, want only record %d", records, kept.ID)
+	}
+	var items int64
+	if err := db.Model(&model.DecisionItem{}).Count(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	if items != 0 {
+		t.Errorf("remaining decision items = %d, want 0", items)
+	}
+}
+
+func TestDecisionRepository_RepointMenu(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewDecisionRepository(db)
+
+	user := model.User{Username: "alice", PasswordHash: "x"}
+	mustCreate(t, db, &user)
+	from := createMenu(t, db, "麦当捞", "巨无霸")
+	to := createMenu(t, db, "麦当劳", "巨无霸")
+	month := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mustCreate(t, db, &[]model.DecisionAggregate{
+		{UserID: user.ID, Month: month, MenuID: from.ID, Weekday: 1, Meals: 1, Dishes: 1},
+		{UserID: user.ID, Month: month, MenuID: from.ID, Weekday: 2, Meals: 1, Dishes: 1, Repeats: 1},
+		{UserID: user.ID, Month: month, MenuID: to.ID, Weekday: 1, Meals: 2, Dishes: 2, Repeats: 1},
+	})
+	mustCreate(t, db, &model.DecisionRecord{UserID: user.ID, MenuID: from.ID, DecidedAt: time.Now().UTC()})
+
+	affected, err := repo.RepointMenu(from.ID, to.ID)
+	if err != nil {
+		t.Fatalf("RepointMenu: %v", err)
+	}
+	if affected != 1 {
+		t.Errorf("affected = %d, want 1", affected)
+	}
+
+	if got := aggregatesOf(t, db, from.ID); len(got) != 0 {
+		t.Errorf("aggregates still pointing at source menu: %+v", got)
+	}
+	got := aggregatesOf(t, db, to.ID)
+	if len(got) != 2 {
+		t.Fatalf("target aggregates = %+v, want 2 rows", got)
+	}
+	if a := got[0]; a.Weekday != 1 || a.Meals != 3 || a.Dishes != 3 || a.Repeats != 1 {
+		t.Errorf("weekday 1 aggregate = %+v, want meals 3, dishes 3, repeats 1", a)
+	}
+	if a := got[1]; a.Weekday != 2 || a.Meals != 1 || a.Dishes != 1 || a.Repeats != 1 {
+		t.Errorf("weekday 2 aggregate = %+v, want meals 1, dishes 1, repeats 1", a)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"what-to-eat/internal/model"
+	"what-to-eat/pkg/timezone"
 
 	"gorm.io/gorm"
 )
@@ -16,17 +17,21 @@ func NewDecisionRepository(db *gorm.DB) *DecisionRepository {
 	return &DecisionRepository{db: db}
 }
 
+// WithTx 返回绑定到指定事务的仓库
+func (r *DecisionRepository) WithTx(tx *gorm.DB) *DecisionRepository {
+	return &DecisionRepository{db: tx}
+}
+
 // Create 创建决策记录
 func (r *DecisionRepository) Create(record *model.DecisionRecord) error {
 	return r.db.Create(record).Error
 }
 
-// CreateOrUpdateToday 创建或更新当天的决策记录（每天只保留一条）
-func (r *DecisionRepository) CreateOrUpdateToday(userID int64, menuID int64) (*model.DecisionRecord, error) {
+// CreateOrUpdateToday 创建或更新当天的决策记录（每天只保留一条，"当天"按用户时区 loc 计算）
+func (r *DecisionRepository) CreateOrUpdateToday(userID int64, menuID int64, loc *time.Location) (*model.DecisionRecord, error) {
 	// 获取今天的开始和结束时间
 	now := time.Now()
-	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	todayEnd := todayStart.Add(24 * time.Hour)
+	todayStart, todayEnd := timezone.DayBounds(now, loc)
 
 	var existingRecord model.DecisionRecord
 	err := r.db.Where("user_id = ? AND decided_at >= ? AND decided_at < ?", userID, todayStart, todayEnd).
@@ -57,48 +62,261 @@ func (r *DecisionRepository) CreateOrUpdateToday(userID int64, menuID int64) (*m
 	return &existingRecord, nil
 }
 
-// GetRecentByUserID 获取用户最近N条决策记录
+// RepointMenu 将指向 fromMenuID 的决策记录（包括组合决策的菜品和月度汇总）改为指向 toMenuID，返回受影响的记录数
+func (r *DecisionRepository) RepointMenu(fromMenuID, toMenuID int64) (int64, error) {
+	result := r.db.Model(&model.DecisionRecord{}).
+		Where("menu_id = ?", fromMenuID).
+		Update("menu_id", toMenuID)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	items := r.db.Model(&model.DecisionItem{}).
+		Where("menu_id = ?", fromMenuID).
+		Update("menu_id", toMenuID)
+	if items.Error != nil {
+		return 0, items.Error
+	}
+	if err := r.repointAggregates(fromMenuID, toMenuID); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected + items.RowsAffected, nil
+}
+
+// GetRecentByUserID 获取用户最近N条决策记录（不区分来源，不包含未来的计划）
 func (r *DecisionRepository) GetRecentByUserID(userID int64, limit int) ([]model.DecisionRecord, error) {
 	var records []model.DecisionRecord
-	err := r.db.Where("user_id = ?", userID).
+	err := r.db.Where("user_id = ? AND decided_at <= ?", userID, time.Now()).
 		Order("decided_at DESC").
 		Limit(limit).
 		Preload("Menu").
 		Preload("Menu.Restaurant").
+		Preload("Items.Menu.Restaurant").
 		Find(&records).Error
 	return records, err
 }
 
-// GetByUserIDAndDays 获取用户最近N天的决策记录（包含已删除的菜单）
-func (r *DecisionRepository) GetByUserIDAndDays(userID int64, days int) ([]model.DecisionRecord, error) {
+// GetByUserIDAndDays 获取用户最近N天的决策记录（包含已删除的菜单，从用户时区 loc 中N天前的 0 点开始）
+func (r *DecisionRepository) GetByUserIDAndDays(userID int64, days int, loc *time.Location) ([]model.DecisionRecord, error) {
 	var records []model.DecisionRecord
-	startTime := time.Now().AddDate(0, 0, -days)
-	// 使用 Unscoped 加载已软删除的菜单，确保历史记录完整显示
-	err := r.db.Where("user_id = ? AND decided_at >= ?", userID, startTime).
+	startTime := timezone.StartOfDay(time.Now().In(loc).AddDate(0, 0, -days), loc)
+	err := preloadHistory(r.db.Where("user_id = ? AND decided_at >= ?", userID, startTime)).
 		Order("decided_at DESC").
+		Find(&records).Error
+	return records, err
+}
+
+// HistoryFilter 历史记录分页查询条件
+type HistoryFilter struct {
+	From         *time.Time     // decided_at >= From
+	To           *time.Time     // decided_at < To
+	RestaurantID int64          // 为 0 表示不限
+	HourStart    int            // 决策时间（用户时区）的小时范围 [HourStart, HourEnd)，HourStart > HourEnd 表示跨越午夜
+	HourEnd      int            // 两者都为 0 表示不限
+	Location     *time.Location // 用户时区，用于按小时过滤
+	// 游标：只返回排在 (CursorTime, CursorID) 之后的记录
+	CursorTime *time.Time
+	CursorID   int64
+	Limit      int
+}
+
+// GetHistoryPage 按决策时间倒序分页查询用户的决策记录（包含已删除的菜单）
+// 使用 (decided_at, id) 游标分页，条件和排序都落在 idx_user_decided 索引上
+func (r *DecisionRepository) GetHistoryPage(userID int64, f HistoryFilter) ([]model.DecisionRecord, error) {
+	query := r.historyQuery(userID, f)
+	if f.CursorTime != nil {
+		query = query.Where("(decided_at < ? OR (decided_at = ? AND id < ?))", *f.CursorTime, *f.CursorTime, f.CursorID)
+	}
+
+	var records []model.DecisionRecord
+	err := preloadHistory(query).
+		Order("decided_at DESC, id DESC").
+		Limit(f.Limit).
+		Find(&records).Error
+	return records, err
+}
+
+// CountHistory 统计符合筛选条件的决策记录总数（不受游标和分页数量影响）
+func (r *DecisionRepository) CountHistory(userID int64, f HistoryFilter) (int64, error) {
+	var count int64
+	err := r.historyQuery(userID, f).Model(&model.DecisionRecord{}).Count(&count).Error
+	return count, err
+}
+
+// historyQuery 历史记录的筛选条件（日期范围、餐厅和用餐时段）
+func (r *DecisionRepository) historyQuery(userID int64, f HistoryFilter) *gorm.DB {
+	query := r.db.Where("user_id = ?", userID)
+	if f.From != nil {
+		query = query.Where("decided_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("decided_at < ?", *f.To)
+	}
+	if f.RestaurantID != 0 {
+		// 组合决策的菜品都来自同一餐厅，按主记录的菜品判断即可；包含已删除的菜品
+		query = query.Where("menu_id IN (?)",
+			r.db.Unscoped().Model(&model.Menu{}).Select("id").Where("restaurant_id = ?", f.RestaurantID))
+	}
+	if f.HourStart != 0 || f.HourEnd != 0 {
+		local := localTime("decided_at", f.Location)
+		if f.HourStart < f.HourEnd {
+			query = query.Where("HOUR(?) >= ? AND HOUR(?) < ?", local, f.HourStart, local, f.HourEnd)
+		} else {
+			query = query.Where("(HOUR(?) >= ? OR HOUR(?) < ?)", local, f.HourStart, local, f.HourEnd)
+		}
+	}
+	return query
+}
+
+// GetCalendarRecords 获取日历订阅的记录：decided_at >= since 的已确认决策和计划（包含已删除的菜单）
+func (r *DecisionRepository) GetCalendarRecords(userID int64, since time.Time) ([]model.DecisionRecord, error) {
+	var records []model.DecisionRecord
+	err := preloadHistory(r.db.Where("user_id = ? AND decided_at >= ?", userID, since).
+		Where("confirmed_at IS NOT NULL OR origin = ?", model.DecisionOriginPlan)).
+		Order("decided_at ASC, id ASC").
+		Find(&records).Error
+	return records, err
+}
+
+// HistoryDish 决策历史中的单个菜品（组合决策的每个菜品各一条）
+type HistoryDish struct {
+	DecisionID     int64
+	ItemID         int64 // 组合决策的菜品ID，单个菜品决策为 0
+	DecidedAt      time.Time
+	ConfirmedAt    *time.Time
+	Mode           string
+	Origin         string
+	Note           string
+	RestaurantName string
+	DishName       string
+	Kcal           *int
+}
+
+// EachHistoryDish 按决策时间顺序逐条读取用户在 [from, to) 内的决策菜品，对每条调用 fn（from/to 为 nil 表示不限）
+// 通过游标逐行读取，不会把全部历史加载到内存；关联菜品和餐厅时不过滤软删除；fn 返回错误时停止
+func (r *DecisionRepository) EachHistoryDish(userID int64, from, to *time.Time, fn func(*HistoryDish) error) error {
+	records := func() *gorm.DB {
+		query := r.db.Table("decision_records AS d").Where("d.user_id = ?", userID)
+		if from != nil {
+			query = query.Where("d.decided_at >= ?", *from)
+		}
+		if to != nil {
+			query = query.Where("d.decided_at < ?", *to)
+		}
+		return query
+	}
+	const columns = "d.decided_at, d.confirmed_at, d.mode, d.origin, d.note, " +
+		"rs.name AS restaurant_name, m.dish_name, m.kcal"
+	single := records().
+		Select("d.id AS decision_id, 0 AS item_id, "+columns).
+		Joins("LEFT JOIN menus m ON m.id = d.menu_id").
+		Joins("LEFT JOIN restaurants rs ON rs.id = m.restaurant_id").
+		Where("d.mode <> ?", model.DecideModeCombo)
+	combo := records().
+		Select("d.id AS decision_id, i.id AS item_id, "+columns).
+		Joins("JOIN decision_items i ON i.decision_id = d.id").
+		Joins("LEFT JOIN menus m ON m.id = i.menu_id").
+		Joins("LEFT JOIN restaurants rs ON rs.id = m.restaurant_id").
+		Where("d.mode = ?", model.DecideModeCombo)
+
+	rows, err := r.db.Raw("? UNION ALL ? ORDER BY decided_at ASC, decision_id ASC, item_id ASC", single, combo).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dish HistoryDish
+		if err := r.db.ScanRows(rows, &dish); err != nil {
+			return err
+		}
+		if err := fn(&dish); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// preloadHistory 预加载历史记录的菜品和餐厅
+// 使用 Unscoped 加载已软删除的菜单和已合并/删除的餐厅，确保历史记录完整显示
+func preloadHistory(db *gorm.DB) *gorm.DB {
+	unscoped := func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}
+	return db.
+		Preload("Menu", unscoped).
+		Preload("Menu.Restaurant", unscoped).
+		Preload("Items.Menu", unscoped).
+		Preload("Items.Menu.Restaurant", unscoped)
+}
+
+// GetConfirmedByUserIDBetween 获取用户在 [start, end) 内已确认的决策记录（包含已删除的菜单）
+func (r *DecisionRepository) GetConfirmedByUserIDBetween(userID int64, start, end time.Time) ([]model.DecisionRecord, error) {
+	var records []model.DecisionRecord
+	err := r.db.Where("user_id = ? AND confirmed_at IS NOT NULL AND decided_at >= ? AND decided_at < ?", userID, start, end).
+		Order("decided_at ASC").
 		Preload("Menu", func(db *gorm.DB) *gorm.DB {
-			return db.Unscoped() // 包含已删除的菜单
+			return db.Unscoped()
+		}).
+		Preload("Items.Menu", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
 		}).
-		Preload("Menu.Restaurant").
 		Find(&records).Error
 	return records, err
 }
 
-// CountByUserIDAndDays 统计用户最近N天的决策记录数量
-func (r *DecisionRepository) CountByUserIDAndDays(userID int64, days int) (int64, error) {
+// GetByIDAndUserID 获取用户的一条决策记录（包含已删除的菜单）
+func (r *DecisionRepository) GetByIDAndUserID(id, userID int64) (*model.DecisionRecord, error) {
+	var record model.DecisionRecord
+	err := preloadHistory(r.db.Where("id = ? AND user_id = ?", id, userID)).First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Update 更新决策记录的指定字段
+func (r *DecisionRepository) Update(id int64, updates map[string]interface{}) error {
+	return r.db.Model(&model.DecisionRecord{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DeleteByIDAndUserID 删除用户的决策记录及组合决策的菜品，返回受影响的记录数
+func (r *DecisionRepository) DeleteByIDAndUserID(id, userID int64) (int64, error) {
+	var affected int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&model.DecisionRecord{})
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+		if affected == 0 {
+			return nil
+		}
+		return tx.Where("decision_id = ?", id).Delete(&model.DecisionItem{}).Error
+	})
+	return affected, err
+}
+
+// Confirm 确认用户的决策记录（表示确实吃了），返回受影响的记录数
+func (r *DecisionRepository) Confirm(userID, recordID int64, confirmedAt time.Time) (int64, error) {
+	result := r.db.Model(&model.DecisionRecord{}).
+		Where("id = ? AND user_id = ?", recordID, userID).
+		Update("confirmed_at", confirmedAt)
+	return result.RowsAffected, result.Error
+}
+
+// CountByUserIDAndDays 统计用户最近N天的决策记录数量（从用户时区 loc 中N天前的 0 点开始）
+func (r *DecisionRepository) CountByUserIDAndDays(userID int64, days int, loc *time.Location) (int64, error) {
 	var count int64
-	startTime := time.Now().AddDate(0, 0, -days)
+	startTime := timezone.StartOfDay(time.Now().In(loc).AddDate(0, 0, -days), loc)
 	err := r.db.Model(&model.DecisionRecord{}).
 		Where("user_id = ? AND decided_at >= ?", userID, startTime).
 		Count(&count).Error
 	return count, err
 }
 
-// GetTodayRecord 获取用户今天的决策记录
-func (r *DecisionRepository) GetTodayRecord(userID int64) (*model.DecisionRecord, error) {
-	now := time.Now()
-	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	todayEnd := todayStart.Add(24 * time.Hour)
+// GetTodayRecord 获取用户今天的决策记录（"今天"按用户时区 loc 计算）
+func (r *DecisionRepository) GetTodayRecord(userID int64, loc *time.Location) (*model.DecisionRecord, error) {
+	todayStart, todayEnd := timezone.DayBounds(time.Now(), loc)
 
 	var record model.DecisionRecord
 	err := r.db.Where("user_id = ? AND decided_at >= ? AND decided_at < ?", userID, todayStart, todayEnd).
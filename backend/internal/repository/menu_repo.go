@@ -2,6 +2,7 @@ package repository
 
 import (
 	"what-to-eat/internal/model"
+	"what-to-eat/pkg/normalize"
 
 	"gorm.io/gorm"
 )
@@ -14,6 +15,16 @@ func NewMenuRepository(db *gorm.DB) *MenuRepository {
 	return &MenuRepository{db: db}
 }
 
+// WithTx 返回绑定到指定事务的仓库
+func (r *MenuRepository) WithTx(tx *gorm.DB) *MenuRepository {
+	return &MenuRepository{db: tx}
+}
+
+// Transaction 在同一个事务中执行 fn
+func (r *MenuRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
 // Create 创建菜单
 func (r *MenuRepository) Create(menu *model.Menu) error {
 	return r.db.Create(menu).Error
@@ -26,6 +37,18 @@ func (r *MenuRepository) GetAll() ([]model.Menu, error) {
 	return menus, err
 }
 
+// SearchByName 查询规范化菜品名、拼音全拼或拼音首字母包含任一片段的菜单（包含餐厅信息，作为搜索的候选）
+func (r *MenuRepository) SearchByName(fragments []string) ([]model.Menu, error) {
+	var menus []model.Menu
+	if len(fragments) == 0 {
+		return menus, nil
+	}
+	err := r.db.Preload("Restaurant").
+		Where(containsAny(r.db, fragments, "dish_name_key", "dish_pinyin", "dish_initials")).
+		Order("id ASC").Find(&menus).Error
+	return menus, err
+}
+
 // GetByID 根据ID查询菜单
 func (r *MenuRepository) GetByID(id int64) (*model.Menu, error) {
 	var menu model.Menu
@@ -50,6 +73,44 @@ func (r *MenuRepository) GetByRestaurantID(restaurantID int64) ([]model.Menu, er
 	return menus, err
 }
 
+// GetByRestaurantIDUnscoped 根据餐厅ID查询菜单（包含已删除的菜单）
+func (r *MenuRepository) GetByRestaurantIDUnscoped(restaurantID int64) ([]model.Menu, error) {
+	var menus []model.Menu
+	err := r.db.Unscoped().Where("restaurant_id = ?", restaurantID).Order("id ASC").Find(&menus).Error
+	return menus, err
+}
+
+// MoveToRestaurant 将菜单移动到指定餐厅（包含已删除的菜单）
+func (r *MenuRepository) MoveToRestaurant(menuID, restaurantID int64) error {
+	return r.db.Unscoped().Model(&model.Menu{}).
+		Where("id = ?", menuID).
+		UpdateColumn("restaurant_id", restaurantID).Error
+}
+
+// UpdateImage 更新图片信息
+func (r *MenuRepository) UpdateImage(id int64, key, imageURL, thumbnailURL string) error {
+	return r.db.Model(&model.Menu{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"image_key":     key,
+		"image_url":     imageURL,
+		"thumbnail_url": thumbnailURL,
+	}).Error
+}
+
+// UpdateNutrition 更新营养信息（字段为 nil 时清空）
+func (r *MenuRepository) UpdateNutrition(id int64, n model.Nutrition) error {
+	return r.db.Model(&model.Menu{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"kcal":    n.Kcal,
+		"protein": n.Protein,
+		"carbs":   n.Carbs,
+		"fat":     n.Fat,
+	}).Error
+}
+
+// UpdateRole 更新菜品角色
+func (r *MenuRepository) UpdateRole(id int64, role string) error {
+	return r.db.Model(&model.Menu{}).Where("id = ?", id).UpdateColumn("role", role).Error
+}
+
 // Delete 删除菜单
 func (r *MenuRepository) Delete(id int64) error {
 	return r.db.Delete(&model.Menu{}, id).Error
@@ -62,11 +123,11 @@ func (r *MenuRepository) Count() (int64, error) {
 	return count, err
 }
 
-// ExistsByRestaurantAndDish 检查餐厅下是否已有该菜品
+// ExistsByRestaurantAndDish 检查餐厅下是否已有该菜品（按规范化后的菜品名匹配）
 func (r *MenuRepository) ExistsByRestaurantAndDish(restaurantID int64, dishName string) (bool, error) {
 	var count int64
 	err := r.db.Model(&model.Menu{}).
-		Where("restaurant_id = ? AND dish_name = ?", restaurantID, dishName).
+		Where("restaurant_id = ? AND dish_name_key = ?", restaurantID, normalize.Key(dishName)).
 		Count(&count).Error
 	if err != nil {
 		return false, err
@@ -0,0 +1,84 @@
+//go:build mysql
+
+package repository
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"what-to-eat/config"
+	"what-to-eat/internal/model"
+)
+
+// openTestDB 重建测试库并完成迁移，返回连接（未设置 MYSQL_TEST_HOST 时跳过）
+// 运行方式：MYSQL_TEST_HOST=127.0.0.1 MYSQL_TEST_PASSWORD=root123456 go test -tags mysql ./...
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	host := os.Getenv("MYSQL_TEST_HOST")
+	if host == "" {
+		t.Skip("MYSQL_TEST_HOST 未设置，跳过 MySQL 集成测试")
+	}
+	cfg := &config.DatabaseConfig{
+		Host:            host,
+		Port:            envOr("MYSQL_TEST_PORT", "3306"),
+		User:            envOr("MYSQL_TEST_USER", "root"),
+		Password:        os.Getenv("MYSQL_TEST_PASSWORD"),
+		DBName:          "what_to_eat_test_repository",
+		MaxIdleConns:    2,
+		MaxOpenConns:    4,
+		ConnMaxLifetime: 60,
+	}
+
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/?charset=utf8mb4&parseTime=True&loc=UTC", cfg.User, cfg.Password, cfg.Host, cfg.Port)
+	server, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("connect MySQL: %v", err)
+	}
+	if err := server.Exec("DROP DATABASE IF EXISTS `" + cfg.DBName + "`").Error; err != nil {
+		t.Fatalf("drop test database: %v", err)
+	}
+	if sqlDB, err := server.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	if err := InitDB(cfg); err != nil {
+		t.Fatalf("init test database: %v", err)
+	}
+	db := DB
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// mustCreate 插入测试数据，失败时终止测试
+func mustCreate(t *testing.T, db *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatalf("create %T: %v", value, err)
+	}
+}
+
+// createMenu 创建一家餐厅和它的一个菜品
+func createMenu(t *testing.T, db *gorm.DB, restaurant, dish string) model.Menu {
+	t.Helper()
+	r := model.Restaurant{Name: restaurant}
+	mustCreate(t, db, &r)
+	m := model.Menu{RestaurantID: r.ID, DishName: dish}
+	mustCreate(t, db, &m)
+	return m
+}
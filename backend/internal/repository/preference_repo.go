@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"what-to-eat/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PreferenceRepository 用户收藏和屏蔽
+type PreferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewPreferenceRepository(db *gorm.DB) *PreferenceRepository {
+	return &PreferenceRepository{db: db}
+}
+
+// WithTx 返回绑定到指定事务的仓库
+func (r *PreferenceRepository) WithTx(tx *gorm.DB) *PreferenceRepository {
+	return &PreferenceRepository{db: tx}
+}
+
+// AddFavorite 收藏菜品（已收藏时忽略）
+func (r *PreferenceRepository) AddFavorite(userID, menuID int64) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.UserFavorite{UserID: userID, MenuID: menuID}).Error
+}
+
+// RemoveFavorite 取消收藏
+func (r *PreferenceRepository) RemoveFavorite(userID, menuID int64) error {
+	return r.db.Where("user_id = ? AND menu_id = ?", userID, menuID).
+		Delete(&model.UserFavorite{}).Error
+}
+
+// GetFavoriteMenuIDs 获取用户收藏的菜品ID
+func (r *PreferenceRepository) GetFavoriteMenuIDs(userID int64) ([]int64, error) {
+	var ids []int64
+	err := r.db.Model(&model.UserFavorite{}).
+		Where("user_id = ?", userID).
+		Order("id ASC").
+		Pluck("menu_id", &ids).Error
+	return ids, err
+}
+
+// AddBlock 屏蔽菜品或餐厅（已屏蔽时忽略）
+func (r *PreferenceRepository) AddBlock(userID int64, targetType string, targetID int64) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.UserBlock{UserID: userID, TargetType: targetType, TargetID: targetID}).Error
+}
+
+// RemoveBlock 取消屏蔽
+func (r *PreferenceRepository) RemoveBlock(userID int64, targetType string, targetID int64) error {
+	return r.db.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+		Delete(&model.UserBlock{}).Error
+}
+
+// GetBlocks 获取用户的全部屏蔽
+func (r *PreferenceRepository) GetBlocks(userID int64) ([]model.UserBlock, error) {
+	var blocks []model.UserBlock
+	err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&blocks).Error
+	return blocks, err
+}
+
+// RepointFavorites 将菜品 fromMenuID 的收藏转移到 toMenuID（两者都已收藏的用户只保留一条）
+func (r *PreferenceRepository) RepointFavorites(fromMenuID, toMenuID int64) error {
+	var userIDs []int64
+	if err := r.db.Model(&model.UserFavorite{}).
+		Where("menu_id = ?", toMenuID).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+	if len(userIDs) > 0 {
+		if err := r.db.Where("menu_id = ? AND user_id IN ?", fromMenuID, userIDs).
+			Delete(&model.UserFavorite{}).Error; err != nil {
+			return err
+		}
+	}
+	return r.db.Model(&model.UserFavorite{}).
+		Where("menu_id = ?", fromMenuID).
+		Update("menu_id", toMenuID).Error
+}
+
+// RepointBlocks 将对 fromID 的屏蔽转移到 toID（两者都已屏蔽的用户只保留一条）
+func (r *PreferenceRepository) RepointBlocks(targetType string, fromID, toID int64) error {
+	var userIDs []int64
+	if err := r.db.Model(&model.UserBlock{}).
+		Where("target_type = ? AND target_id = ?", targetType, toID).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+	if len(userIDs) > 0 {
+		if err := r.db.Where("target_type = ? AND target_id = ? AND user_id IN ?", targetType, fromID, userIDs).
+			Delete(&model.UserBlock{}).Error; err != nil {
+			return err
+		}
+	}
+	return r.db.Model(&model.UserBlock{}).
+		Where("target_type = ? AND target_id = ?", targetType, fromID).
+		Update("target_id", toID).Error
+}
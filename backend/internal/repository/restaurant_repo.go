@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"strings"
+
 	"what-to-eat/internal/model"
+	"what-to-eat/pkg/normalize"
 
 	"gorm.io/gorm"
 )
@@ -14,6 +17,11 @@ func NewRestaurantRepository(db *gorm.DB) *RestaurantRepository {
 	return &RestaurantRepository{db: db}
 }
 
+// WithTx 返回绑定到指定事务的仓库
+func (r *RestaurantRepository) WithTx(tx *gorm.DB) *RestaurantRepository {
+	return &RestaurantRepository{db: tx}
+}
+
 // Create 创建餐厅
 func (r *RestaurantRepository) Create(restaurant *model.Restaurant) error {
 	return r.db.Create(restaurant).Error
@@ -26,6 +34,26 @@ func (r *RestaurantRepository) GetAll() ([]model.Restaurant, error) {
 	return restaurants, err
 }
 
+// SearchByName 查询规范化名称、拼音全拼或拼音首字母包含任一片段的餐厅（作为搜索的候选）
+func (r *RestaurantRepository) SearchByName(fragments []string) ([]model.Restaurant, error) {
+	var restaurants []model.Restaurant
+	if len(fragments) == 0 {
+		return restaurants, nil
+	}
+	err := r.db.Where(containsAny(r.db, fragments, "name_key", "name_pinyin", "name_initials")).
+		Order("id ASC").Find(&restaurants).Error
+	return restaurants, err
+}
+
+// GetAllWithMenus 获取所有餐厅及其菜品（不含已删除的菜品）
+func (r *RestaurantRepository) GetAllWithMenus() ([]model.Restaurant, error) {
+	var restaurants []model.Restaurant
+	err := r.db.Preload("Menus", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Order("id ASC").Find(&restaurants).Error
+	return restaurants, err
+}
+
 // GetByID 根据ID查询餐厅
 func (r *RestaurantRepository) GetByID(id int64) (*model.Restaurant, error) {
 	var restaurant model.Restaurant
@@ -43,20 +71,21 @@ func (r *RestaurantRepository) GetByIDs(ids []int64) ([]model.Restaurant, error)
 	return restaurants, err
 }
 
-// GetByName 根据名称查询餐厅
+// GetByName 根据名称查询餐厅（按规范化后的名称匹配）
 func (r *RestaurantRepository) GetByName(name string) (*model.Restaurant, error) {
 	var restaurant model.Restaurant
-	err := r.db.Where("name = ?", name).First(&restaurant).Error
+	err := r.db.Where("name_key = ?", normalize.Key(name)).Order("id ASC").First(&restaurant).Error
 	if err != nil {
 		return nil, err
 	}
 	return &restaurant, nil
 }
 
-// GetOrCreate 获取或创建餐厅
+// GetOrCreate 获取或创建餐厅（按规范化后的名称查找，"麦当劳 " 与 "麦当劳" 视为同一餐厅）
+// 名称属于已被合并的餐厅时返回合并后的餐厅；属于已删除的餐厅时将其恢复
 func (r *RestaurantRepository) GetOrCreate(name string) (*model.Restaurant, bool, error) {
 	var restaurant model.Restaurant
-	err := r.db.Where("name = ?", name).First(&restaurant).Error
+	err := r.db.Where("name_key = ?", normalize.Key(name)).Order("id ASC").First(&restaurant).Error
 	if err == nil {
 		return &restaurant, false, nil // 已存在
 	}
@@ -64,6 +93,26 @@ func (r *RestaurantRepository) GetOrCreate(name string) (*model.Restaurant, bool
 		return nil, false, err
 	}
 
+	// 查找已删除的同名餐厅
+	err = r.db.Unscoped().Where("name_key = ?", normalize.Key(name)).Order("id ASC").First(&restaurant).Error
+	if err == nil {
+		if restaurant.MergedIntoID != nil {
+			target, err := r.GetByID(*restaurant.MergedIntoID)
+			if err != nil {
+				return nil, false, err
+			}
+			return target, false, nil // 已合并到其他餐厅
+		}
+		if err := r.db.Unscoped().Model(&restaurant).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return nil, false, err
+		}
+		restaurant.DeletedAt = gorm.DeletedAt{}
+		return &restaurant, false, nil // 已恢复
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, false, err
+	}
+
 	// 创建新餐厅
 	restaurant = model.Restaurant{Name: name}
 	if err := r.db.Create(&restaurant).Error; err != nil {
@@ -72,6 +121,31 @@ func (r *RestaurantRepository) GetOrCreate(name string) (*model.Restaurant, bool
 	return &restaurant, true, nil // 新创建
 }
 
+// MarkMerged 将源餐厅标记为已合并到目标餐厅并软删除
+// 之前已合并到源餐厅的餐厅也一并指向目标餐厅，避免出现合并链
+func (r *RestaurantRepository) MarkMerged(sourceID, targetID int64) error {
+	if err := r.db.Unscoped().Model(&model.Restaurant{}).
+		Where("id = ? OR merged_into_id = ?", sourceID, sourceID).
+		UpdateColumn("merged_into_id", targetID).Error; err != nil {
+		return err
+	}
+	return r.db.Delete(&model.Restaurant{}, sourceID).Error
+}
+
+// UpdateCuisine 更新餐厅菜系
+func (r *RestaurantRepository) UpdateCuisine(id int64, cuisine string) error {
+	return r.db.Model(&model.Restaurant{}).Where("id = ?", id).UpdateColumn("cuisine", cuisine).Error
+}
+
+// UpdateImage 更新图片信息
+func (r *RestaurantRepository) UpdateImage(id int64, key, imageURL, thumbnailURL string) error {
+	return r.db.Model(&model.Restaurant{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"image_key":     key,
+		"image_url":     imageURL,
+		"thumbnail_url": thumbnailURL,
+	}).Error
+}
+
 // Delete 删除餐厅
 func (r *RestaurantRepository) Delete(id int64) error {
 	return r.db.Delete(&model.Restaurant{}, id).Error
@@ -83,3 +157,18 @@ func (r *RestaurantRepository) Count() (int64, error) {
 	err := r.db.Model(&model.Restaurant{}).Count(&count).Error
 	return count, err
 }
+
+// likeEscaper 转义 LIKE 模式中的通配符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsAny 生成“任一列包含任一片段”的查询条件（作为分组条件使用）
+func containsAny(db *gorm.DB, fragments []string, columns ...string) *gorm.DB {
+	cond := db.Session(&gorm.Session{NewDB: true})
+	for _, fragment := range fragments {
+		pattern := "%" + likeEscaper.Replace(fragment) + "%"
+		for _, column := range columns {
+			cond = cond.Or(column+" LIKE ?", pattern)
+		}
+	}
+	return cond
+}
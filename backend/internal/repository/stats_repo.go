@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/pkg/timezone"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StatsRepository 基于 decision_records 和 decision_aggregates 的聚合统计
+// 所有查询都按 user_id 和决策时间过滤（落在 idx_user_decided 索引上），在数据库中完成聚合；
+// 关联菜品和餐厅时不过滤软删除，保证统计包含已删除的条目。
+// 用餐次数、最常吃的菜品和餐厅、星期分布和团队排行榜合并计入超过保留期限后按月汇总的数据；
+// 按周的多样性、连续记录和按天的热力图需要逐条记录，只统计保留期内的原始记录。
+type StatsRepository struct {
+	db *gorm.DB
+}
+
+func NewStatsRepository(db *gorm.DB) *StatsRepository {
+	return &StatsRepository{db: db}
+}
+
+// StatsScope 统计范围：一组用户在 [From, To) 内的决策记录，From/To 为 nil 表示不限
+// 按星期、周和日期分组时使用 Location 时区（为 nil 时按 UTC）
+type StatsScope struct {
+	UserIDs  []int64
+	From     *time.Time
+	To       *time.Time
+	Location *time.Location
+}
+
+// localTime 将以 UTC 存储的时间列换算为 loc 时区的本地时间
+// MySQL 加载了时区表时按时区名称换算（正确处理夏令时）；否则返回 NULL，退回到 loc 当前的 UTC 偏移
+func localTime(column string, loc *time.Location) clause.Expr {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return gorm.Expr(
+		fmt.Sprintf("COALESCE(CONVERT_TZ(%s, '+00:00', ?), CONVERT_TZ(%s, '+00:00', ?))", column, column),
+		loc.String(), timezone.Offset(time.Now(), loc),
+	)
+}
+
+// records 范围内已经发生的决策记录（别名 d），不包含决策时间在未来的计划
+func (r *StatsRepository) records(scope StatsScope) *gorm.DB {
+	query := r.db.Table("decision_records AS d").
+		Where("d.user_id IN ? AND d.decided_at <= ?", scope.UserIDs, time.Now())
+	if scope.From != nil {
+		query = query.Where("d.decided_at >= ?", *scope.From)
+	}
+	if scope.To != nil {
+		query = query.Where("d.decided_at < ?", *scope.To)
+	}
+	return query
+}
+
+// aggregates 范围内的月度汇总（别名 a）
+// 汇总只包含整月，当月第一天落在 From/To 的本地日期之间即计入
+func (r *StatsRepository) aggregates(scope StatsScope) *gorm.DB {
+	query := r.db.Table("decision_aggregates AS a").Where("a.user_id IN ?", scope.UserIDs)
+	if scope.From != nil {
+		query = query.Where("a.month >= ?", localDate(*scope.From))
+	}
+	if scope.To != nil {
+		query = query.Where("a.month < ?", localDate(*scope.To))
+	}
+	return query
+}
+
+// localDate 返回 t 所在时区的日期（以 UTC 0 点表示，用于和 DATE 列比较）
+func localDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// dishes 范围内吃过的菜品及次数（组合决策的每个菜品各计一次），列为 user_id, menu_id, n
+func (r *StatsRepository) dishes(scope StatsScope) *gorm.DB {
+	single := r.records(scope).
+		Select("d.user_id, d.menu_id, 1 AS n").
+		Where("d.mode <> ?", model.DecideModeCombo)
+	combo := r.records(scope).
+		Select("d.user_id, i.menu_id, 1 AS n").
+		Joins("JOIN decision_items i ON i.decision_id = d.id")
+	rolledUp := r.aggregates(scope).
+		Select("a.user_id, a.menu_id, a.dishes AS n").
+		Where("a.dishes > 0")
+	return r.db.Raw("? UNION ALL ? UNION ALL ?", single, combo, rolledUp)
+}
+
+// meals 范围内每次用餐的主记录菜品及次数（组合决策为第一个菜品），列为 user_id, menu_id, n
+func (r *StatsRepository) meals(scope StatsScope) *gorm.DB {
+	raw := r.records(scope).Select("d.user_id, d.menu_id, 1 AS n")
+	rolledUp := r.aggregates(scope).
+		Select("a.user_id, a.menu_id, a.meals AS n").
+		Where("a.meals > 0")
+	return r.db.Raw("? UNION ALL ?", raw, rolledUp)
+}
+
+// MealTotals 用餐次数和重复次数
+type MealTotals struct {
+	Meals   int64
+	Repeats int64
+}
+
+// CountMeals 统计用餐次数和选中最近吃过菜品的次数
+func (r *StatsRepository) CountMeals(scope StatsScope) (*MealTotals, error) {
+	var totals, rolledUp MealTotals
+	err := r.records(scope).
+		Select("COUNT(*) AS meals, COALESCE(SUM(d.recent_repeat), 0) AS repeats").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.aggregates(scope).
+		Select("COALESCE(SUM(a.meals), 0) AS meals, COALESCE(SUM(a.repeats), 0) AS repeats").
+		Scan(&rolledUp).Error
+	totals.Meals += rolledUp.Meals
+	totals.Repeats += rolledUp.Repeats
+	return &totals, err
+}
+
+// TopDishes 最常吃的菜品（组合决策的每个菜品都计入）
+func (r *StatsRepository) TopDishes(scope StatsScope, limit int) ([]model.DishCount, error) {
+	var dishes []model.DishCount
+	err := r.db.Table("(?) AS t", r.dishes(scope)).
+		Select("t.menu_id, m.dish_name, m.restaurant_id, rs.name AS restaurant_name, SUM(t.n) AS count").
+		Joins("JOIN menus m ON m.id = t.menu_id").
+		Joins("JOIN restaurants rs ON rs.id = m.restaurant_id").
+		Group("t.menu_id, m.dish_name, m.restaurant_id, rs.name").
+		Order("count DESC, t.menu_id ASC").
+		Limit(limit).
+		Scan(&dishes).Error
+	return dishes, err
+}
+
+// TopRestaurants 最常去的餐厅（每次用餐计一次）
+func (r *StatsRepository) TopRestaurants(scope StatsScope, limit int) ([]model.RestaurantCount, error) {
+	var restaurants []model.RestaurantCount
+	err := r.db.Table("(?) AS t", r.meals(scope)).
+		Select("m.restaurant_id, rs.name AS restaurant_name, SUM(t.n) AS count").
+		Joins("JOIN menus m ON m.id = t.menu_id").
+		Joins("JOIN restaurants rs ON rs.id = m.restaurant_id").
+		Group("m.restaurant_id, rs.name").
+		Order("count DESC, m.restaurant_id ASC").
+		Limit(limit).
+		Scan(&restaurants).Error
+	return restaurants, err
+}
+
+// CountByWeekday 按星期统计用餐次数（Weekday 0 表示星期日，只返回有记录的星期）
+func (r *StatsRepository) CountByWeekday(scope StatsScope) ([]model.WeekdayCount, error) {
+	var counts []model.WeekdayCount
+	raw := r.records(scope).
+		Select("DAYOFWEEK(?) - 1 AS weekday, COUNT(*) AS count", localTime("d.decided_at", scope.Location)).
+		Group("weekday")
+	rolledUp := r.aggregates(scope).
+		Select("a.weekday, SUM(a.meals) AS count").
+		Group("a.weekday")
+	err := r.db.Table("(?) AS t", r.db.Raw("? UNION ALL ?", raw, rolledUp)).
+		Select("t.weekday, SUM(t.count) AS count").
+		Group("t.weekday").
+		Order("t.weekday").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// WeekVariety 单周的去重统计
+type WeekVariety struct {
+	YearWeek    int // ISO 年周，如 202409
+	Meals       int64
+	Dishes      int64
+	Restaurants int64
+}
+
+// VarietyByWeek 按 ISO 周统计用餐次数、不同菜品数和不同餐厅数，最近的在前
+func (r *StatsRepository) VarietyByWeek(scope StatsScope, weeks int) ([]WeekVariety, error) {
+	var result []WeekVariety
+	err := r.records(scope).
+		Select("YEARWEEK(?, 3) AS year_week, COUNT(*) AS meals, "+
+			"COUNT(DISTINCT d.menu_id) AS dishes, COUNT(DISTINCT m.restaurant_id) AS restaurants",
+			localTime("d.decided_at", scope.Location)).
+		Joins("JOIN menus m ON m.id = d.menu_id").
+		Group("year_week").
+		Order("year_week DESC").
+		Limit(weeks).
+		Scan(&result).Error
+	return result, err
+}
+
+// RestaurantStreaks 连续不重复餐厅的用餐次数
+type RestaurantStreaks struct {
+	Longest int // 最长的一段
+	Current int // 截至最后一次用餐的一段
+}
+
+// RestaurantStreaks 按决策时间顺序计算连续不重复餐厅的用餐次数（一段连续记录中每家餐厅最多出现一次）
+// 全部在数据库中用窗口函数计算：每次用餐向前找到同一餐厅的上一次用餐，
+// 截至第 n 次用餐的一段从此前所有"上一次"中最靠后的一次之后开始
+func (r *StatsRepository) RestaurantStreaks(scope StatsScope) (RestaurantStreaks, error) {
+	// 用餐序号
+	meals := r.records(scope).
+		Joins("JOIN menus m ON m.id = d.menu_id").
+		Select("m.restaurant_id, ROW_NUMBER() OVER (ORDER BY d.decided_at, d.id) AS rn")
+	// 同一餐厅上一次用餐的序号
+	previous := r.db.Table("(?) AS s", meals).
+		Select("s.rn, LAG(s.rn) OVER (PARTITION BY s.restaurant_id ORDER BY s.rn) AS prev_rn")
+	// 截至每次用餐的连续次数
+	streaks := r.db.Table("(?) AS p", previous).
+		Select("p.rn, p.rn - COALESCE(MAX(p.prev_rn) OVER (ORDER BY p.rn ROWS UNBOUNDED PRECEDING), 0) AS streak, " +
+			"COUNT(*) OVER () AS total")
+
+	var result RestaurantStreaks
+	err := r.db.Table("(?) AS k", streaks).
+		Select("COALESCE(MAX(k.streak), 0) AS longest, COALESCE(MAX(CASE WHEN k.rn = k.total THEN k.streak END), 0) AS current").
+		Scan(&result).Error
+	return result, err
+}
+
+// UserDishTotals 单个用户吃过的菜品数和不同菜品数
+type UserDishTotals struct {
+	UserID   int64
+	Username string
+	Dishes   int64 // 菜品总数（组合决策的每个菜品各计一次）
+	Unique   int64 // 不同菜品数
+}
+
+// DishTotalsByUser 按用户统计吃过的菜品数和不同菜品数（只返回有记录的用户）
+func (r *StatsRepository) DishTotalsByUser(scope StatsScope) ([]UserDishTotals, error) {
+	var totals []UserDishTotals
+	err := r.db.Table("(?) AS t", r.dishes(scope)).
+		Select("t.user_id, u.username, SUM(t.n) AS dishes, COUNT(DISTINCT t.menu_id) AS `unique`").
+		Joins("JOIN users u ON u.id = t.user_id").
+		Group("t.user_id, u.username").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// DayRestaurantCount 某一天某家餐厅的用餐次数
+type DayRestaurantCount struct {
+	Day            time.Time
+	RestaurantID   int64
+	RestaurantName string
+	Count          int64
+}
+
+// CountByDayAndRestaurant 按天（Location 时区的日期）和餐厅统计用餐次数（按日期和次数排序）
+func (r *StatsRepository) CountByDayAndRestaurant(scope StatsScope) ([]DayRestaurantCount, error) {
+	var counts []DayRestaurantCount
+	err := r.records(scope).
+		Select("DATE(?) AS day, m.restaurant_id, rs.name AS restaurant_name, COUNT(*) AS count",
+			localTime("d.decided_at", scope.Location)).
+		Joins("JOIN menus m ON m.id = d.menu_id").
+		Joins("JOIN restaurants rs ON rs.id = m.restaurant_id").
+		Group("day, m.restaurant_id, rs.name").
+		Order("day ASC, count DESC, m.restaurant_id ASC").
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,51 @@
+//go:build mysql
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"what-to-eat/internal/model"
+)
+
+func TestStatsRepository_RestaurantStreaks(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewStatsRepository(db)
+
+	user := model.User{Username: "alice", PasswordHash: "x"}
+	mustCreate(t, db, &user)
+	other := model.User{Username: "bob", PasswordHash: "x"}
+	mustCreate(t, db, &other)
+
+	menus := map[string]model.Menu{}
+	for _, name := range []string{"A", "B", "C", "D"} {
+		menus[name] = createMenu(t, db, "餐厅"+name, "招牌菜")
+	}
+
+	// A B C D A A：最长的一段为 4 次，最后一次与上一次是同一餐厅，当前一段为 1 次
+	base := time.Now().UTC().AddDate(0, 0, -10)
+	for i, name := range []string{"A", "B", "C", "D", "A", "A"} {
+		mustCreate(t, db, &model.DecisionRecord{UserID: user.ID, MenuID: menus[name].ID, DecidedAt: base.AddDate(0, 0, i)})
+	}
+	// 其他用户和未来的计划不计入
+	mustCreate(t, db, &model.DecisionRecord{UserID: other.ID, MenuID: menus["B"].ID, DecidedAt: base.AddDate(0, 0, 6)})
+	mustCreate(t, db, &model.DecisionRecord{UserID: user.ID, MenuID: menus["B"].ID, DecidedAt: time.Now().UTC().AddDate(0, 0, 7),
+		Origin: model.DecisionOriginPlan})
+
+	got, err := repo.RestaurantStreaks(StatsScope{UserIDs: []int64{user.ID}})
+	if err != nil {
+		t.Fatalf("RestaurantStreaks: %v", err)
+	}
+	if got.Longest != 4 || got.Current != 1 {
+		t.Errorf("streaks = %+v, want longest 4, current 1", got)
+	}
+
+	empty, err := repo.RestaurantStreaks(StatsScope{UserIDs: []int64{user.ID + other.ID + 100}})
+	if err != nil {
+		t.Fatalf("RestaurantStreaks without records: %v", err)
+	}
+	if empty != (RestaurantStreaks{}) {
+		t.Errorf("streaks without records = %+v, want zero", empty)
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"what-to-eat/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type TeamRepository struct {
+	db *gorm.DB
+}
+
+func NewTeamRepository(db *gorm.DB) *TeamRepository {
+	return &TeamRepository{db: db}
+}
+
+// Create 创建团队并将创建者加入团队
+func (r *TeamRepository) Create(team *model.Team) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(team).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.TeamMember{TeamID: team.ID, UserID: team.OwnerID}).Error
+	})
+}
+
+// GetByID 根据ID查询团队
+func (r *TeamRepository) GetByID(id int64) (*model.Team, error) {
+	var team model.Team
+	if err := r.db.First(&team, id).Error; err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// GetByInviteCode 根据邀请码查询团队
+func (r *TeamRepository) GetByInviteCode(code string) (*model.Team, error) {
+	var team model.Team
+	if err := r.db.Where("invite_code = ?", code).First(&team).Error; err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// GetByUserID 获取用户加入的全部团队
+func (r *TeamRepository) GetByUserID(userID int64) ([]model.Team, error) {
+	var teams []model.Team
+	err := r.db.Where("id IN (?)",
+		r.db.Model(&model.TeamMember{}).Select("team_id").Where("user_id = ?", userID)).
+		Order("id ASC").
+		Find(&teams).Error
+	return teams, err
+}
+
+// AddMember 加入团队（已是成员时忽略）
+func (r *TeamRepository) AddMember(teamID, userID int64) error {
+	var count int64
+	if err := r.db.Model(&model.TeamMember{}).
+		Where("team_id = ? AND user_id = ?", teamID, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return r.db.Create(&model.TeamMember{TeamID: teamID, UserID: userID}).Error
+}
+
+// RemoveMember 退出团队
+func (r *TeamRepository) RemoveMember(teamID, userID int64) error {
+	return r.db.Where("team_id = ? AND user_id = ?", teamID, userID).
+		Delete(&model.TeamMember{}).Error
+}
+
+// GetMemberIDs 获取团队成员的用户ID
+func (r *TeamRepository) GetMemberIDs(teamID int64) ([]int64, error) {
+	var ids []int64
+	err := r.db.Model(&model.TeamMember{}).
+		Where("team_id = ?", teamID).
+		Order("id ASC").
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"time"
+
+	"what-to-eat/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type TokenRepository struct {
+	db *gorm.DB
+}
+
+func NewTokenRepository(db *gorm.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+// CreateRefreshToken 保存刷新令牌
+func (r *TokenRepository) CreateRefreshToken(token *model.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetRefreshTokenByHash 根据令牌哈希查询刷新令牌
+func (r *TokenRepository) GetRefreshTokenByHash(hash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed 把尚未使用的刷新令牌标记为已使用，返回是否标记成功
+// 并发刷新同一个令牌时只有一个请求能成功，其余请求视为重复使用
+func (r *TokenRepository) MarkRefreshTokenUsed(id int64, at time.Time) (bool, error) {
+	result := r.db.Model(&model.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeRefreshTokenFamily 作废整个家族的刷新令牌
+func (r *TokenRepository) RevokeRefreshTokenFamily(familyID string, at time.Time) error {
+	return r.db.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+// DeleteExpiredRefreshTokens 删除在 before 之前过期的刷新令牌
+func (r *TokenRepository) DeleteExpiredRefreshTokens(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&model.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
+
+// RefreshTokenFamilyActive 检查用户的令牌家族（登录会话）是否仍有未作废、未过期的刷新令牌
+func (r *TokenRepository) RefreshTokenFamilyActive(familyID string, userID int64, now time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", familyID, userID, now).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ListActiveRefreshTokenFamilies 查询用户尚未作废的令牌家族
+func (r *TokenRepository) ListActiveRefreshTokenFamilies(userID int64) ([]string, error) {
+	var families []string
+	err := r.db.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Distinct().
+		Pluck("family_id", &families).Error
+	return families, err
+}
+
+// RevokeUserRefreshTokens 作废用户的全部刷新令牌
+func (r *TokenRepository) RevokeUserRefreshTokens(userID int64, at time.Time) error {
+	return r.db.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
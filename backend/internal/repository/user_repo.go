@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"what-to-eat/internal/model"
 
 	"gorm.io/gorm"
@@ -48,3 +50,299 @@ func (r *UserRepository) ExistsByUsername(username string) (bool, error) {
 	}
 	return count > 0, nil
 }
+
+// UpdateTimeZone 更新用户的时区
+func (r *UserRepository) UpdateTimeZone(id int64, timeZone string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("time_zone", timeZone).Error
+}
+
+// GetByCalendarKey 根据日历订阅令牌的哈希查询用户
+func (r *UserRepository) GetByCalendarKey(key string) (*model.User, error) {
+	var user model.User
+	err := r.db.Where("calendar_key = ? AND calendar_key <> ''", key).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateCalendarKey 更新用户的日历订阅令牌哈希（为空表示关闭订阅）
+func (r *UserRepository) UpdateCalendarKey(id int64, key string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("calendar_key", key).Error
+}
+
+// GetGuestByDeviceKey 根据设备标识的哈希查询游客
+func (r *UserRepository) GetGuestByDeviceKey(key string) (*model.User, error) {
+	var user model.User
+	err := r.db.Where("device_key = ? AND role = ?", key, model.RoleGuest).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateLastSeen 更新用户最近一次登录或刷新令牌的时间
+func (r *UserRepository) UpdateLastSeen(id int64, at time.Time) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).UpdateColumn("last_seen_at", at).Error
+}
+
+// ListInactiveGuestIDs 查询在 before 之后没有活动的游客（从未活动的按创建时间计算）
+func (r *UserRepository) ListInactiveGuestIDs(before time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	err := r.db.Model(&model.User{}).
+		Where("role = ? AND COALESCE(last_seen_at, created_at) < ?", model.RoleGuest, before).
+		Order("id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// DeleteGuests 删除游客及其全部数据（决策记录、月度汇总、收藏、屏蔽、团队成员关系和刷新令牌）
+// 游客创建的团队转给最早加入的剩余成员，没有剩余成员的团队随之删除
+func (r *UserRepository) DeleteGuests(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		records := tx.Model(&model.DecisionRecord{}).Select("id").Where("user_id IN ?", ids)
+		if err := tx.Where("decision_id IN (?)", records).Delete(&model.DecisionItem{}).Error; err != nil {
+			return err
+		}
+		for _, table := range []interface{}{
+			&model.DecisionRecord{},
+			&model.DecisionAggregate{},
+			&model.UserFavorite{},
+			&model.UserBlock{},
+			&model.TeamMember{},
+			&model.RefreshToken{},
+		} {
+			if err := tx.Where("user_id IN ?", ids).Delete(table).Error; err != nil {
+				return err
+			}
+		}
+		if err := reassignOwnedTeams(tx, ids); err != nil {
+			return err
+		}
+		return tx.Where("id IN ? AND role = ?", ids, model.RoleGuest).Delete(&model.User{}).Error
+	})
+}
+
+// reassignOwnedTeams 处理被删除用户创建的团队（需要在删除其成员关系之后调用）
+func reassignOwnedTeams(tx *gorm.DB, ownerIDs []int64) error {
+	var teams []model.Team
+	if err := tx.Where("owner_id IN ?", ownerIDs).Find(&teams).Error; err != nil {
+		return err
+	}
+	if len(teams) == 0 {
+		return nil
+	}
+	teamIDs := make([]int64, len(teams))
+	for i, t := range teams {
+		teamIDs[i] = t.ID
+	}
+	var members []model.TeamMember
+	if err := tx.Where("team_id IN ?", teamIDs).Find(&members).Error; err != nil {
+		return err
+	}
+
+	owners, empty := planTeamOwners(teams, members)
+	for teamID, ownerID := range owners {
+		if err := tx.Model(&model.Team{}).Where("id = ?", teamID).Update("owner_id", ownerID).Error; err != nil {
+			return err
+		}
+	}
+	if len(empty) > 0 {
+		return tx.Where("id IN ?", empty).Delete(&model.Team{}).Error
+	}
+	return nil
+}
+
+// planTeamOwners 为失去创建者的团队选出新的创建者（最早加入的剩余成员，同时加入时取先加入的记录）
+// members 为这些团队剩余的成员关系，返回团队ID到新创建者的映射和没有剩余成员的团队
+func planTeamOwners(teams []model.Team, members []model.TeamMember) (map[int64]int64, []int64) {
+	earliest := make(map[int64]model.TeamMember, len(teams))
+	for _, m := range members {
+		cur, ok := earliest[m.TeamID]
+		if !ok || m.JoinedAt.Before(cur.JoinedAt) || (m.JoinedAt.Equal(cur.JoinedAt) && m.ID < cur.ID) {
+			earliest[m.TeamID] = m
+		}
+	}
+
+	owners := make(map[int64]int64)
+	var empty []int64
+	for _, t := range teams {
+		if m, ok := earliest[t.ID]; ok {
+			owners[t.ID] = m.UserID
+		} else {
+			empty = append(empty, t.ID)
+		}
+	}
+	return owners, empty
+}
+
+// ConvertGuest 把游客转为注册用户（用户ID不变，历史记录和偏好随之保留）
+// 旧令牌携带游客用户名，转换成功时在同一事务中作废游客的全部刷新令牌
+func (r *UserRepository) ConvertGuest(id int64, username, passwordHash string, revokedAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).Where("id = ? AND role = ?", id, model.RoleGuest).Updates(map[string]interface{}{
+			"username":      username,
+			"password_hash": passwordHash,
+			"role":          model.RoleMember,
+			"device_key":    nil,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&model.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", revokedAt).Error
+	})
+}
+
+// MergeGuestInto 把游客的数据合并到已有用户后删除游客
+// 决策记录和团队直接转移；月度汇总按月份、菜品和星期累加；收藏、屏蔽和团队成员关系两者都有时只保留一条；
+// 已有用户没有设置时区时沿用游客的时区
+func (r *UserRepository) MergeGuestInto(guest *model.User, targetID int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.DecisionRecord{}).Where("user_id = ?", guest.ID).
+			Update("user_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Team{}).Where("owner_id = ?", guest.ID).
+			Update("owner_id", targetID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Exec("INSERT INTO decision_aggregates (user_id, month, menu_id, weekday, meals, dishes, repeats) "+
+			"SELECT ?, month, menu_id, weekday, meals, dishes, repeats FROM decision_aggregates WHERE user_id = ? "+
+			"ON DUPLICATE KEY UPDATE meals = decision_aggregates.meals + VALUES(meals), "+
+			"dishes = decision_aggregates.dishes + VALUES(dishes), repeats = decision_aggregates.repeats + VALUES(repeats)",
+			targetID, guest.ID).Error
+		if err != nil {
+			return err
+		}
+		for _, stmt := range []string{
+			"INSERT IGNORE INTO user_favorites (user_id, menu_id, created_at) " +
+				"SELECT ?, menu_id, created_at FROM user_favorites WHERE user_id = ?",
+			"INSERT IGNORE INTO user_blocks (user_id, target_type, target_id, created_at) " +
+				"SELECT ?, target_type, target_id, created_at FROM user_blocks WHERE user_id = ?",
+			"INSERT IGNORE INTO team_members (team_id, user_id, joined_at) " +
+				"SELECT team_id, ?, joined_at FROM team_members WHERE user_id = ?",
+		} {
+			if err := tx.Exec(stmt, targetID, guest.ID).Error; err != nil {
+				return err
+			}
+		}
+		for _, table := range []interface{}{
+			&model.DecisionAggregate{},
+			&model.UserFavorite{},
+			&model.UserBlock{},
+			&model.TeamMember{},
+			&model.RefreshToken{},
+		} {
+			if err := tx.Where("user_id = ?", guest.ID).Delete(table).Error; err != nil {
+				return err
+			}
+		}
+
+		if guest.TimeZone != "" {
+			if err := tx.Model(&model.User{}).Where("id = ? AND time_zone = ''", targetID).
+				Update("time_zone", guest.TimeZone).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("id = ? AND role = ?", guest.ID, model.RoleGuest).Delete(&model.User{}).Error
+	})
+}
+
+// List 分页查询用户（按ID升序），role 为空表示全部角色，query 按用户名模糊匹配
+func (r *UserRepository) List(role, query string, offset, limit int) ([]model.User, int64, error) {
+	db := r.db.Model(&model.User{})
+	if role != "" {
+		db = db.Where("role = ?", role)
+	}
+	if query != "" {
+		db = db.Where("username LIKE ?", "%"+query+"%")
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []model.User
+	err := db.Order("id ASC").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+// UpdateRole 更新用户角色
+func (r *UserRepository) UpdateRole(id int64, role string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("role", role).Error
+}
+
+// BootstrapAdmins 还没有任何管理员时把指定用户名的注册用户设为管理员，返回更新的用户数
+// 已有管理员后不再处理，角色以用户管理接口的调整为准
+func (r *UserRepository) BootstrapAdmins(usernames []string) (int64, error) {
+	if len(usernames) == 0 {
+		return 0, nil
+	}
+	var promoted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var admins int64
+		if err := tx.Model(&model.User{}).Where("role = ?", model.RoleAdmin).Count(&admins).Error; err != nil {
+			return err
+		}
+		if admins > 0 {
+			return nil
+		}
+		result := tx.Model(&model.User{}).
+			Where("username IN ? AND role = ?", usernames, model.RoleMember).
+			Update("role", model.RoleAdmin)
+		promoted = result.RowsAffected
+		return result.Error
+	})
+	return promoted, err
+}
+
+// UpdatePassword 更新用户密码（同时作废尚未使用的密码重置令牌）
+func (r *UserRepository) UpdatePassword(id int64, passwordHash string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password_hash": passwordHash,
+		"reset_key":     nil,
+		"reset_expires": nil,
+	}).Error
+}
+
+// SetResetKey 保存密码重置令牌的哈希和过期时间（之前的令牌失效）
+func (r *UserRepository) SetResetKey(id int64, key string, expires time.Time) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"reset_key":     key,
+		"reset_expires": expires,
+	}).Error
+}
+
+// GetByResetKey 根据密码重置令牌的哈希查询用户
+func (r *UserRepository) GetByResetKey(key string) (*model.User, error) {
+	var user model.User
+	err := r.db.Where("reset_key = ?", key).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ResetPassword 凭密码重置令牌更新密码，令牌随即失效，返回是否更新成功
+// 同一个令牌并发使用时只有一个请求能成功
+func (r *UserRepository) ResetPassword(id int64, key, passwordHash string) (bool, error) {
+	result := r.db.Model(&model.User{}).Where("id = ? AND reset_key = ?", id, key).Updates(map[string]interface{}{
+		"password_hash": passwordHash,
+		"reset_key":     nil,
+		"reset_expires": nil,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
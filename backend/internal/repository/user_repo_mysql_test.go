@@ -0,0 +1,112 @@
+//go:build mysql
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"what-to-eat/internal/model"
+)
+
+func TestUserRepository_MergeGuestInto(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewUserRepository(db)
+
+	guest := model.User{Username: "guest_abc", PasswordHash: "", Role: model.RoleGuest, TimeZone: "Asia/Shanghai"}
+	mustCreate(t, db, &guest)
+	target := model.User{Username: "alice", PasswordHash: "x"}
+	mustCreate(t, db, &target)
+	burger := createMenu(t, db, "麦当劳", "巨无霸")
+	cola := createMenu(t, db, "可口可乐", "可乐")
+	month := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	record := model.DecisionRecord{UserID: guest.ID, MenuID: burger.ID, DecidedAt: time.Now().UTC()}
+	mustCreate(t, db, &record)
+	owned := model.Team{Name: "游客的团队", InviteCode: "guest-team", OwnerID: guest.ID}
+	mustCreate(t, db, &owned)
+	shared := model.Team{Name: "共同的团队", InviteCode: "shared-team", OwnerID: target.ID}
+	mustCreate(t, db, &shared)
+	mustCreate(t, db, &[]model.TeamMember{
+		{TeamID: owned.ID, UserID: guest.ID},
+		{TeamID: shared.ID, UserID: guest.ID},
+		{TeamID: shared.ID, UserID: target.ID},
+	})
+	mustCreate(t, db, &[]model.DecisionAggregate{
+		{UserID: guest.ID, Month: month, MenuID: burger.ID, Weekday: 1, Meals: 1, Dishes: 1, Repeats: 1},
+		{UserID: guest.ID, Month: month, MenuID: cola.ID, Weekday: 1, Meals: 0, Dishes: 2},
+		{UserID: target.ID, Month: month, MenuID: burger.ID, Weekday: 1, Meals: 2, Dishes: 2},
+	})
+	mustCreate(t, db, &[]model.UserFavorite{
+		{UserID: guest.ID, MenuID: burger.ID},
+		{UserID: guest.ID, MenuID: cola.ID},
+		{UserID: target.ID, MenuID: burger.ID},
+	})
+	mustCreate(t, db, &[]model.UserBlock{
+		{UserID: guest.ID, TargetType: model.BlockTargetMenu, TargetID: cola.ID},
+		{UserID: target.ID, TargetType: model.BlockTargetMenu, TargetID: cola.ID},
+	})
+	mustCreate(t, db, &model.RefreshToken{UserID: guest.ID, FamilyID: "f", TokenHash: "h", ExpiresAt: time.Now().UTC().Add(time.Hour)})
+
+	if err := repo.MergeGuestInto(&guest, target.ID); err != nil {
+		t.Fatalf("MergeGuestInto: %v", err)
+	}
+
+	// 游客本身和仍指向游客的数据都应删除
+	for name, value := range map[string]interface{}{
+		"users":               &model.User{},
+		"decision_records":    &model.DecisionRecord{},
+		"decision_aggregates": &model.DecisionAggregate{},
+		"user_favorites":      &model.UserFavorite{},
+		"user_blocks":         &model.UserBlock{},
+		"team_members":        &model.TeamMember{},
+		"refresh_tokens":      &model.RefreshToken{},
+	} {
+		column := "user_id"
+		if name == "users" {
+			column = "id"
+		}
+		var n int64
+		if err := db.Model(value).Where(column+" = ?", guest.ID).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		if n != 0 {
+			t.Errorf("%s still has %d rows for the guest", name, n)
+		}
+	}
+
+	var moved model.DecisionRecord
+	if err := db.First(&moved, record.ID).Error; err != nil || moved.UserID != target.ID {
+		t.Errorf("record = %+v (err %v), want it moved to user %d", moved, err, target.ID)
+	}
+	var team model.Team
+	if err := db.First(&team, owned.ID).Error; err != nil || team.OwnerID != target.ID {
+		t.Errorf("team = %+v (err %v), want owner %d", team, err, target.ID)
+	}
+
+	if got := aggregatesOf(t, db, burger.ID); len(got) != 1 || got[0].Meals != 3 || got[0].Dishes != 3 || got[0].Repeats != 1 {
+		t.Errorf("burger aggregates = %+v, want one row with meals 3, dishes 3, repeats 1", got)
+	}
+	if got := aggregatesOf(t, db, cola.ID); len(got) != 1 || got[0].UserID != target.ID || got[0].Dishes != 2 {
+		t.Errorf("cola aggregates = %+v, want one row for the target with dishes 2", got)
+	}
+
+	counts := map[string]int64{"user_favorites": 2, "user_blocks": 1, "team_members": 2}
+	for table, want := range counts {
+		var n int64
+		if err := db.Table(table).Where("user_id = ?", target.ID).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Errorf("%s rows for target = %d, want %d", table, n, want)
+		}
+	}
+
+	var merged model.User
+	if err := db.First(&merged, target.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if merged.TimeZone != "Asia/Shanghai" {
+		t.Errorf("target time zone = %q, want the guest's Asia/Shanghai", merged.TimeZone)
+	}
+}
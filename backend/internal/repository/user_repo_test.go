@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"what-to-eat/internal/model"
+)
+
+func TestPlanTeamOwners(t *testing.T) {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	teams := []model.Team{
+		{ID: 1, OwnerID: 100}, // 游客创建、还有其他成员
+		{ID: 2, OwnerID: 100}, // 游客创建、只有游客自己
+		{ID: 3, OwnerID: 101}, // 同时加入的成员
+	}
+	members := []model.TeamMember{
+		{ID: 11, TeamID: 1, UserID: 7, JoinedAt: base.Add(2 * time.Hour)},
+		{ID: 12, TeamID: 1, UserID: 5, JoinedAt: base.Add(time.Hour)},
+		{ID: 13, TeamID: 1, UserID: 9, JoinedAt: base.Add(3 * time.Hour)},
+		{ID: 32, TeamID: 3, UserID: 8, JoinedAt: base},
+		{ID: 31, TeamID: 3, UserID: 6, JoinedAt: base},
+	}
+
+	owners, empty := planTeamOwners(teams, members)
+	if got := owners[1]; got != 5 {
+		t.Errorf("team 1 owner = %d, want 5 (earliest member)", got)
+	}
+	if got := owners[3]; got != 6 {
+		t.Errorf("team 3 owner = %d, want 6 (earlier membership)", got)
+	}
+	if _, ok := owners[2]; ok {
+		t.Errorf("team 2 should not get an owner")
+	}
+	if len(empty) != 1 || empty[0] != 2 {
+		t.Errorf("empty teams = %v, want [2]", empty)
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"what-to-eat/internal/model"
+)
+
+var ErrInvalidResetToken = errors.New("密码重置令牌无效或已过期")
+
+// hashPassword 使用 bcrypt 生成密码哈希
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// ChangePassword 修改密码（需要旧密码）
+// 修改后该用户的全部会话结束（包括其他设备），返回当前设备的新令牌
+func (s *AuthService) ChangePassword(userID int64, req *model.ChangePasswordRequest) (*model.LoginResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, notFoundOr(err, ErrUserNotFound)
+	}
+	if user.IsGuest() {
+		return nil, ErrGuestRole
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.OldPassword)); err != nil {
+		return nil, ErrInvalidPassword
+	}
+	if err := s.policy.Validate(req.NewPassword, user.Username); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := hashPassword(req.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return nil, err
+	}
+	if _, err := s.LogoutAll(user.ID); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user, "")
+}
+
+// CreatePasswordReset 为用户签发一次性密码重置令牌（之前的令牌失效），令牌只在签发时返回一次
+func (s *AuthService) CreatePasswordReset(userID int64) (string, time.Time, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return "", time.Time{}, notFoundOr(err, ErrUserNotFound)
+	}
+	if user.IsGuest() {
+		return "", time.Time{}, ErrGuestRole
+	}
+
+	token, err := newSecretToken(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires := time.Now().Add(s.resetTTL)
+	if err := s.userRepo.SetResetKey(user.ID, hashToken(token), expires); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expires, nil
+}
+
+// ResetPassword 凭一次性令牌设置新密码，令牌随即失效，该用户的全部会话结束
+func (s *AuthService) ResetPassword(req *model.ResetPasswordRequest) error {
+	key := hashToken(req.Token)
+	user, err := s.userRepo.GetByResetKey(key)
+	if err != nil {
+		return notFoundOr(err, ErrInvalidResetToken)
+	}
+	if user.ResetExpires == nil || !time.Now().Before(*user.ResetExpires) {
+		return ErrInvalidResetToken
+	}
+	if err := s.policy.Validate(req.NewPassword, user.Username); err != nil {
+		return err
+	}
+
+	hashedPassword, err := hashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	reset, err := s.userRepo.ResetPassword(user.ID, key, hashedPassword)
+	if err != nil {
+		return err
+	}
+	if !reset {
+		return ErrInvalidResetToken
+	}
+	_, err = s.LogoutAll(user.ID)
+	return err
+}
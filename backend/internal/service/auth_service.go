@@ -2,35 +2,79 @@ package service
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 
 	"what-to-eat/internal/model"
 	"what-to-eat/internal/repository"
 )
 
 var (
-	ErrUserExists      = errors.New("用户名已存在")
-	ErrUserNotFound    = errors.New("用户不存在")
-	ErrInvalidPassword = errors.New("密码错误")
+	ErrUserExists          = errors.New("用户名已存在")
+	ErrUserNotFound        = errors.New("用户不存在")
+	ErrInvalidPassword     = errors.New("密码错误")
+	ErrInvalidRefreshToken = errors.New("刷新令牌无效或已过期")
+	ErrRefreshTokenReused  = errors.New("刷新令牌已被使用，请重新登录")
+	ErrReservedUsername    = errors.New("该用户名保留给游客使用")
+	ErrNotGuest            = errors.New("当前账号不是游客")
 )
 
+// 游客用户名前缀（后面是随机字符），每批清理的游客数
+const (
+	guestUsernamePrefix = "guest-"
+	guestPurgeBatchSize = 100
+)
+
+// TokenConfig 令牌配置：访问令牌（JWT）短期有效，过期后凭刷新令牌换取新的访问令牌
+type TokenConfig struct {
+	Secret     string
+	AccessTTL  time.Duration // 访问令牌有效期
+	RefreshTTL time.Duration // 刷新令牌有效期（每次刷新重新计算）
+	ResetTTL   time.Duration // 管理员签发的密码重置令牌有效期
+}
+
 type AuthService struct {
-	userRepo  *repository.UserRepository
-	jwtSecret []byte
+	userRepo   *repository.UserRepository
+	tokenRepo  *repository.TokenRepository
+	jwtSecret  []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	resetTTL   time.Duration
+	sessions   *sessionCache
+	policy     *PasswordPolicy
 }
 
-func NewAuthService(userRepo *repository.UserRepository, jwtSecret string) *AuthService {
+func NewAuthService(
+	userRepo *repository.UserRepository,
+	tokenRepo *repository.TokenRepository,
+	tokens TokenConfig,
+	policy *PasswordPolicy,
+) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		jwtSecret: []byte(jwtSecret),
+		userRepo:   userRepo,
+		tokenRepo:  tokenRepo,
+		jwtSecret:  []byte(tokens.Secret),
+		accessTTL:  tokens.AccessTTL,
+		refreshTTL: tokens.RefreshTTL,
+		resetTTL:   tokens.ResetTTL,
+		sessions:   newSessionCache(sessionCacheTTL),
+		policy:     policy,
 	}
 }
 
 // Register 用户注册
 func (s *AuthService) Register(req *model.RegisterRequest) (*model.User, error) {
+	if strings.HasPrefix(req.Username, guestUsernamePrefix) {
+		return nil, ErrReservedUsername
+	}
+	if err := s.policy.Validate(req.Password, req.Username); err != nil {
+		return nil, err
+	}
+
 	// 检查用户名是否已存在
 	exists, err := s.userRepo.ExistsByUsername(req.Username)
 	if err != nil {
@@ -41,14 +85,14 @@ func (s *AuthService) Register(req *model.RegisterRequest) (*model.User, error)
 	}
 
 	// 加密密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(req.Password)
 	if err != nil {
 		return nil, err
 	}
 
 	user := &model.User{
 		Username:     req.Username,
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
@@ -70,26 +114,162 @@ func (s *AuthService) Login(req *model.LoginRequest) (*model.LoginResponse, erro
 		return nil, ErrInvalidPassword
 	}
 
-	// 生成 JWT Token
-	token, err := s.generateToken(user)
+	return s.issueTokens(user, "")
+}
+
+// Refresh 凭刷新令牌换取新的访问令牌和刷新令牌（旧的刷新令牌随即失效）
+// 已轮换过的刷新令牌再次使用时作废整个家族，持有者（包括正常客户端）都需要重新登录
+func (s *AuthService) Refresh(refreshToken string) (*model.LoginResponse, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	token, err := s.tokenRepo.GetRefreshTokenByHash(hashToken(refreshToken))
+	if err != nil {
+		return nil, notFoundOr(err, ErrInvalidRefreshToken)
+	}
+
+	now := time.Now()
+	if token.RevokedAt != nil || !now.Before(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if token.UsedAt != nil {
+		return nil, s.revokeReusedFamily(token, now)
+	}
+	marked, err := s.tokenRepo.MarkRefreshTokenUsed(token.ID, now)
 	if err != nil {
 		return nil, err
 	}
+	if !marked {
+		// 并发请求抢先轮换了同一个令牌
+		return nil, s.revokeReusedFamily(token, now)
+	}
+
+	user, err := s.userRepo.GetByID(token.UserID)
+	if err != nil {
+		return nil, notFoundOr(err, ErrInvalidRefreshToken)
+	}
+	return s.issueTokens(user, token.FamilyID)
+}
+
+// revokeReusedFamily 作废被重复使用的刷新令牌所在的家族（同时结束该登录会话）
+func (s *AuthService) revokeReusedFamily(token *model.RefreshToken, now time.Time) error {
+	if err := s.tokenRepo.RevokeRefreshTokenFamily(token.FamilyID, now); err != nil {
+		return err
+	}
+	s.sessions.set(token.FamilyID, token.UserID, false, now)
+	return ErrRefreshTokenReused
+}
+
+// Logout 退出当前登录会话：会话的刷新令牌作废，已签发的访问令牌随即失效
+func (s *AuthService) Logout(userID int64, sessionID string) error {
+	now := time.Now()
+	if err := s.tokenRepo.RevokeRefreshTokenFamily(sessionID, now); err != nil {
+		return err
+	}
+	s.sessions.set(sessionID, userID, false, now)
+	return nil
+}
+
+// LogoutAll 退出用户在所有设备上的登录会话，返回结束的会话数
+func (s *AuthService) LogoutAll(userID int64) (int, error) {
+	families, err := s.tokenRepo.ListActiveRefreshTokenFamilies(userID)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	if err := s.tokenRepo.RevokeUserRefreshTokens(userID, now); err != nil {
+		return 0, err
+	}
+	s.forgetSessions(userID, families, now)
+	return len(families), nil
+}
+
+// forgetSessions 把已作废的会话记入缓存，使其访问令牌立即失效
+func (s *AuthService) forgetSessions(userID int64, families []string, now time.Time) {
+	for _, familyID := range families {
+		s.sessions.set(familyID, userID, false, now)
+	}
+}
+
+// SessionActive 检查访问令牌所属的登录会话是否仍然有效（供 JWT 中间件使用，结果会短暂缓存）
+func (s *AuthService) SessionActive(userID int64, sessionID string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+	now := time.Now()
+	if active, ok := s.sessions.get(sessionID, userID, now); ok {
+		return active, nil
+	}
+	active, err := s.tokenRepo.RefreshTokenFamilyActive(sessionID, userID, now)
+	if err != nil {
+		return false, err
+	}
+	s.sessions.set(sessionID, userID, active, now)
+	return active, nil
+}
+
+// PurgeExpiredTokens 删除已过期的刷新令牌
+func (s *AuthService) PurgeExpiredTokens(now time.Time) (int64, error) {
+	return s.tokenRepo.DeleteExpiredRefreshTokens(now)
+}
+
+// issueTokens 签发访问令牌和刷新令牌，familyID 为空表示新的登录（新的令牌家族）
+// 令牌家族同时作为登录会话：访问令牌携带家族 ID，会话结束后访问令牌立即失效
+func (s *AuthService) issueTokens(user *model.User, familyID string) (*model.LoginResponse, error) {
+	var err error
+	if familyID == "" {
+		if familyID, err = newSecretToken(16); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if err := s.userRepo.UpdateLastSeen(user.ID, now); err != nil {
+		return nil, err
+	}
+	accessToken, err := s.generateToken(user, familyID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := newSecretToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokenRepo.CreateRefreshToken(&model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(s.refreshTTL),
+	}); err != nil {
+		return nil, err
+	}
 
 	return &model.LoginResponse{
-		Token:    token,
-		UserID:   user.ID,
-		Username: user.Username,
+		Token:        accessToken,
+		ExpiresIn:    int64(s.accessTTL / time.Second),
+		RefreshToken: refreshToken,
+		UserID:       user.ID,
+		Username:     user.Username,
+		Role:         user.Role,
 	}, nil
 }
 
-// generateToken 生成 JWT Token
-func (s *AuthService) generateToken(user *model.User) (string, error) {
+// generateToken 生成 JWT Token（有效期为配置的访问令牌有效期）
+// jti 为令牌的唯一 ID，sid 为所属的登录会话（刷新令牌家族），role 为签发时的用户角色
+func (s *AuthService) generateToken(user *model.User, sessionID string, now time.Time) (string, error) {
+	tokenID, err := newSecretToken(16)
+	if err != nil {
+		return "", err
+	}
 	claims := jwt.MapClaims{
 		"user_id":  user.ID,
 		"username": user.Username,
-		"exp":      time.Now().Add(24 * time.Hour).Unix(),
-		"iat":      time.Now().Unix(),
+		"role":     user.Role,
+		"jti":      tokenID,
+		"sid":      sessionID,
+		"exp":      now.Add(s.accessTTL).Unix(),
+		"iat":      now.Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -121,25 +301,124 @@ func (s *AuthService) GetUserByID(id int64) (*model.User, error) {
 	return s.userRepo.GetByID(id)
 }
 
-// GuestLogin 游客登录（使用预设的游客账号）
-func (s *AuthService) GuestLogin() (*model.LoginResponse, error) {
-	// 游客用户ID固定为1
-	const guestUserID int64 = 1
+// GuestLogin 游客登录：每个设备一个匿名游客，同一设备再次登录时继续使用之前的游客
+func (s *AuthService) GuestLogin(deviceID string) (*model.LoginResponse, error) {
+	key := hashToken(deviceID)
+	user, err := s.userRepo.GetGuestByDeviceKey(key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err = s.createGuest(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, "")
+}
 
-	user, err := s.userRepo.GetByID(guestUserID)
+// createGuest 为设备创建匿名游客，用户名随机生成
+func (s *AuthService) createGuest(deviceKey string) (*model.User, error) {
+	suffix, err := newSecretToken(6)
 	if err != nil {
-		return nil, ErrUserNotFound
+		return nil, err
+	}
+	user := &model.User{
+		Username:  guestUsernamePrefix + suffix,
+		Role:      model.RoleGuest,
+		DeviceKey: &deviceKey,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		// 同一设备并发登录时另一个请求已经创建了游客
+		if existing, getErr := s.userRepo.GetGuestByDeviceKey(deviceKey); getErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// Upgrade 游客升级为注册用户，或合并到已有账号（需要该账号的密码）
+// 两种方式都保留游客的决策记录、收藏、屏蔽和设置；游客原有的登录会话全部结束，返回新账号的令牌
+func (s *AuthService) Upgrade(guestID int64, req *model.UpgradeRequest) (*model.LoginResponse, error) {
+	guest, err := s.userRepo.GetByID(guestID)
+	if err != nil {
+		return nil, notFoundOr(err, ErrUserNotFound)
+	}
+	if !guest.IsGuest() {
+		return nil, ErrNotGuest
+	}
+
+	if req.Merge {
+		target, err := s.userRepo.GetByUsername(req.Username)
+		if err != nil || target.IsGuest() {
+			return nil, ErrUserNotFound
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(target.PasswordHash), []byte(req.Password)); err != nil {
+			return nil, ErrInvalidPassword
+		}
+		// 合并会删除游客的刷新令牌，成功后再把游客的会话标记为失效
+		families, err := s.tokenRepo.ListActiveRefreshTokenFamilies(guest.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.userRepo.MergeGuestInto(guest, target.ID); err != nil {
+			return nil, err
+		}
+		s.forgetSessions(guest.ID, families, time.Now())
+		return s.issueTokens(target, "")
+	}
+
+	if strings.HasPrefix(req.Username, guestUsernamePrefix) {
+		return nil, ErrReservedUsername
+	}
+	if err := s.policy.Validate(req.Password, req.Username); err != nil {
+		return nil, err
+	}
+	exists, err := s.userRepo.ExistsByUsername(req.Username)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrUserExists
+	}
+	hashedPassword, err := hashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+	families, err := s.tokenRepo.ListActiveRefreshTokenFamilies(guest.ID)
+	if err != nil {
+		return nil, err
+	}
+	// 转换失败时游客的会话保持不变
+	now := time.Now()
+	if err := s.userRepo.ConvertGuest(guest.ID, req.Username, hashedPassword, now); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotGuest
+		}
+		return nil, err
 	}
+	s.forgetSessions(guest.ID, families, now)
 
-	// 生成 JWT Token
-	token, err := s.generateToken(user)
+	user, err := s.userRepo.GetByID(guest.ID)
 	if err != nil {
 		return nil, err
 	}
+	return s.issueTokens(user, "")
+}
 
-	return &model.LoginResponse{
-		Token:    token,
-		UserID:   user.ID,
-		Username: user.Username,
-	}, nil
+// PurgeInactiveGuests 删除在 before 之后没有登录或刷新令牌的游客及其全部数据，返回删除的游客数
+func (s *AuthService) PurgeInactiveGuests(before time.Time) (int, error) {
+	total := 0
+	for {
+		ids, err := s.userRepo.ListInactiveGuestIDs(before, guestPurgeBatchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+		if err := s.userRepo.DeleteGuests(ids); err != nil {
+			return total, err
+		}
+		total += len(ids)
+	}
 }
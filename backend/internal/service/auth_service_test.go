@@ -1,10 +1,14 @@
 package service
 
 import (
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"what-to-eat/internal/model"
 )
 
 func TestAuthService_GenerateAndValidateToken(t *testing.T) {
@@ -71,6 +75,64 @@ func TestAuthService_GenerateAndValidateToken(t *testing.T) {
 	}
 }
 
+func TestAuthService_GenerateToken_AccessTTL(t *testing.T) {
+	authService := &AuthService{
+		jwtSecret: []byte("test-secret"),
+		accessTTL: 15 * time.Minute,
+	}
+	now := time.Now()
+
+	tokenString, err := authService.generateToken(&model.User{ID: 1, Username: "test"}, "session-1", now)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	claims, err := authService.ValidateToken(tokenString)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if exp := int64((*claims)["exp"].(float64)); exp != now.Add(15*time.Minute).Unix() {
+		t.Errorf("exp = %d, want %d", exp, now.Add(15*time.Minute).Unix())
+	}
+	if sid := (*claims)["sid"]; sid != "session-1" {
+		t.Errorf("sid = %v, want session-1", sid)
+	}
+
+	// 超过有效期的令牌验证失败
+	expired, err := authService.generateToken(&model.User{ID: 1, Username: "test"}, "session-1", now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := authService.ValidateToken(expired); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Errorf("ValidateToken(expired) error = %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestAuthService_GenerateToken_UniqueID(t *testing.T) {
+	authService := &AuthService{
+		jwtSecret: []byte("test-secret"),
+		accessTTL: 15 * time.Minute,
+	}
+	user := &model.User{ID: 1, Username: "test"}
+	now := time.Now()
+
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		tokenString, err := authService.generateToken(user, "session-1", now)
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		claims, err := authService.ValidateToken(tokenString)
+		if err != nil {
+			t.Fatalf("Failed to validate token: %v", err)
+		}
+		jti, _ := (*claims)["jti"].(string)
+		if jti == "" || seen[jti] {
+			t.Errorf("jti = %q, want a unique token ID", jti)
+		}
+		seen[jti] = true
+	}
+}
+
 func TestAuthService_ValidateToken_Invalid(t *testing.T) {
 	authService := &AuthService{
 		jwtSecret: []byte("test-secret"),
@@ -117,6 +179,31 @@ func TestAuthService_ValidateToken_Invalid(t *testing.T) {
 	}
 }
 
+func TestAuthService_Register_ReservedGuestPrefix(t *testing.T) {
+	authService := &AuthService{}
+	_, err := authService.Register(&model.RegisterRequest{Username: guestUsernamePrefix + "abc", Password: "secret123"})
+	if !errors.Is(err, ErrReservedUsername) {
+		t.Errorf("Register() error = %v, want ErrReservedUsername", err)
+	}
+}
+
+func TestAuthService_Register_WeakPassword(t *testing.T) {
+	policy, err := NewPasswordPolicy(8, 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authService := &AuthService{policy: policy}
+	_, err = authService.Register(&model.RegisterRequest{Username: "alice", Password: "12345678"})
+	if !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("Register() error = %v, want ErrWeakPassword", err)
+	}
+	// 超过 bcrypt 上限的密码在注册时同样被拒绝
+	_, err = authService.Register(&model.RegisterRequest{Username: "alice", Password: "Lunch-" + strings.Repeat("x", 80)})
+	if !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("Register() error = %v, want ErrWeakPassword", err)
+	}
+}
+
 func TestPasswordHashing(t *testing.T) {
 	// 测试密码哈希的基本功能
 	password := "testpassword123"
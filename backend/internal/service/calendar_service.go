@@ -0,0 +1,141 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+	"what-to-eat/pkg/ical"
+	"what-to-eat/pkg/timezone"
+)
+
+var ErrInvalidCalendarToken = errors.New("日历订阅链接无效或已失效")
+
+// 日历订阅包含最近 calendarPastDays 天的记录和全部未来的计划，建议日历客户端每小时刷新
+const (
+	calendarPastDays = 90
+	calendarRefresh  = time.Hour
+	calendarProdID   = "-//what-to-eat//Lunch Calendar//CN"
+)
+
+// 日历事件描述中的来源名称
+var originLabels = map[string]string{
+	model.DecisionOriginSpin:   "随机决策",
+	model.DecisionOriginManual: "手动记录",
+	model.DecisionOriginPlan:   "计划",
+	model.DecisionOriginGroup:  "团体聚餐",
+}
+
+// CalendarService 日历订阅：每个用户一个秘密令牌，凭令牌只读访问已确认的用餐和计划
+// 数据库只保存令牌的 SHA-256，重新生成或撤销后旧链接立即失效
+type CalendarService struct {
+	userRepo     *repository.UserRepository
+	decisionRepo *repository.DecisionRepository
+	timeZones    *TimeZoneService
+}
+
+func NewCalendarService(
+	userRepo *repository.UserRepository,
+	decisionRepo *repository.DecisionRepository,
+	timeZones *TimeZoneService,
+) *CalendarService {
+	return &CalendarService{userRepo: userRepo, decisionRepo: decisionRepo, timeZones: timeZones}
+}
+
+// CreateToken 生成新的订阅令牌（之前的令牌失效），令牌只在生成时返回一次
+func (s *CalendarService) CreateToken(userID int64) (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	if err := s.userRepo.UpdateCalendarKey(userID, calendarKey(token)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeToken 撤销订阅令牌
+func (s *CalendarService) RevokeToken(userID int64) error {
+	return s.userRepo.UpdateCalendarKey(userID, "")
+}
+
+// Feed 凭令牌生成日历订阅内容（.ics）
+func (s *CalendarService) Feed(token string) ([]byte, error) {
+	if token == "" {
+		return nil, ErrInvalidCalendarToken
+	}
+	user, err := s.userRepo.GetByCalendarKey(calendarKey(token))
+	if err != nil {
+		return nil, notFoundOr(err, ErrInvalidCalendarToken)
+	}
+
+	loc := s.timeZones.resolve(user.TimeZone)
+	now := time.Now()
+	since := timezone.StartOfDay(now.In(loc).AddDate(0, 0, -calendarPastDays), loc)
+	records, err := s.decisionRepo.GetCalendarRecords(user.ID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &ical.Calendar{
+		ProdID:  calendarProdID,
+		Name:    "今天吃什么 - " + user.Username,
+		Refresh: calendarRefresh,
+		Events:  buildCalendarEvents(records, loc, now),
+	}
+	return cal.Marshal(), nil
+}
+
+// buildCalendarEvents 每条记录生成一个事件：标题为餐厅和菜品，时间为所在用餐时段（按用户时区）
+// 已确认的用餐为 CONFIRMED，尚未确认的计划为 TENTATIVE
+func buildCalendarEvents(records []model.DecisionRecord, loc *time.Location, now time.Time) []ical.Event {
+	events := make([]ical.Event, 0, len(records))
+	for i := range records {
+		record := &records[i]
+		menus := record.Menus()
+		dishes := make([]string, 0, len(menus))
+		for _, m := range menus {
+			dishes = append(dishes, m.DishName)
+		}
+		restaurant := record.Menu.Restaurant.Name
+		if restaurant == "" && len(menus) > 0 {
+			restaurant = menus[0].Restaurant.Name
+		}
+
+		description := originLabels[record.Origin]
+		if record.Note != "" {
+			description += "：" + record.Note
+		}
+
+		start, end := model.MealSlotWindow(record.DecidedAt.In(loc))
+		event := ical.Event{
+			UID:         fmt.Sprintf("decision-%d@what-to-eat", record.ID),
+			Start:       start,
+			End:         end,
+			Summary:     restaurant + " · " + strings.Join(dishes, "、"),
+			Description: description,
+			Location:    restaurant,
+			Status:      ical.StatusTentative,
+			Stamp:       now,
+		}
+		if record.ConfirmedAt != nil {
+			event.Status = ical.StatusConfirmed
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// calendarKey 令牌的 SHA-256（数据库中只保存哈希）
+func calendarKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/pkg/ical"
+)
+
+func TestBuildCalendarEvents(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	confirmedAt := time.Date(2024, 3, 1, 5, 0, 0, 0, time.UTC)
+	restaurant := model.Restaurant{Name: "兰州拉面"}
+
+	records := []model.DecisionRecord{
+		{
+			ID:          1,
+			Origin:      model.DecisionOriginSpin,
+			DecidedAt:   time.Date(2024, 3, 1, 4, 0, 0, 0, time.UTC), // 北京时间 12:00
+			ConfirmedAt: &confirmedAt,
+			Menu:        model.Menu{DishName: "牛肉面", Restaurant: restaurant},
+		},
+		{
+			ID:        2,
+			Mode:      model.DecideModeCombo,
+			Origin:    model.DecisionOriginPlan,
+			Note:      "周五聚餐",
+			DecidedAt: time.Date(2024, 3, 8, 11, 0, 0, 0, time.UTC), // 北京时间 19:00
+			Menu:      model.Menu{DishName: "烤串", Restaurant: restaurant},
+			Items: []model.DecisionItem{
+				{Menu: model.Menu{DishName: "烤串", Restaurant: restaurant}},
+				{Menu: model.Menu{DishName: "啤酒", Restaurant: restaurant}},
+			},
+		},
+	}
+
+	events := buildCalendarEvents(records, loc, now)
+	if len(events) != 2 {
+		t.Fatalf("len(events) = %d, want 2", len(events))
+	}
+
+	lunch := events[0]
+	if lunch.Summary != "兰州拉面 · 牛肉面" || lunch.Status != ical.StatusConfirmed || lunch.UID != "decision-1@what-to-eat" {
+		t.Errorf("lunch event = %+v", lunch)
+	}
+	if want := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC); !lunch.Start.Equal(want) {
+		t.Errorf("lunch start = %v, want %v (10:00 in Shanghai)", lunch.Start.UTC(), want)
+	}
+	if want := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC); !lunch.End.Equal(want) {
+		t.Errorf("lunch end = %v, want %v (15:00 in Shanghai)", lunch.End.UTC(), want)
+	}
+
+	plan := events[1]
+	if plan.Summary != "兰州拉面 · 烤串、啤酒" || plan.Status != ical.StatusTentative || plan.Description != "计划：周五聚餐" {
+		t.Errorf("plan event = %+v", plan)
+	}
+}
+
+func TestCalendarKey(t *testing.T) {
+	if calendarKey("a") == calendarKey("b") {
+		t.Error("different tokens should have different keys")
+	}
+	if got := len(calendarKey("token")); got != 64 {
+		t.Errorf("len(calendarKey) = %d, want 64", got)
+	}
+}
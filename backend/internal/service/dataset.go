@@ -0,0 +1,318 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"what-to-eat/internal/model"
+)
+
+// DatasetFormat 数据集文件格式
+type DatasetFormat string
+
+const (
+	FormatCSV  DatasetFormat = "csv"
+	FormatJSON DatasetFormat = "json"
+	FormatYAML DatasetFormat = "yaml"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("不支持的文件格式，仅支持 csv、json、yaml")
+	ErrInvalidCSVHeader  = errors.New("CSV 表头缺少 restaurant 列")
+	ErrInvalidDataset    = errors.New("数据格式错误")
+)
+
+// csv 列名
+const (
+	csvColRestaurant = "restaurant"
+	csvColCuisine    = "cuisine"
+	csvColDish       = "dish"
+	csvColRole       = "role"
+	csvColKcal       = "kcal"
+	csvColProtein    = "protein"
+	csvColCarbs      = "carbs"
+	csvColFat        = "fat"
+
+	csvColRestaurantImage     = "restaurant_image_url"
+	csvColRestaurantThumbnail = "restaurant_thumbnail_url"
+	csvColImage               = "image_url"
+	csvColThumbnail           = "thumbnail_url"
+)
+
+// csvHeader 导出 CSV 的表头（与导入时识别的列名一致）
+var csvHeader = []string{
+	csvColRestaurant, csvColCuisine, csvColDish, csvColRole, csvColKcal, csvColProtein, csvColCarbs, csvColFat,
+	csvColRestaurantImage, csvColRestaurantThumbnail, csvColImage, csvColThumbnail,
+}
+
+// ParseDatasetFormat 解析格式名称（支持 yml 别名）
+func ParseDatasetFormat(name string) (DatasetFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "csv":
+		return FormatCSV, nil
+	case "json":
+		return FormatJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// DetectDatasetFormat 根据文件名后缀推断格式
+func DetectDatasetFormat(filename string) (DatasetFormat, error) {
+	return ParseDatasetFormat(strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// ContentType 返回格式对应的 MIME 类型
+func (f DatasetFormat) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSON:
+		return "application/json; charset=utf-8"
+	case FormatYAML:
+		return "application/yaml; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// EncodeDataset 将数据集按指定格式写出，输出可以被 DecodeImportRows 原样读回
+func EncodeDataset(format DatasetFormat, w io.Writer, dataset *model.MenuDataset) error {
+	switch format {
+	case FormatCSV:
+		return encodeCSV(w, dataset)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(dataset)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(dataset); err != nil {
+			return err
+		}
+		return encoder.Close()
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// encodeCSV 每个菜品输出一行，没有菜品的餐厅输出一行空菜品
+func encodeCSV(w io.Writer, dataset *model.MenuDataset) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, restaurant := range dataset.Restaurants {
+		if len(restaurant.Dishes) == 0 {
+			if err := writer.Write(csvRecord(&restaurant, model.DatasetDish{})); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, dish := range restaurant.Dishes {
+			if err := writer.Write(csvRecord(&restaurant, dish)); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvRecord 生成一行 CSV 记录（列顺序与 csvHeader 一致）
+func csvRecord(restaurant *model.DatasetRestaurant, dish model.DatasetDish) []string {
+	record := []string{restaurant.Name, restaurant.Cuisine, dish.Name, dish.Role, "", "", "", "",
+		restaurant.ImageURL, restaurant.ThumbnailURL, dish.ImageURL, dish.ThumbnailURL}
+	if n := dish.Nutrition; n != nil {
+		if n.Kcal != nil {
+			record[4] = strconv.Itoa(*n.Kcal)
+		}
+		record[5] = formatOptionalFloat(n.Protein)
+		record[6] = formatOptionalFloat(n.Carbs)
+		record[7] = formatOptionalFloat(n.Fat)
+	}
+	return record
+}
+
+// formatOptionalFloat 格式化可选数值，nil 输出空字符串
+func formatOptionalFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+// DecodeImportRows 读取数据集并展开为导入行
+func DecodeImportRows(format DatasetFormat, r io.Reader) ([]model.ImportRow, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSVRows(r)
+	case FormatJSON:
+		var dataset model.MenuDataset
+		if err := json.NewDecoder(r).Decode(&dataset); err != nil {
+			return nil, fmt.Errorf("%w: 解析 JSON 失败: %w", ErrInvalidDataset, err)
+		}
+		return flattenDataset(&dataset), nil
+	case FormatYAML:
+		var dataset model.MenuDataset
+		if err := yaml.NewDecoder(r).Decode(&dataset); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: 解析 YAML 失败: %w", ErrInvalidDataset, err)
+		}
+		return flattenDataset(&dataset), nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// decodeCSVRows 解析 CSV（首行为表头，至少包含 restaurant 列，dish 列可选）
+func decodeCSVRows(r io.Reader) ([]model.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // 允许列数不一致，缺失的列按空值处理
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: 解析 CSV 失败: %w", ErrInvalidDataset, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) // 兼容 Excel 导出的 BOM
+		columns[name] = i
+	}
+	if _, ok := columns[csvColRestaurant]; !ok {
+		return nil, ErrInvalidCSVHeader
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	var rows []model.ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: 解析 CSV 失败: %w", ErrInvalidDataset, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := model.ImportRow{
+			Line:           line,
+			RestaurantName: field(record, csvColRestaurant),
+			Cuisine:        field(record, csvColCuisine),
+			DishName:       field(record, csvColDish),
+			Role:           field(record, csvColRole),
+			RestaurantImage: model.Image{
+				URL:          strings.TrimSpace(field(record, csvColRestaurantImage)),
+				ThumbnailURL: strings.TrimSpace(field(record, csvColRestaurantThumbnail)),
+			},
+			DishImage: model.Image{
+				URL:          strings.TrimSpace(field(record, csvColImage)),
+				ThumbnailURL: strings.TrimSpace(field(record, csvColThumbnail)),
+			},
+		}
+		row.Nutrition, err = parseCSVNutrition(
+			field(record, csvColKcal), field(record, csvColProtein),
+			field(record, csvColCarbs), field(record, csvColFat),
+		)
+		if err != nil {
+			row.Error = err.Error()
+		}
+		// 跳过空行
+		if strings.TrimSpace(row.RestaurantName) == "" && strings.TrimSpace(row.DishName) == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseCSVNutrition 解析 CSV 中的营养信息列，全部为空时返回 nil
+func parseCSVNutrition(kcal, protein, carbs, fat string) (*model.Nutrition, error) {
+	var n model.Nutrition
+	if kcal = strings.TrimSpace(kcal); kcal != "" {
+		v, err := strconv.Atoi(kcal)
+		if err != nil {
+			return nil, fmt.Errorf("kcal 不是有效的整数: %q", kcal)
+		}
+		n.Kcal = &v
+	}
+
+	for _, col := range []struct {
+		name  string
+		value string
+		dest  **float64
+	}{
+		{csvColProtein, protein, &n.Protein},
+		{csvColCarbs, carbs, &n.Carbs},
+		{csvColFat, fat, &n.Fat},
+	} {
+		value := strings.TrimSpace(col.value)
+		if value == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s 不是有效的数值: %q", col.name, value)
+		}
+		*col.dest = &v
+	}
+
+	if n.IsEmpty() {
+		return nil, nil
+	}
+	return &n, nil
+}
+
+// flattenDataset 将嵌套的数据集展开为导入行，没有菜品的餐厅展开为一条只含餐厅的记录
+func flattenDataset(dataset *model.MenuDataset) []model.ImportRow {
+	var rows []model.ImportRow
+	for _, restaurant := range dataset.Restaurants {
+		restaurantImage := model.Image{URL: restaurant.ImageURL, ThumbnailURL: restaurant.ThumbnailURL}
+		if len(restaurant.Dishes) == 0 {
+			rows = append(rows, model.ImportRow{
+				Line:            len(rows) + 1,
+				RestaurantName:  restaurant.Name,
+				Cuisine:         restaurant.Cuisine,
+				RestaurantImage: restaurantImage,
+			})
+			continue
+		}
+		for _, dish := range restaurant.Dishes {
+			rows = append(rows, model.ImportRow{
+				Line:            len(rows) + 1,
+				RestaurantName:  restaurant.Name,
+				Cuisine:         restaurant.Cuisine,
+				DishName:        dish.Name,
+				Role:            dish.Role,
+				Nutrition:       dish.Nutrition,
+				RestaurantImage: restaurantImage,
+				DishImage:       model.Image{URL: dish.ImageURL, ThumbnailURL: dish.ThumbnailURL},
+			})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"what-to-eat/internal/model"
+)
+
+func TestParseDatasetFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    DatasetFormat
+		wantErr bool
+	}{
+		{name: "csv", input: "csv", want: FormatCSV},
+		{name: "upper case json", input: "JSON", want: FormatJSON},
+		{name: "yml alias", input: "yml", want: FormatYAML},
+		{name: "unsupported", input: "xlsx", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDatasetFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDatasetFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDatasetFormat(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeImportRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   DatasetFormat
+		input    string
+		wantRows [][2]string
+		wantLine []int
+	}{
+		{
+			name:     "csv with header and blank line",
+			format:   FormatCSV,
+			input:    "\ufeffRestaurant,Dish\n麦当劳,巨无霸\n\n肯德基,蛋挞\n海底捞\n",
+			wantRows: [][2]string{{"麦当劳", "巨无霸"}, {"肯德基", "蛋挞"}, {"海底捞", ""}},
+			wantLine: []int{2, 4, 5},
+		},
+		{
+			name:     "json nested dataset",
+			format:   FormatJSON,
+			input:    `{"restaurants":[{"name":"麦当劳","dishes":[{"name":"巨无霸"},{"name":"薯条"}]},{"name":"海底捞"}]}`,
+			wantRows: [][2]string{{"麦当劳", "巨无霸"}, {"麦当劳", "薯条"}, {"海底捞", ""}},
+			wantLine: []int{1, 2, 3},
+		},
+		{
+			name:   "yaml nested dataset",
+			format: FormatYAML,
+			input: `restaurants:
+  - name: 兰州拉面
+    dishes:
+      - name: 牛肉面
+`,
+			wantRows: [][2]string{{"兰州拉面", "牛肉面"}},
+			wantLine: []int{1},
+		},
+		{
+			name:   "empty yaml",
+			format: FormatYAML,
+			input:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := DecodeImportRows(tt.format, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("DecodeImportRows() error = %v", err)
+			}
+			if len(rows) != len(tt.wantRows) {
+				t.Fatalf("DecodeImportRows() returned %d rows, want %d", len(rows), len(tt.wantRows))
+			}
+			for i, row := range rows {
+				if row.RestaurantName != tt.wantRows[i][0] || row.DishName != tt.wantRows[i][1] {
+					t.Errorf("row %d = (%q, %q), want (%q, %q)",
+						i, row.RestaurantName, row.DishName, tt.wantRows[i][0], tt.wantRows[i][1])
+				}
+				if row.Line != tt.wantLine[i] {
+					t.Errorf("row %d line = %d, want %d", i, row.Line, tt.wantLine[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeImportRows_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  DatasetFormat
+		input   string
+		wantErr error
+	}{
+		{name: "csv without restaurant column", format: FormatCSV, input: "name,dish\n麦当劳,薯条\n", wantErr: ErrInvalidCSVHeader},
+		{name: "malformed json", format: FormatJSON, input: `{"restaurants":[`, wantErr: ErrInvalidDataset},
+		{name: "unknown format", format: DatasetFormat("xml"), input: "", wantErr: ErrUnsupportedFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeImportRows(tt.format, strings.NewReader(tt.input))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DecodeImportRows() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateImportRow(t *testing.T) {
+	long := strings.Repeat("面", maxNameLength+1)
+
+	kcal, negative := 500, -1.0
+
+	tests := []struct {
+		name       string
+		restaurant string
+		dish       string
+		nutrition  *model.Nutrition
+		valid      bool
+	}{
+		{name: "valid", restaurant: "麦当劳", dish: "薯条", valid: true},
+		{name: "restaurant only", restaurant: "麦当劳", valid: true},
+		{name: "empty restaurant", restaurant: "", dish: "薯条", valid: false},
+		{name: "restaurant too long", restaurant: long, dish: "薯条", valid: false},
+		{name: "dish too long", restaurant: "麦当劳", dish: long, valid: false},
+		{name: "with nutrition", restaurant: "麦当劳", dish: "薯条", nutrition: &model.Nutrition{Kcal: &kcal}, valid: true},
+		{name: "nutrition without dish", restaurant: "麦当劳", nutrition: &model.Nutrition{Kcal: &kcal}, valid: false},
+		{name: "negative nutrition", restaurant: "麦当劳", dish: "薯条", nutrition: &model.Nutrition{Fat: &negative}, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := validateImportRow(tt.restaurant, tt.dish, tt.nutrition)
+			if (msg == "") != tt.valid {
+				t.Errorf("validateImportRow() = %q, want valid=%v", msg, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidateImportImages(t *testing.T) {
+	image := model.Image{URL: "/uploads/menus/a.jpg"}
+	if msg := validateImportImages("", model.Image{}, image); msg == "" {
+		t.Error("dish image without dish should fail")
+	}
+	if msg := validateImportImages("", image, model.Image{}); msg != "" {
+		t.Errorf("restaurant image only: %s", msg)
+	}
+	long := model.Image{URL: "/" + strings.Repeat("a", maxImageURLLength)}
+	if msg := validateImportImages("牛肉面", model.Image{}, long); msg == "" {
+		t.Error("too long url should fail")
+	}
+}
+
+func TestParseCSVNutrition(t *testing.T) {
+	tests := []struct {
+		name                      string
+		kcal, protein, carbs, fat string
+		wantNil                   bool
+		wantErr                   bool
+	}{
+		{name: "all empty", wantNil: true},
+		{name: "kcal only", kcal: "550"},
+		{name: "all fields", kcal: "550", protein: "25.5", carbs: "45", fat: " 30 "},
+		{name: "invalid kcal", kcal: "abc", wantErr: true},
+		{name: "fractional kcal", kcal: "550.5", wantErr: true},
+		{name: "invalid fat", fat: "1g", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := parseCSVNutrition(tt.kcal, tt.protein, tt.carbs, tt.fat)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCSVNutrition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (n == nil) != tt.wantNil {
+				t.Errorf("parseCSVNutrition() = %+v, wantNil %v", n, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestEncodeDataset_RoundTrip(t *testing.T) {
+	kcal, protein := 550, 25.5
+	dataset := &model.MenuDataset{
+		Restaurants: []model.DatasetRestaurant{
+			{Name: "麦当劳", Cuisine: model.CuisineFastFood, ImageURL: "/uploads/restaurants/mcd.jpg", ThumbnailURL: "/uploads/restaurants/mcd_thumb.jpg", Dishes: []model.DatasetDish{
+				{Name: "巨无霸", Role: model.MenuRoleMain, Nutrition: &model.Nutrition{Kcal: &kcal, Protein: &protein},
+					ImageURL: "/uploads/menus/bigmac.jpg", ThumbnailURL: "/uploads/menus/bigmac_thumb.jpg"},
+				{Name: "薯条, 大份"},
+			}},
+			{Name: "海底捞"},
+		},
+	}
+	want := flattenDataset(dataset)
+
+	for _, format := range []DatasetFormat{FormatCSV, FormatJSON, FormatYAML} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodeDataset(format, &buf, dataset); err != nil {
+				t.Fatalf("EncodeDataset() error = %v", err)
+			}
+
+			rows, err := DecodeImportRows(format, &buf)
+			if err != nil {
+				t.Fatalf("DecodeImportRows() error = %v", err)
+			}
+			if len(rows) != len(want) {
+				t.Fatalf("round trip returned %d rows, want %d", len(rows), len(want))
+			}
+			for i := range rows {
+				if rows[i].RestaurantName != want[i].RestaurantName || rows[i].DishName != want[i].DishName ||
+					rows[i].Cuisine != want[i].Cuisine || rows[i].Role != want[i].Role {
+					t.Errorf("row %d = %+v, want %+v", i, rows[i], want[i])
+				}
+				if rows[i].RestaurantImage != want[i].RestaurantImage || rows[i].DishImage != want[i].DishImage {
+					t.Errorf("row %d images = %+v %+v, want %+v %+v", i,
+						rows[i].RestaurantImage, rows[i].DishImage, want[i].RestaurantImage, want[i].DishImage)
+				}
+				if !sameNutrition(rows[i].Nutrition, want[i].Nutrition) {
+					t.Errorf("row %d nutrition = %+v, want %+v", i, rows[i].Nutrition, want[i].Nutrition)
+				}
+			}
+		})
+	}
+}
+
+// sameNutrition 比较两个营养信息是否相同
+func sameNutrition(a, b *model.Nutrition) bool {
+	if a.IsEmpty() || b.IsEmpty() {
+		return a.IsEmpty() == b.IsEmpty()
+	}
+	sameInt := func(x, y *int) bool { return (x == nil && y == nil) || (x != nil && y != nil && *x == *y) }
+	sameFloat := func(x, y *float64) bool { return (x == nil && y == nil) || (x != nil && y != nil && *x == *y) }
+	return sameInt(a.Kcal, b.Kcal) && sameFloat(a.Protein, b.Protein) &&
+		sameFloat(a.Carbs, b.Carbs) && sameFloat(a.Fat, b.Fat)
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"what-to-eat/internal/model"
+)
+
+var (
+	ErrInvalidCombo        = errors.New("组合菜品数量不能少于角色要求数量")
+	ErrNoComboCandidates   = errors.New("没有能满足组合要求的餐厅")
+	ErrComboExceedCalories = errors.New("没有总热量在上限以内的组合")
+)
+
+// 组合决策默认菜品数量
+const defaultComboSize = 2
+
+// 组合模式总热量超限时的重试次数
+const comboKcalAttempts = 20
+
+// comboSpec 组合要求
+type comboSpec struct {
+	size  int      // 菜品数量
+	roles []string // 角色要求，每项选出一个对应角色的菜品
+}
+
+// newComboSpec 根据请求生成组合要求
+func newComboSpec(req *model.DecideRequest) (comboSpec, error) {
+	spec := comboSpec{size: req.Size, roles: req.Roles}
+	if spec.size == 0 {
+		spec.size = max(defaultComboSize, len(spec.roles))
+	}
+	if spec.size < len(spec.roles) {
+		return comboSpec{}, ErrInvalidCombo
+	}
+	return spec, nil
+}
+
+// satisfiedBy 判断餐厅的菜品能否满足组合要求
+func (c comboSpec) satisfiedBy(menus []model.Menu) bool {
+	if len(menus) < c.size {
+		return false
+	}
+	available := make(map[string]int)
+	for _, m := range menus {
+		available[m.Role]++
+	}
+	for _, role := range c.roles {
+		if available[role] == 0 {
+			return false
+		}
+		available[role]--
+	}
+	return true
+}
+
+// comboChoice 组合决策的结果
+type comboChoice struct {
+	restaurant model.Restaurant
+	items      []model.DecisionItem
+}
+
+// totalKcal 组合的总热量，有菜品未填写热量时返回 nil
+func (c *comboChoice) totalKcal() *int {
+	total := 0
+	for _, item := range c.items {
+		if item.Menu.Nutrition.Kcal == nil {
+			return nil
+		}
+		total += *item.Menu.Nutrition.Kcal
+	}
+	return &total
+}
+
+// pickCombo 组合决策：先按餐厅级权重选出餐厅，再在该餐厅内按角色要求和菜品级权重选出菜品
+// 用户收藏的菜品在餐厅内选择时乘以收藏权重倍数
+func pickCombo(
+	rng *rand.Rand,
+	menus []model.Menu,
+	recent *recentSet,
+	prefs *userPreferences,
+	w DecisionWeights,
+	spec comboSpec,
+) (*comboChoice, error) {
+	// 按餐厅分组，保留能满足组合要求的餐厅
+	var restaurantIDs []int64
+	byRestaurant := make(map[int64][]model.Menu)
+	for _, m := range menus {
+		if _, ok := byRestaurant[m.RestaurantID]; !ok {
+			restaurantIDs = append(restaurantIDs, m.RestaurantID)
+		}
+		byRestaurant[m.RestaurantID] = append(byRestaurant[m.RestaurantID], m)
+	}
+
+	var candidates [][]model.Menu
+	for _, id := range restaurantIDs {
+		if spec.satisfiedBy(byRestaurant[id]) {
+			candidates = append(candidates, byRestaurant[id])
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoComboCandidates
+	}
+
+	// 选餐厅
+	weights := make([]float64, len(candidates))
+	for i, c := range candidates {
+		weights[i] = recent.restaurantWeight(c[0].RestaurantID, c[0].Restaurant.Cuisine, w)
+	}
+	restaurantMenus := candidates[weightedIndex(rng, weights)]
+
+	// 选菜品：先满足角色要求，再从剩余菜品中补足数量
+	choice := &comboChoice{restaurant: restaurantMenus[0].Restaurant}
+	picked := make(map[int64]bool)
+	pick := func(role string, match func(*model.Menu) bool) {
+		var pool []*model.Menu
+		var poolWeights []float64
+		for i := range restaurantMenus {
+			m := &restaurantMenus[i]
+			if picked[m.ID] || !match(m) {
+				continue
+			}
+			pool = append(pool, m)
+			poolWeights = append(poolWeights, recent.dishWeight(m, w)*prefs.favoriteFactor(m.ID, w))
+		}
+		selected := pool[weightedIndex(rng, poolWeights)]
+		picked[selected.ID] = true
+		choice.items = append(choice.items, model.DecisionItem{MenuID: selected.ID, Role: role, Menu: *selected})
+	}
+
+	for _, role := range spec.roles {
+		pick(role, func(m *model.Menu) bool { return m.Role == role })
+	}
+	for len(choice.items) < spec.size {
+		pick("", func(*model.Menu) bool { return true })
+	}
+	return choice, nil
+}
+
+// pickComboWithinKcal 选出总热量不超过上限的组合（菜品需已按上限过滤），多次随机后仍超限时返回错误
+func pickComboWithinKcal(
+	rng *rand.Rand,
+	menus []model.Menu,
+	recent *recentSet,
+	prefs *userPreferences,
+	w DecisionWeights,
+	spec comboSpec,
+	maxKcal int,
+) (*comboChoice, error) {
+	for i := 0; i < comboKcalAttempts; i++ {
+		choice, err := pickCombo(rng, menus, recent, prefs, w, spec)
+		if err != nil {
+			return nil, err
+		}
+		if total := choice.totalKcal(); total != nil && *total <= maxKcal {
+			return choice, nil
+		}
+	}
+	return nil, ErrComboExceedCalories
+}
+
+// generateComboMessage 生成组合决策的响应消息
+func generateComboMessage(choice *comboChoice) string {
+	return fmt.Sprintf("就去%s，点这%d样！", choice.restaurant.Name, len(choice.items))
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"what-to-eat/internal/model"
+)
+
+func TestNewComboSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      model.DecideRequest
+		wantSize int
+		wantErr  error
+	}{
+		{name: "default size", req: model.DecideRequest{}, wantSize: 2},
+		{name: "size from roles", req: model.DecideRequest{Roles: []string{"main", "side", "drink"}}, wantSize: 3},
+		{name: "explicit size", req: model.DecideRequest{Size: 4, Roles: []string{"main"}}, wantSize: 4},
+		{name: "size smaller than roles", req: model.DecideRequest{Size: 1, Roles: []string{"main", "side"}}, wantErr: ErrInvalidCombo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := newComboSpec(&tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("newComboSpec() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && spec.size != tt.wantSize {
+				t.Errorf("size = %d, want %d", spec.size, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestComboSpecSatisfiedBy(t *testing.T) {
+	menus := []model.Menu{
+		{ID: 1, Role: model.MenuRoleMain},
+		{ID: 2, Role: model.MenuRoleSide},
+		{ID: 3},
+	}
+
+	tests := []struct {
+		name string
+		spec comboSpec
+		want bool
+	}{
+		{name: "enough dishes", spec: comboSpec{size: 3}, want: true},
+		{name: "too few dishes", spec: comboSpec{size: 4}, want: false},
+		{name: "roles available", spec: comboSpec{size: 2, roles: []string{"main", "side"}}, want: true},
+		{name: "missing role", spec: comboSpec{size: 2, roles: []string{"main", "drink"}}, want: false},
+		{name: "role needed twice", spec: comboSpec{size: 2, roles: []string{"main", "main"}}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.spec.satisfiedBy(menus); got != tt.want {
+				t.Errorf("satisfiedBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickCombo(t *testing.T) {
+	mcd := model.Restaurant{ID: 1, Name: "麦当劳", Cuisine: model.CuisineFastFood}
+	noodles := model.Restaurant{ID: 2, Name: "兰州拉面", Cuisine: model.CuisineNoodles}
+	menus := []model.Menu{
+		{ID: 1, RestaurantID: 1, Restaurant: mcd, DishName: "巨无霸", Role: model.MenuRoleMain},
+		{ID: 2, RestaurantID: 1, Restaurant: mcd, DishName: "麦辣鸡腿堡", Role: model.MenuRoleMain},
+		{ID: 3, RestaurantID: 1, Restaurant: mcd, DishName: "薯条", Role: model.MenuRoleSide},
+		{ID: 4, RestaurantID: 1, Restaurant: mcd, DishName: "可乐", Role: model.MenuRoleDrink},
+		{ID: 5, RestaurantID: 2, Restaurant: noodles, DishName: "牛肉面", Role: model.MenuRoleMain},
+	}
+	rng := rand.New(rand.NewSource(1))
+	recent := newRecentSet(nil)
+	spec := comboSpec{size: 3, roles: []string{model.MenuRoleMain, model.MenuRoleSide}}
+
+	for i := 0; i < 100; i++ {
+		choice, err := pickCombo(rng, menus, recent, nil, testDecisionWeights, spec)
+		if err != nil {
+			t.Fatalf("pickCombo() error = %v", err)
+		}
+		// 兰州拉面只有一个菜品，无法满足组合要求
+		if choice.restaurant.ID != mcd.ID {
+			t.Fatalf("restaurant = %d, want %d", choice.restaurant.ID, mcd.ID)
+		}
+		if len(choice.items) != 3 {
+			t.Fatalf("got %d items, want 3", len(choice.items))
+		}
+		if choice.items[0].Menu.Role != model.MenuRoleMain || choice.items[0].Role != model.MenuRoleMain {
+			t.Errorf("item 0 = %+v, want a main dish", choice.items[0])
+		}
+		if choice.items[1].Menu.Role != model.MenuRoleSide || choice.items[1].Role != model.MenuRoleSide {
+			t.Errorf("item 1 = %+v, want a side dish", choice.items[1])
+		}
+		seen := make(map[int64]bool)
+		for _, item := range choice.items {
+			if seen[item.MenuID] {
+				t.Fatalf("dish %d picked twice", item.MenuID)
+			}
+			seen[item.MenuID] = true
+		}
+	}
+
+	if _, err := pickCombo(rng, menus, recent, nil, testDecisionWeights, comboSpec{size: 5}); !errors.Is(err, ErrNoComboCandidates) {
+		t.Errorf("pickCombo() error = %v, want %v", err, ErrNoComboCandidates)
+	}
+}
+
+func TestPickComboWithinKcal(t *testing.T) {
+	restaurant := model.Restaurant{ID: 1, Name: "麦当劳"}
+	kcal := func(v int) model.Nutrition { return model.Nutrition{Kcal: &v} }
+	menus := []model.Menu{
+		{ID: 1, RestaurantID: 1, Restaurant: restaurant, Nutrition: kcal(500)},
+		{ID: 2, RestaurantID: 1, Restaurant: restaurant, Nutrition: kcal(300)},
+		{ID: 3, RestaurantID: 1, Restaurant: restaurant, Nutrition: kcal(600)},
+	}
+	rng := rand.New(rand.NewSource(1))
+	recent := newRecentSet(nil)
+
+	choice, err := pickComboWithinKcal(rng, menus, recent, nil, testDecisionWeights, comboSpec{size: 2}, 800)
+	if err != nil {
+		t.Fatalf("pickComboWithinKcal() error = %v", err)
+	}
+	if total := choice.totalKcal(); total == nil || *total > 800 {
+		t.Errorf("total kcal = %v, want <= 800", total)
+	}
+
+	if _, err := pickComboWithinKcal(rng, menus, recent, nil, testDecisionWeights, comboSpec{size: 2}, 700); !errors.Is(err, ErrComboExceedCalories) {
+		t.Errorf("pickComboWithinKcal() error = %v, want %v", err, ErrComboExceedCalories)
+	}
+}
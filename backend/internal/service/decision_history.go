@@ -0,0 +1,230 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+)
+
+var (
+	ErrInvalidCursor   = errors.New("分页游标无效")
+	ErrComboMenuChange = errors.New("组合决策不能修改菜品")
+)
+
+// 历史记录分页的默认/最大数量
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+// GetHistory 按决策时间倒序分页获取用户的决策历史（日期范围和用餐时段按用户时区计算）
+func (s *DecisionService) GetHistory(userID int64, req *model.HistoryRequest) (*model.HistoryResponse, error) {
+	loc, err := s.timeZones.Location(userID)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := newHistoryFilter(req, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	// 多取一条用于判断是否还有下一页
+	records, err := s.decisionRepo.GetHistoryPage(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &model.HistoryResponse{Records: records}
+	if len(records) > filter.Limit-1 {
+		resp.Records = records[:filter.Limit-1]
+		resp.HasMore = true
+		last := resp.Records[len(resp.Records)-1]
+		resp.NextCursor = encodeHistoryCursor(last.DecidedAt, last.ID)
+	}
+	if resp.Total, err = s.decisionRepo.CountHistory(userID, filter); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AddHistory 手动添加决策记录（没有随机就去吃了、计划或团体聚餐），与随机决策一样参与最近决策的权重计算
+// 手动补记的用餐视为已确认，计入营养统计
+func (s *DecisionService) AddHistory(userID int64, req *model.CreateHistoryRequest) (*model.DecisionRecord, error) {
+	if _, err := s.menuRepo.GetByID(req.MenuID); err != nil {
+		return nil, notFoundOr(err, ErrMenuNotFound)
+	}
+
+	record := &model.DecisionRecord{
+		UserID:    userID,
+		MenuID:    req.MenuID,
+		Mode:      model.DecideModeSingle,
+		Origin:    req.Origin,
+		Note:      req.Note,
+		DecidedAt: time.Now(),
+	}
+	if record.Origin == "" {
+		record.Origin = model.DecisionOriginManual
+	}
+	if req.DecidedAt != nil {
+		record.DecidedAt = *req.DecidedAt
+	}
+	if record.Origin == model.DecisionOriginManual {
+		confirmedAt := record.DecidedAt
+		record.ConfirmedAt = &confirmedAt
+	}
+	if err := s.decisionRepo.Create(record); err != nil {
+		return nil, err
+	}
+	return s.decisionRepo.GetByIDAndUserID(record.ID, userID)
+}
+
+// UpdateHistory 修改决策记录（只修改请求中提供的字段）
+func (s *DecisionService) UpdateHistory(userID, recordID int64, req *model.UpdateHistoryRequest) (*model.DecisionRecord, error) {
+	record, err := s.decisionRepo.GetByIDAndUserID(recordID, userID)
+	if err != nil {
+		return nil, notFoundOr(err, ErrDecisionNotFound)
+	}
+
+	updates := make(map[string]interface{})
+	if req.MenuID != nil && *req.MenuID != record.MenuID {
+		if record.Mode == model.DecideModeCombo {
+			return nil, ErrComboMenuChange
+		}
+		if _, err := s.menuRepo.GetByID(*req.MenuID); err != nil {
+			return nil, notFoundOr(err, ErrMenuNotFound)
+		}
+		updates["menu_id"] = *req.MenuID
+	}
+	if req.DecidedAt != nil {
+		updates["decided_at"] = *req.DecidedAt
+	}
+	if req.Origin != nil {
+		updates["origin"] = *req.Origin
+	}
+	if req.Note != nil {
+		updates["note"] = *req.Note
+	}
+	if confirmedAt, changed := confirmedAtAfterUpdate(record, req); changed {
+		if confirmedAt != nil {
+			updates["confirmed_at"] = *confirmedAt
+		} else {
+			updates["confirmed_at"] = nil
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := s.decisionRepo.Update(record.ID, updates); err != nil {
+			return nil, err
+		}
+	}
+	return s.decisionRepo.GetByIDAndUserID(record.ID, userID)
+}
+
+// confirmedAtAfterUpdate 修改来源或用餐时间后的确认时间，changed 为 false 表示不需要修改
+// 手动补记与 AddHistory 一样视为在用餐时间确认；从手动补记改为其他来源时撤销这个自动确认（返回 nil），
+// 用户在其他时间确认过的保留
+func confirmedAtAfterUpdate(record *model.DecisionRecord, req *model.UpdateHistoryRequest) (confirmedAt *time.Time, changed bool) {
+	if req.Origin == nil && req.DecidedAt == nil {
+		return nil, false
+	}
+	origin := record.Origin
+	if req.Origin != nil {
+		origin = *req.Origin
+	}
+	if origin != model.DecisionOriginManual {
+		autoConfirmed := record.Origin == model.DecisionOriginManual &&
+			record.ConfirmedAt != nil && record.ConfirmedAt.Equal(record.DecidedAt)
+		return nil, autoConfirmed
+	}
+	at := record.DecidedAt
+	if req.DecidedAt != nil {
+		at = *req.DecidedAt
+	}
+	if record.ConfirmedAt != nil && record.ConfirmedAt.Equal(at) {
+		return nil, false
+	}
+	return &at, true
+}
+
+// DeleteHistory 删除决策记录
+func (s *DecisionService) DeleteHistory(userID, recordID int64) error {
+	affected, err := s.decisionRepo.DeleteByIDAndUserID(recordID, userID)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrDecisionNotFound
+	}
+	return nil
+}
+
+// newHistoryFilter 将请求转换为查询条件（Limit 比请求的数量多一条）
+func newHistoryFilter(req *model.HistoryRequest, loc *time.Location) (repository.HistoryFilter, error) {
+	filter := repository.HistoryFilter{
+		RestaurantID: req.RestaurantID,
+		Location:     loc,
+		Limit:        defaultHistoryLimit + 1,
+	}
+	if req.Limit > 0 {
+		filter.Limit = min(req.Limit, maxHistoryLimit) + 1
+	}
+
+	if req.From != "" {
+		from, err := time.ParseInLocation(summaryDateLayout, req.From, loc)
+		if err != nil {
+			return filter, ErrInvalidDateRange
+		}
+		filter.From = &from
+	}
+	if req.To != "" {
+		to, err := time.ParseInLocation(summaryDateLayout, req.To, loc)
+		if err != nil {
+			return filter, ErrInvalidDateRange
+		}
+		to = to.AddDate(0, 0, 1) // 包含结束日期当天
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, ErrInvalidDateRange
+	}
+
+	if req.MealSlot != "" {
+		hours, ok := model.MealSlotHours[req.MealSlot]
+		if !ok {
+			return filter, fmt.Errorf("未知的用餐时段: %s", req.MealSlot)
+		}
+		filter.HourStart, filter.HourEnd = hours.Start, hours.End
+	}
+
+	if req.Cursor != "" {
+		t, id, err := decodeHistoryCursor(req.Cursor)
+		if err != nil {
+			return filter, err
+		}
+		filter.CursorTime, filter.CursorID = &t, id
+	}
+	return filter, nil
+}
+
+// encodeHistoryCursor 游标为最后一条记录的决策时间和ID
+func encodeHistoryCursor(decidedAt time.Time, id int64) string {
+	raw := fmt.Sprintf("%d:%d", decidedAt.UnixNano(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeHistoryCursor 解析游标
+func decodeHistoryCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	var nanos, id int64
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &nanos, &id); err != nil || id <= 0 {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return time.Unix(0, nanos), id, nil
+}
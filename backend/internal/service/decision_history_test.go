@@ -0,0 +1,196 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"what-to-eat/internal/model"
+)
+
+func TestHistoryCursor_RoundTrip(t *testing.T) {
+	decidedAt := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	cursor := encodeHistoryCursor(decidedAt, 42)
+
+	gotTime, gotID, err := decodeHistoryCursor(cursor)
+	if err != nil {
+		t.Fatalf("decodeHistoryCursor() error = %v", err)
+	}
+	if !gotTime.Equal(decidedAt) || gotID != 42 {
+		t.Errorf("decodeHistoryCursor() = (%v, %d), want (%v, 42)", gotTime, gotID, decidedAt)
+	}
+
+	for _, bad := range []string{"!!!", "bm90LWEtY3Vyc29y", encodeHistoryCursor(decidedAt, 0)} {
+		if _, _, err := decodeHistoryCursor(bad); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decodeHistoryCursor(%q) error = %v, want %v", bad, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestNewHistoryFilter(t *testing.T) {
+	loc := time.UTC
+
+	filter, err := newHistoryFilter(&model.HistoryRequest{}, loc)
+	if err != nil {
+		t.Fatalf("newHistoryFilter() error = %v", err)
+	}
+	if filter.Limit != defaultHistoryLimit+1 || filter.From != nil || filter.To != nil || filter.CursorTime != nil {
+		t.Errorf("default filter = %+v", filter)
+	}
+
+	filter, err = newHistoryFilter(&model.HistoryRequest{
+		From:     "2024-03-01",
+		To:       "2024-03-03",
+		Limit:    500,
+		MealSlot: model.MealSlotLateNight,
+		Cursor:   encodeHistoryCursor(time.Date(2024, 3, 2, 0, 0, 0, 0, loc), 7),
+	}, loc)
+	if err != nil {
+		t.Fatalf("newHistoryFilter() error = %v", err)
+	}
+	if filter.Limit != maxHistoryLimit+1 {
+		t.Errorf("Limit = %d, want %d", filter.Limit, maxHistoryLimit+1)
+	}
+	if want := time.Date(2024, 3, 4, 0, 0, 0, 0, loc); !filter.To.Equal(want) {
+		t.Errorf("To = %v, want %v (end date inclusive)", filter.To, want)
+	}
+	if filter.HourStart != 21 || filter.HourEnd != 5 {
+		t.Errorf("hours = [%d, %d), want [21, 5)", filter.HourStart, filter.HourEnd)
+	}
+	if filter.CursorID != 7 {
+		t.Errorf("CursorID = %d, want 7", filter.CursorID)
+	}
+
+	for _, req := range []model.HistoryRequest{
+		{From: "2024-03-05", To: "2024-03-01"},
+		{From: "yesterday"},
+	} {
+		if _, err := newHistoryFilter(&req, loc); !errors.Is(err, ErrInvalidDateRange) {
+			t.Errorf("newHistoryFilter(%+v) error = %v, want %v", req, err, ErrInvalidDateRange)
+		}
+	}
+}
+
+func TestNewHistoryFilter_DST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		date      string
+		wantFrom  time.Time
+		wantTo    time.Time
+		wantHours float64
+	}{
+		{
+			date:      "2024-03-10", // 夏令时开始，当天 23 小时
+			wantFrom:  time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC),
+			wantTo:    time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC),
+			wantHours: 23,
+		},
+		{
+			date:      "2024-11-03", // 夏令时结束，当天 25 小时
+			wantFrom:  time.Date(2024, 11, 3, 4, 0, 0, 0, time.UTC),
+			wantTo:    time.Date(2024, 11, 4, 5, 0, 0, 0, time.UTC),
+			wantHours: 25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			filter, err := newHistoryFilter(&model.HistoryRequest{From: tt.date, To: tt.date}, loc)
+			if err != nil {
+				t.Fatalf("newHistoryFilter() error = %v", err)
+			}
+			if !filter.From.Equal(tt.wantFrom) || !filter.To.Equal(tt.wantTo) {
+				t.Errorf("range = [%v, %v), want [%v, %v)", filter.From.UTC(), filter.To.UTC(), tt.wantFrom, tt.wantTo)
+			}
+			if got := filter.To.Sub(*filter.From).Hours(); got != tt.wantHours {
+				t.Errorf("range length = %vh, want %vh", got, tt.wantHours)
+			}
+			if filter.Location != loc {
+				t.Errorf("Location = %v, want %v", filter.Location, loc)
+			}
+		})
+	}
+}
+
+func TestConfirmedAtAfterUpdate(t *testing.T) {
+	decidedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	moved := decidedAt.Add(-24 * time.Hour)
+	confirmedLater := decidedAt.Add(2 * time.Hour)
+	manual, plan := model.DecisionOriginManual, model.DecisionOriginPlan
+	note := "和同事一起"
+
+	tests := []struct {
+		name        string
+		record      model.DecisionRecord
+		req         model.UpdateHistoryRequest
+		want        *time.Time
+		wantChanged bool
+	}{
+		{
+			name:        "plan changed to manual",
+			record:      model.DecisionRecord{Origin: plan, DecidedAt: decidedAt},
+			req:         model.UpdateHistoryRequest{Origin: &manual},
+			want:        &decidedAt,
+			wantChanged: true,
+		},
+		{
+			name:        "manual moved to another time",
+			record:      model.DecisionRecord{Origin: manual, DecidedAt: decidedAt, ConfirmedAt: &decidedAt},
+			req:         model.UpdateHistoryRequest{DecidedAt: &moved},
+			want:        &moved,
+			wantChanged: true,
+		},
+		{
+			name:        "changed to manual with new time",
+			record:      model.DecisionRecord{Origin: plan, DecidedAt: decidedAt},
+			req:         model.UpdateHistoryRequest{Origin: &manual, DecidedAt: &moved},
+			want:        &moved,
+			wantChanged: true,
+		},
+		{
+			name:   "already confirmed at decided time",
+			record: model.DecisionRecord{Origin: manual, DecidedAt: decidedAt, ConfirmedAt: &decidedAt},
+			req:    model.UpdateHistoryRequest{Origin: &manual},
+		},
+		{
+			name:        "manual changed to plan clears auto confirmation",
+			record:      model.DecisionRecord{Origin: manual, DecidedAt: decidedAt, ConfirmedAt: &decidedAt},
+			req:         model.UpdateHistoryRequest{Origin: &plan, DecidedAt: &moved},
+			wantChanged: true,
+		},
+		{
+			name:   "manual changed to plan keeps explicit confirmation",
+			record: model.DecisionRecord{Origin: manual, DecidedAt: decidedAt, ConfirmedAt: &confirmedLater},
+			req:    model.UpdateHistoryRequest{Origin: &plan},
+		},
+		{
+			name:   "plan moved stays unconfirmed",
+			record: model.DecisionRecord{Origin: plan, DecidedAt: decidedAt},
+			req:    model.UpdateHistoryRequest{DecidedAt: &moved},
+		},
+		{
+			name:   "note only",
+			record: model.DecisionRecord{Origin: manual, DecidedAt: decidedAt},
+			req:    model.UpdateHistoryRequest{Note: &note},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := confirmedAtAfterUpdate(&tt.record, &tt.req)
+			if changed != tt.wantChanged {
+				t.Fatalf("confirmedAtAfterUpdate() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("confirmedAtAfterUpdate() = %v, want nil", *got)
+			case tt.want != nil && (got == nil || !got.Equal(*tt.want)):
+				t.Errorf("confirmedAtAfterUpdate() = %v, want %v", got, *tt.want)
+			}
+		})
+	}
+}
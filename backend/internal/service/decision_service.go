@@ -7,34 +7,75 @@ import (
 
 	"what-to-eat/internal/model"
 	"what-to-eat/internal/repository"
+	"what-to-eat/pkg/timezone"
 )
 
 var (
-	ErrNoMenus = errors.New("没有可选择的菜品")
+	ErrNoMenus               = errors.New("没有可选择的菜品")
+	ErrNoMenusWithinCalories = errors.New("没有热量在上限以内的菜品（未填写热量的菜品不参与）")
+	ErrDecisionNotFound      = errors.New("决策记录不存在")
+	ErrInvalidDateRange      = errors.New("日期范围无效")
+)
+
+// 营养汇总的日期格式和默认/最大范围
+const (
+	summaryDateLayout  = "2006-01-02"
+	defaultSummaryDays = 7
+	maxSummaryDays     = 366
 )
 
 type DecisionService struct {
 	decisionRepo *repository.DecisionRepository
 	menuRepo     *repository.MenuRepository
+	prefRepo     *repository.PreferenceRepository
+	timeZones    *TimeZoneService
+	weights      DecisionWeights
 	rng          *rand.Rand
 }
 
-func NewDecisionService(decisionRepo *repository.DecisionRepository, menuRepo *repository.MenuRepository) *DecisionService {
+// DecisionWeights 加权随机的重复惩罚权重（取值 0-1，1 表示不降低）
+type DecisionWeights struct {
+	RecentCount int     // 参与比较的最近决策次数
+	Dish        float64 // 最近吃过同一菜品
+	Restaurant  float64 // 最近吃过同一餐厅的其他菜品
+	Cuisine     float64 // 最近吃过同一菜系的其他餐厅
+	Favorite    float64 // 收藏菜品的权重倍数（不小于 1）
+}
+
+func NewDecisionService(
+	decisionRepo *repository.DecisionRepository,
+	menuRepo *repository.MenuRepository,
+	prefRepo *repository.PreferenceRepository,
+	timeZones *TimeZoneService,
+	weights DecisionWeights,
+) *DecisionService {
+	weights.Dish = clampWeight(weights.Dish)
+	weights.Restaurant = clampWeight(weights.Restaurant)
+	weights.Cuisine = clampWeight(weights.Cuisine)
+	weights.Favorite = max(1, weights.Favorite)
 	return &DecisionService{
 		decisionRepo: decisionRepo,
 		menuRepo:     menuRepo,
+		prefRepo:     prefRepo,
+		timeZones:    timeZones,
+		weights:      weights,
 		rng:          rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
+// clampWeight 将权重限制在 [0, 1]
+func clampWeight(w float64) float64 {
+	return max(0, min(1, w))
+}
+
 // Decide 执行决策（加权随机算法）
-func (s *DecisionService) Decide(userID int64, menuIDs []int64) (*model.DecideResponse, error) {
+func (s *DecisionService) Decide(userID int64, req *model.DecideRequest) (*model.DecideResponse, error) {
 	// 获取候选菜单列表
 	var menus []model.Menu
 	var err error
 
-	if len(menuIDs) > 0 {
-		menus, err = s.menuRepo.GetByIDs(menuIDs)
+	if len(req.MenuIDs) > 0 {
+		menus, err = s.menuRepo.GetByIDs(req.MenuIDs)
 	} else {
 		menus, err = s.menuRepo.GetAll()
 	}
@@ -47,20 +88,45 @@ func (s *DecisionService) Decide(userID int64, menuIDs []int64) (*model.DecideRe
 		return nil, ErrNoMenus
 	}
 
-	// 获取用户最近3次决策记录
-	recentRecords, err := s.decisionRepo.GetRecentByUserID(userID, 3)
+	// 移除用户屏蔽的菜品和餐厅（指定了 MenuIDs 时同样生效）
+	prefs, err := loadUserPreferences(s.prefRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	menus = prefs.filterBlocked(menus)
+	if len(menus) == 0 {
+		return nil, ErrAllMenusBlocked
+	}
+
+	// 热量上限
+	if req.MaxKcal != nil {
+		menus = filterByMaxKcal(menus, *req.MaxKcal)
+		if len(menus) == 0 {
+			return nil, ErrNoMenusWithinCalories
+		}
+	}
+
+	// 获取用户最近几次决策记录
+	recentRecords, err := s.decisionRepo.GetRecentByUserID(userID, s.weights.RecentCount)
 	if err != nil {
 		return nil, err
 	}
 
+	if req.Mode == model.DecideModeCombo {
+		return s.decideCombo(userID, req, menus, recentRecords, prefs)
+	}
+
 	// 执行加权随机选择
-	selected := s.weightedRandom(menus, recentRecords)
+	selected := s.weightedRandom(menus, recentRecords, prefs)
 
 	// 保存决策记录
 	record := &model.DecisionRecord{
-		UserID:    userID,
-		MenuID:    selected.ID,
-		DecidedAt: time.Now(),
+		UserID:       userID,
+		MenuID:       selected.ID,
+		Mode:         model.DecideModeSingle,
+		Origin:       model.DecisionOriginSpin,
+		DecidedAt:    time.Now(),
+		RecentRepeat: newRecentSet(recentRecords).menus[selected.ID],
 	}
 	if err := s.decisionRepo.Create(record); err != nil {
 		return nil, err
@@ -70,47 +136,170 @@ func (s *DecisionService) Decide(userID int64, menuIDs []int64) (*model.DecideRe
 	message := s.generateMessage(selected, recentRecords)
 
 	return &model.DecideResponse{
+		Mode:    model.DecideModeSingle,
 		Menu:    *selected,
 		Message: message,
 	}, nil
 }
 
-// weightedRandom 加权随机算法
-// 如果菜品在最近3次记录中出现过，其被选中的概率降低50%
-func (s *DecisionService) weightedRandom(menus []model.Menu, recentRecords []model.DecisionRecord) *model.Menu {
-	// 统计最近3次中各菜品出现次数
-	recentCount := make(map[int64]int)
-	for _, record := range recentRecords {
-		recentCount[record.MenuID]++
+// decideCombo 组合决策：选出同一餐厅的一组菜品，作为一条决策记录保存
+func (s *DecisionService) decideCombo(
+	userID int64,
+	req *model.DecideRequest,
+	menus []model.Menu,
+	recentRecords []model.DecisionRecord,
+	prefs *userPreferences,
+) (*model.DecideResponse, error) {
+	spec, err := newComboSpec(req)
+	if err != nil {
+		return nil, err
 	}
 
-	// 计算权重
-	weights := make([]float64, len(menus))
-	totalWeight := 0.0
+	recent := newRecentSet(recentRecords)
+	var choice *comboChoice
+	if req.MaxKcal != nil {
+		choice, err = pickComboWithinKcal(s.rng, menus, recent, prefs, s.weights, spec, *req.MaxKcal)
+	} else {
+		choice, err = pickCombo(s.rng, menus, recent, prefs, s.weights, spec)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	for i, m := range menus {
-		if recentCount[m.ID] > 0 {
-			// 在最近3次中出现过，权重降为0.5
-			weights[i] = 0.5
-		} else {
-			weights[i] = 1.0
+	// 保存决策记录（菜品随记录一起写入 decision_items）
+	record := &model.DecisionRecord{
+		UserID:    userID,
+		MenuID:    choice.items[0].MenuID,
+		Mode:      model.DecideModeCombo,
+		Origin:    model.DecisionOriginSpin,
+		DecidedAt: time.Now(),
+		Items:     make([]model.DecisionItem, len(choice.items)),
+	}
+	for i, item := range choice.items {
+		record.Items[i] = model.DecisionItem{MenuID: item.MenuID, Role: item.Role}
+		record.RecentRepeat = record.RecentRepeat || recent.menus[item.MenuID]
+	}
+	if err := s.decisionRepo.Create(record); err != nil {
+		return nil, err
+	}
+	for i := range choice.items {
+		choice.items[i].ID = record.Items[i].ID
+		choice.items[i].DecisionID = record.ID
+	}
+
+	return &model.DecideResponse{
+		Mode:       model.DecideModeCombo,
+		Menu:       choice.items[0].Menu,
+		Restaurant: &choice.restaurant,
+		Items:      choice.items,
+		TotalKcal:  choice.totalKcal(),
+		Message:    generateComboMessage(choice),
+	}, nil
+}
+
+// filterByMaxKcal 过滤出已填写热量且不超过上限的菜品
+func filterByMaxKcal(menus []model.Menu, maxKcal int) []model.Menu {
+	filtered := make([]model.Menu, 0, len(menus))
+	for _, m := range menus {
+		if m.Nutrition.Kcal != nil && *m.Nutrition.Kcal <= maxKcal {
+			filtered = append(filtered, m)
 		}
-		totalWeight += weights[i]
 	}
+	return filtered
+}
 
-	// 加权随机选择
-	randomValue := s.rng.Float64() * totalWeight
-	cumulativeWeight := 0.0
+// weightedRandom 加权随机算法
+// 候选菜品与最近的决策重复时降低权重：同一菜品、同一餐厅、同一菜系分别使用各自的权重，只取最具体的一级；
+// 用户收藏的菜品再乘以收藏权重倍数
+func (s *DecisionService) weightedRandom(menus []model.Menu, recentRecords []model.DecisionRecord, prefs *userPreferences) *model.Menu {
+	recent := newRecentSet(recentRecords)
+
+	weights := make([]float64, len(menus))
+	for i := range menus {
+		weights[i] = recent.weight(&menus[i], s.weights) * prefs.favoriteFactor(menus[i].ID, s.weights)
+	}
+	return &menus[weightedIndex(s.rng, weights)]
+}
+
+// weightedIndex 按权重随机选出一个下标，权重全部为 0 时退化为等概率
+func weightedIndex(rng *rand.Rand, weights []float64) int {
+	totalWeight := 0.0
+	for _, w := range weights {
+		totalWeight += w
+	}
+	if totalWeight == 0 {
+		return rng.Intn(len(weights))
+	}
 
+	randomValue := rng.Float64() * totalWeight
+	cumulativeWeight := 0.0
 	for i, w := range weights {
 		cumulativeWeight += w
 		if randomValue <= cumulativeWeight {
-			return &menus[i]
+			return i
 		}
 	}
 
 	// 兜底：返回最后一个
-	return &menus[len(menus)-1]
+	return len(weights) - 1
+}
+
+// recentSet 最近决策中出现过的菜品、餐厅和菜系（组合决策的每个菜品都计入）
+type recentSet struct {
+	menus       map[int64]bool
+	restaurants map[int64]bool
+	cuisines    map[string]bool
+}
+
+func newRecentSet(records []model.DecisionRecord) *recentSet {
+	set := &recentSet{
+		menus:       make(map[int64]bool),
+		restaurants: make(map[int64]bool),
+		cuisines:    make(map[string]bool),
+	}
+	for _, record := range records {
+		set.menus[record.MenuID] = true
+		for _, menu := range record.Menus() {
+			if menu.ID != 0 {
+				set.menus[menu.ID] = true
+			}
+			if menu.RestaurantID != 0 {
+				set.restaurants[menu.RestaurantID] = true
+			}
+			if cuisine := menu.Restaurant.Cuisine; cuisine != "" {
+				set.cuisines[cuisine] = true
+			}
+		}
+	}
+	return set
+}
+
+// weight 计算候选菜品的权重
+func (r *recentSet) weight(menu *model.Menu, w DecisionWeights) float64 {
+	if r.menus[menu.ID] {
+		return w.Dish
+	}
+	return r.restaurantWeight(menu.RestaurantID, menu.Restaurant.Cuisine, w)
+}
+
+// restaurantWeight 计算候选餐厅的权重（同一餐厅 > 同一菜系）
+func (r *recentSet) restaurantWeight(restaurantID int64, cuisine string, w DecisionWeights) float64 {
+	switch {
+	case r.restaurants[restaurantID]:
+		return w.Restaurant
+	case cuisine != "" && r.cuisines[cuisine]:
+		return w.Cuisine
+	default:
+		return 1.0
+	}
+}
+
+// dishWeight 计算餐厅内候选菜品的权重（组合决策已选定餐厅，只比较菜品）
+func (r *recentSet) dishWeight(menu *model.Menu, w DecisionWeights) float64 {
+	if r.menus[menu.ID] {
+		return w.Dish
+	}
+	return 1.0
 }
 
 // generateMessage 生成响应消息
@@ -124,20 +313,125 @@ func (s *DecisionService) generateMessage(menu *model.Menu, recentRecords []mode
 	return "就决定是你了！"
 }
 
-// GetHistory 获取用户最近5天的决策历史
-func (s *DecisionService) GetHistory(userID int64) (*model.HistoryResponse, error) {
-	records, err := s.decisionRepo.GetByUserIDAndDays(userID, 5)
+// GetRecentRecords 获取用户最近N条决策记录
+func (s *DecisionService) GetRecentRecords(userID int64, limit int) ([]model.DecisionRecord, error) {
+	return s.decisionRepo.GetRecentByUserID(userID, limit)
+}
+
+// Confirm 确认决策记录（表示确实吃了，确认后计入营养统计）
+func (s *DecisionService) Confirm(userID, recordID int64) error {
+	affected, err := s.decisionRepo.Confirm(userID, recordID, time.Now())
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrDecisionNotFound
+	}
+	return nil
+}
+
+// NutritionSummary 按天（用户时区的日期）汇总用户在日期范围内已确认决策的营养信息
+func (s *DecisionService) NutritionSummary(userID int64, req *model.NutritionSummaryRequest) (*model.NutritionSummaryResponse, error) {
+	loc, err := s.timeZones.Location(userID)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := parseSummaryRange(req.From, req.To, time.Now().In(loc))
 	if err != nil {
 		return nil, err
 	}
 
-	return &model.HistoryResponse{
-		Records: records,
-		Total:   int64(len(records)),
-	}, nil
+	records, err := s.decisionRepo.GetConfirmedByUserIDBetween(userID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	return summarizeNutrition(records, from, to), nil
 }
 
-// GetRecentRecords 获取用户最近N条决策记录
-func (s *DecisionService) GetRecentRecords(userID int64, limit int) ([]model.DecisionRecord, error) {
-	return s.decisionRepo.GetRecentByUserID(userID, limit)
+// parseSummaryRange 解析汇总日期范围（包含首尾），默认最近 7 天；日期按 now 所在的时区解析
+func parseSummaryRange(fromStr, toStr string, now time.Time) (time.Time, time.Time, error) {
+	today := timezone.StartOfDay(now, now.Location())
+
+	to := today
+	if toStr != "" {
+		t, err := time.ParseInLocation(summaryDateLayout, toStr, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidDateRange
+		}
+		to = t
+	}
+
+	from := to.AddDate(0, 0, -(defaultSummaryDays - 1))
+	if fromStr != "" {
+		t, err := time.ParseInLocation(summaryDateLayout, fromStr, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidDateRange
+		}
+		from = t
+	}
+
+	if from.After(to) || to.Sub(from) >= maxSummaryDays*24*time.Hour {
+		return time.Time{}, time.Time{}, ErrInvalidDateRange
+	}
+	return from, to, nil
+}
+
+// summarizeNutrition 按天汇总营养信息，只输出有记录的日期（组合决策的全部菜品计为一餐）
+func summarizeNutrition(records []model.DecisionRecord, from, to time.Time) *model.NutritionSummaryResponse {
+	resp := &model.NutritionSummaryResponse{
+		From: from.Format(summaryDateLayout),
+		To:   to.Format(summaryDateLayout),
+		Days: []model.NutritionDay{},
+	}
+
+	dayIndex := make(map[string]int)
+	for _, record := range records {
+		date := record.DecidedAt.In(from.Location()).Format(summaryDateLayout)
+		i, ok := dayIndex[date]
+		if !ok {
+			i = len(resp.Days)
+			dayIndex[date] = i
+			resp.Days = append(resp.Days, model.NutritionDay{Date: date})
+		}
+		day := &resp.Days[i]
+
+		day.Meals++
+		unknown := false
+		for _, menu := range record.Menus() {
+			n := menu.Nutrition
+			if n.Kcal == nil {
+				unknown = true
+			}
+			addNutrition(&day.NutritionTotals, n)
+			addNutrition(&resp.Total, n)
+		}
+		if unknown {
+			day.UnknownMeals++
+		}
+	}
+
+	for _, day := range resp.Days {
+		resp.Meals += day.Meals
+		resp.UnknownMeals += day.UnknownMeals
+	}
+	if len(resp.Days) > 0 {
+		resp.AvgDailyKcal = float64(resp.Total.Kcal) / float64(len(resp.Days))
+	}
+	return resp
+}
+
+// addNutrition 累加营养信息（未填写的字段按 0 计）
+func addNutrition(totals *model.NutritionTotals, n model.Nutrition) {
+	if n.Kcal != nil {
+		totals.Kcal += *n.Kcal
+	}
+	if n.Protein != nil {
+		totals.Protein += *n.Protein
+	}
+	if n.Carbs != nil {
+		totals.Carbs += *n.Carbs
+	}
+	if n.Fat != nil {
+		totals.Fat += *n.Fat
+	}
 }
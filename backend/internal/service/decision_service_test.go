@@ -7,8 +7,11 @@ import (
 	"what-to-eat/internal/model"
 )
 
+// testDecisionWeights 与默认配置一致的权重
+var testDecisionWeights = DecisionWeights{RecentCount: 3, Dish: 0.5, Restaurant: 0.7, Cuisine: 0.85, Favorite: 2}
+
 func TestWeightedRandom(t *testing.T) {
-	service := NewDecisionService(nil, nil)
+	service := NewDecisionService(nil, nil, nil, nil, testDecisionWeights)
 
 	tests := []struct {
 		name          string
@@ -47,7 +50,7 @@ func TestWeightedRandom(t *testing.T) {
 			iterations := 1000
 
 			for i := 0; i < iterations; i++ {
-				result := service.weightedRandom(tt.menus, tt.recentRecords)
+				result := service.weightedRandom(tt.menus, tt.recentRecords, nil)
 				counts[result.ID]++
 			}
 
@@ -83,6 +86,58 @@ func TestWeightedRandom(t *testing.T) {
 	}
 }
 
+func TestRecentSetWeight(t *testing.T) {
+	mcd := model.Restaurant{ID: 1, Name: "麦当劳", Cuisine: model.CuisineFastFood}
+	kfc := model.Restaurant{ID: 2, Name: "肯德基", Cuisine: model.CuisineFastFood}
+	noodles := model.Restaurant{ID: 3, Name: "兰州拉面", Cuisine: model.CuisineNoodles}
+	unknown := model.Restaurant{ID: 4, Name: "路边摊"}
+
+	recent := newRecentSet([]model.DecisionRecord{
+		{MenuID: 10, Menu: model.Menu{ID: 10, RestaurantID: mcd.ID, Restaurant: mcd}},
+		// 组合决策的每个菜品都计入
+		{MenuID: 50, Mode: model.DecideModeCombo, Items: []model.DecisionItem{
+			{MenuID: 50, Menu: model.Menu{ID: 50, RestaurantID: 5}},
+			{MenuID: 51, Menu: model.Menu{ID: 51, RestaurantID: 5}},
+		}},
+	})
+
+	tests := []struct {
+		name string
+		menu model.Menu
+		want float64
+	}{
+		{name: "same dish", menu: model.Menu{ID: 10, RestaurantID: mcd.ID, Restaurant: mcd}, want: 0.5},
+		{name: "same restaurant", menu: model.Menu{ID: 11, RestaurantID: mcd.ID, Restaurant: mcd}, want: 0.7},
+		{name: "same cuisine", menu: model.Menu{ID: 20, RestaurantID: kfc.ID, Restaurant: kfc}, want: 0.85},
+		{name: "different cuisine", menu: model.Menu{ID: 30, RestaurantID: noodles.ID, Restaurant: noodles}, want: 1},
+		{name: "unclassified", menu: model.Menu{ID: 40, RestaurantID: unknown.ID, Restaurant: unknown}, want: 1},
+		{name: "dish from combo", menu: model.Menu{ID: 51, RestaurantID: 5}, want: 0.5},
+		{name: "restaurant from combo", menu: model.Menu{ID: 52, RestaurantID: 5}, want: 0.7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recent.weight(&tt.menu, testDecisionWeights); got != tt.want {
+				t.Errorf("weight() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDecisionService_ClampWeights(t *testing.T) {
+	s := NewDecisionService(nil, nil, nil, nil, DecisionWeights{RecentCount: 3, Dish: -1, Restaurant: 2, Cuisine: 0.3})
+	if s.weights.Dish != 0 || s.weights.Restaurant != 1 || s.weights.Cuisine != 0.3 {
+		t.Errorf("weights = %+v, want clamped to [0, 1]", s.weights)
+	}
+
+	// 权重全部为 0 时仍然能选出菜品
+	menus := []model.Menu{{ID: 1}}
+	recent := []model.DecisionRecord{{MenuID: 1}}
+	if got := s.weightedRandom(menus, recent, nil); got.ID != 1 {
+		t.Errorf("weightedRandom() = %d, want 1", got.ID)
+	}
+}
+
 func TestGenerateMessage(t *testing.T) {
 	service := &DecisionService{}
 
@@ -174,3 +229,157 @@ func TestDecisionRecord_Today(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterByMaxKcal(t *testing.T) {
+	low, high := 400, 900
+	menus := []model.Menu{
+		{ID: 1, Nutrition: model.Nutrition{Kcal: &low}},
+		{ID: 2, Nutrition: model.Nutrition{Kcal: &high}},
+		{ID: 3}, // 未填写热量
+	}
+
+	tests := []struct {
+		name    string
+		maxKcal int
+		wantIDs []int64
+	}{
+		{name: "below all", maxKcal: 300, wantIDs: []int64{}},
+		{name: "inclusive ceiling", maxKcal: 400, wantIDs: []int64{1}},
+		{name: "above all", maxKcal: 1000, wantIDs: []int64{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterByMaxKcal(menus, tt.maxKcal)
+			if len(got) != len(tt.wantIDs) {
+				t.Fatalf("filterByMaxKcal() returned %d menus, want %d", len(got), len(tt.wantIDs))
+			}
+			for i, m := range got {
+				if m.ID != tt.wantIDs[i] {
+					t.Errorf("menu %d ID = %d, want %d", i, m.ID, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSummaryRange(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from, to string
+		wantFrom string
+		wantTo   string
+		wantErr  bool
+	}{
+		{name: "default last 7 days", wantFrom: "2024-03-09", wantTo: "2024-03-15"},
+		{name: "explicit range", from: "2024-03-01", to: "2024-03-10", wantFrom: "2024-03-01", wantTo: "2024-03-10"},
+		{name: "single day", from: "2024-03-01", to: "2024-03-01", wantFrom: "2024-03-01", wantTo: "2024-03-01"},
+		{name: "to only", to: "2024-02-29", wantFrom: "2024-02-23", wantTo: "2024-02-29"},
+		{name: "from after to", from: "2024-03-10", to: "2024-03-01", wantErr: true},
+		{name: "invalid date", from: "2024/03/01", wantErr: true},
+		{name: "range too long", from: "2022-01-01", to: "2024-01-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := parseSummaryRange(tt.from, tt.to, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSummaryRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := from.Format(summaryDateLayout); got != tt.wantFrom {
+				t.Errorf("from = %s, want %s", got, tt.wantFrom)
+			}
+			if got := to.Format(summaryDateLayout); got != tt.wantTo {
+				t.Errorf("to = %s, want %s", got, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestParseSummaryRange_UserTimeZone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// UTC 已经是 3 月 10 日，纽约还是 3 月 9 日晚上
+	now := time.Date(2024, 3, 10, 3, 30, 0, 0, time.UTC).In(loc)
+
+	from, to, err := parseSummaryRange("", "", now)
+	if err != nil {
+		t.Fatalf("parseSummaryRange() error = %v", err)
+	}
+	if got := to.Format(summaryDateLayout); got != "2024-03-09" {
+		t.Errorf("to = %s, want 2024-03-09", got)
+	}
+	if got := from.Format(summaryDateLayout); got != "2024-03-03" {
+		t.Errorf("from = %s, want 2024-03-03", got)
+	}
+	if want := time.Date(2024, 3, 3, 5, 0, 0, 0, time.UTC); !from.Equal(want) {
+		t.Errorf("from = %v, want %v (midnight in New York)", from.UTC(), want)
+	}
+
+	// 跨越夏令时切换的汇总按日历日计算结束边界
+	from, to, err = parseSummaryRange("2024-03-09", "2024-03-10", now)
+	if err != nil {
+		t.Fatalf("parseSummaryRange() error = %v", err)
+	}
+	if got := to.AddDate(0, 0, 1).Sub(from).Hours(); got != 47 {
+		t.Errorf("range length = %vh, want 47h", got)
+	}
+}
+
+func TestSummarizeNutrition(t *testing.T) {
+	kcalA, kcalB, protein := 500, 700, 20.0
+	menuA := model.Menu{ID: 1, Nutrition: model.Nutrition{Kcal: &kcalA, Protein: &protein}}
+	menuB := model.Menu{ID: 2, Nutrition: model.Nutrition{Kcal: &kcalB}}
+	menuUnknown := model.Menu{ID: 3}
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC)
+	records := []model.DecisionRecord{
+		{MenuID: 1, Menu: menuA, DecidedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{MenuID: 2, Menu: menuB, DecidedAt: time.Date(2024, 3, 1, 19, 0, 0, 0, time.UTC)},
+		{MenuID: 3, Menu: menuUnknown, DecidedAt: time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC)},
+	}
+
+	resp := summarizeNutrition(records, from, to)
+
+	if resp.From != "2024-03-01" || resp.To != "2024-03-07" {
+		t.Errorf("range = %s..%s, want 2024-03-01..2024-03-07", resp.From, resp.To)
+	}
+	if len(resp.Days) != 2 {
+		t.Fatalf("got %d days, want 2", len(resp.Days))
+	}
+	if day := resp.Days[0]; day.Date != "2024-03-01" || day.Meals != 2 || day.Kcal != 1200 || day.Protein != 20 {
+		t.Errorf("day 1 = %+v", day)
+	}
+	if day := resp.Days[1]; day.Date != "2024-03-03" || day.Meals != 1 || day.UnknownMeals != 1 || day.Kcal != 0 {
+		t.Errorf("day 2 = %+v", day)
+	}
+	if resp.Total.Kcal != 1200 || resp.Meals != 3 || resp.UnknownMeals != 1 {
+		t.Errorf("total = %+v, meals = %d, unknown = %d", resp.Total, resp.Meals, resp.UnknownMeals)
+	}
+	if resp.AvgDailyKcal != 600 {
+		t.Errorf("avg daily kcal = %v, want 600", resp.AvgDailyKcal)
+	}
+
+	// 组合决策的全部菜品计为一餐
+	combo := []model.DecisionRecord{{
+		MenuID:    1,
+		Mode:      model.DecideModeCombo,
+		DecidedAt: time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC),
+		Items:     []model.DecisionItem{{MenuID: 1, Menu: menuA}, {MenuID: 3, Menu: menuUnknown}, {MenuID: 2, Menu: menuB}},
+	}}
+	if got := summarizeNutrition(combo, from, to); got.Meals != 1 || got.UnknownMeals != 1 || got.Total.Kcal != 1200 {
+		t.Errorf("combo summary = %+v", got)
+	}
+
+	if empty := summarizeNutrition(nil, from, to); len(empty.Days) != 0 || empty.AvgDailyKcal != 0 {
+		t.Errorf("empty summary = %+v", empty)
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+)
+
+// 决策历史导出格式
+const (
+	HistoryExportCSV   = "csv"
+	HistoryExportJSONL = "jsonl"
+)
+
+// 每写出多少行刷新一次输出，让客户端尽早收到数据
+const historyExportFlushRows = 200
+
+// historyCSVHeader 导出 CSV 的表头（与 HistoryExportRow 的 JSON 字段一致）
+var historyCSVHeader = []string{
+	"decision_id", "decided_at", "date", "meal_slot", "mode", "origin",
+	"confirmed", "restaurant", "dish", "kcal", "note",
+}
+
+// HistoryExport 已校验的导出任务，调用 Stream 开始输出
+type HistoryExport struct {
+	Format string
+	userID int64
+	filter repository.HistoryFilter
+	loc    *time.Location
+	repo   *repository.DecisionRepository
+}
+
+// ContentType 返回导出格式对应的 MIME 类型
+func (e *HistoryExport) ContentType() string {
+	if e.Format == HistoryExportJSONL {
+		return "application/x-ndjson; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Filename 下载文件名
+func (e *HistoryExport) Filename() string {
+	return "history." + e.Format
+}
+
+// PrepareHistoryExport 校验导出参数（日期范围按用户时区解析）
+// 参数错误在开始输出之前返回，便于调用方返回普通的错误响应
+func (s *DecisionService) PrepareHistoryExport(userID int64, req *model.HistoryExportRequest) (*HistoryExport, error) {
+	loc, err := s.timeZones.Location(userID)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := newHistoryFilter(&model.HistoryRequest{From: req.From, To: req.To}, loc)
+	if err != nil {
+		return nil, err
+	}
+	format := req.Format
+	if format == "" {
+		format = HistoryExportCSV
+	}
+	return &HistoryExport{Format: format, userID: userID, filter: filter, loc: loc, repo: s.decisionRepo}, nil
+}
+
+// Stream 从数据库逐行读取并写出全部决策历史
+func (e *HistoryExport) Stream(w io.Writer) error {
+	out := newHistoryRowWriter(e.Format, w)
+	rows := 0
+	err := e.repo.EachHistoryDish(e.userID, e.filter.From, e.filter.To, func(dish *repository.HistoryDish) error {
+		if err := out.Write(toHistoryExportRow(dish, e.loc)); err != nil {
+			return err
+		}
+		if rows++; rows%historyExportFlushRows == 0 {
+			return out.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// toHistoryExportRow 转换为导出行（时间换算为用户时区）
+func toHistoryExportRow(dish *repository.HistoryDish, loc *time.Location) *model.HistoryExportRow {
+	local := dish.DecidedAt.In(loc)
+	return &model.HistoryExportRow{
+		DecisionID: dish.DecisionID,
+		DecidedAt:  local.Format(time.RFC3339),
+		Date:       local.Format(summaryDateLayout),
+		MealSlot:   model.MealSlotOf(local),
+		Mode:       dish.Mode,
+		Origin:     dish.Origin,
+		Confirmed:  dish.ConfirmedAt != nil,
+		Restaurant: dish.RestaurantName,
+		Dish:       dish.DishName,
+		Kcal:       dish.Kcal,
+		Note:       dish.Note,
+	}
+}
+
+// historyRowWriter 按格式写出导出行
+type historyRowWriter interface {
+	Write(row *model.HistoryExportRow) error
+	Flush() error
+}
+
+func newHistoryRowWriter(format string, w io.Writer) historyRowWriter {
+	if format == HistoryExportJSONL {
+		return &jsonlRowWriter{w: w, enc: json.NewEncoder(w)}
+	}
+	return &csvRowWriter{w: w, csv: csv.NewWriter(w)}
+}
+
+// csvRowWriter 第一行之前写出表头
+type csvRowWriter struct {
+	w          io.Writer
+	csv        *csv.Writer
+	headerDone bool
+}
+
+func (c *csvRowWriter) Write(row *model.HistoryExportRow) error {
+	if err := c.header(); err != nil {
+		return err
+	}
+	kcal := ""
+	if row.Kcal != nil {
+		kcal = strconv.Itoa(*row.Kcal)
+	}
+	return c.csv.Write([]string{
+		strconv.FormatInt(row.DecisionID, 10), row.DecidedAt, row.Date, row.MealSlot, row.Mode, row.Origin,
+		strconv.FormatBool(row.Confirmed), row.Restaurant, row.Dish, kcal, row.Note,
+	})
+}
+
+// Flush 没有任何记录时也输出表头
+func (c *csvRowWriter) Flush() error {
+	if err := c.header(); err != nil {
+		return err
+	}
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		return err
+	}
+	flushWriter(c.w)
+	return nil
+}
+
+func (c *csvRowWriter) header() error {
+	if c.headerDone {
+		return nil
+	}
+	c.headerDone = true
+	return c.csv.Write(historyCSVHeader)
+}
+
+// jsonlRowWriter 每行一个 JSON 对象
+type jsonlRowWriter struct {
+	w   io.Writer
+	enc *json.Encoder
+}
+
+func (j *jsonlRowWriter) Write(row *model.HistoryExportRow) error {
+	return j.enc.Encode(row)
+}
+
+func (j *jsonlRowWriter) Flush() error {
+	flushWriter(j.w)
+	return nil
+}
+
+// flushWriter 输出支持 http.Flusher 时立即发送已写出的数据
+func flushWriter(w io.Writer) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+)
+
+func TestToHistoryExportRow(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kcal := 650
+	confirmedAt := time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC)
+	row := toHistoryExportRow(&repository.HistoryDish{
+		DecisionID:     9,
+		DecidedAt:      time.Date(2024, 3, 1, 16, 30, 0, 0, time.UTC), // 北京时间 3 月 2 日 00:30
+		ConfirmedAt:    &confirmedAt,
+		Mode:           model.DecideModeSingle,
+		Origin:         model.DecisionOriginSpin,
+		RestaurantName: "已关门的店",
+		DishName:       "已下架的菜",
+		Kcal:           &kcal,
+	}, loc)
+
+	if row.DecidedAt != "2024-03-02T00:30:00+08:00" || row.Date != "2024-03-02" || row.MealSlot != model.MealSlotLateNight {
+		t.Errorf("time fields = %q %q %q", row.DecidedAt, row.Date, row.MealSlot)
+	}
+	if !row.Confirmed || row.Restaurant != "已关门的店" || row.Dish != "已下架的菜" || *row.Kcal != 650 {
+		t.Errorf("row = %+v", row)
+	}
+}
+
+func TestHistoryRowWriter(t *testing.T) {
+	kcal := 500
+	rows := []*model.HistoryExportRow{
+		{DecisionID: 1, DecidedAt: "2024-03-01T12:00:00+08:00", Date: "2024-03-01", MealSlot: "lunch",
+			Mode: "single", Origin: "spin", Confirmed: true, Restaurant: "麦当劳", Dish: "巨无霸", Kcal: &kcal},
+		{DecisionID: 2, DecidedAt: "2024-03-02T19:00:00+08:00", Date: "2024-03-02", MealSlot: "dinner",
+			Mode: "single", Origin: "manual", Restaurant: "面馆", Dish: "牛肉面, 大碗", Note: "加班"},
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := newHistoryRowWriter(HistoryExportCSV, &buf)
+		for _, row := range rows {
+			if err := w.Write(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		want := "decision_id,decided_at,date,meal_slot,mode,origin,confirmed,restaurant,dish,kcal,note\n" +
+			"1,2024-03-01T12:00:00+08:00,2024-03-01,lunch,single,spin,true,麦当劳,巨无霸,500,\n" +
+			"2,2024-03-02T19:00:00+08:00,2024-03-02,dinner,single,manual,false,面馆,\"牛肉面, 大碗\",,加班\n"
+		if buf.String() != want {
+			t.Errorf("csv =\n%s\nwant\n%s", buf.String(), want)
+		}
+	})
+
+	t.Run("csv header without rows", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := newHistoryRowWriter(HistoryExportCSV, &buf).Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(buf.String(), "decision_id,") || strings.Count(buf.String(), "\n") != 1 {
+			t.Errorf("csv = %q, want header only", buf.String())
+		}
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := newHistoryRowWriter(HistoryExportJSONL, &buf)
+		for _, row := range rows {
+			if err := w.Write(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("got %d lines, want 2", len(lines))
+		}
+		var got model.HistoryExportRow
+		if err := json.Unmarshal([]byte(lines[1]), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Dish != "牛肉面, 大碗" || got.Kcal != nil || got.Note != "加班" {
+			t.Errorf("decoded = %+v", got)
+		}
+	})
+}
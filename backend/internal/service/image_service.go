@@ -0,0 +1,229 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // 注册 GIF 解码器
+	"image/jpeg"
+	_ "image/png" // 注册 PNG 解码器
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"golang.org/x/image/draw"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+	"what-to-eat/pkg/logger"
+	"what-to-eat/pkg/storage"
+)
+
+var (
+	ErrImageTooLarge       = errors.New("图片过大")
+	ErrUnsupportedImage    = errors.New("不支持的图片格式，仅支持 JPEG、PNG、GIF")
+	ErrImageDimensionLimit = errors.New("图片尺寸过大")
+)
+
+// 图片像素上限（防止解压炸弹）
+const maxImagePixels = 40_000_000
+
+// allowedImageTypes 允许上传的图片类型及对应扩展名
+var allowedImageTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// ImageService 菜品和餐厅图片上传
+type ImageService struct {
+	storage        storage.Storage
+	menuRepo       *repository.MenuRepository
+	restaurantRepo *repository.RestaurantRepository
+	maxSize        int64 // 字节
+	thumbnailSize  int   // 缩略图最长边（像素）
+}
+
+func NewImageService(
+	store storage.Storage,
+	menuRepo *repository.MenuRepository,
+	restaurantRepo *repository.RestaurantRepository,
+	maxSize int64,
+	thumbnailSize int,
+) *ImageService {
+	return &ImageService{
+		storage:        store,
+		menuRepo:       menuRepo,
+		restaurantRepo: restaurantRepo,
+		maxSize:        maxSize,
+		thumbnailSize:  thumbnailSize,
+	}
+}
+
+// MaxSize 返回单个图片的大小上限（字节）
+func (s *ImageService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// UploadMenuImage 上传菜品图片
+func (s *ImageService) UploadMenuImage(menuID int64, r io.Reader) (*model.Menu, error) {
+	menu, err := s.menuRepo.GetByID(menuID)
+	if err != nil {
+		return nil, notFoundOr(err, ErrMenuNotFound)
+	}
+
+	stored, err := s.store(fmt.Sprintf("menus/%d", menu.ID), r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.menuRepo.UpdateImage(menu.ID, stored.key, stored.imageURL, stored.thumbnailURL); err != nil {
+		s.remove(stored.key)
+		return nil, err
+	}
+
+	s.remove(menu.ImageKey)
+	menu.ImageKey, menu.ImageURL, menu.ThumbnailURL = stored.key, stored.imageURL, stored.thumbnailURL
+	return menu, nil
+}
+
+// UploadRestaurantImage 上传餐厅图片
+func (s *ImageService) UploadRestaurantImage(restaurantID int64, r io.Reader) (*model.Restaurant, error) {
+	restaurant, err := s.restaurantRepo.GetByID(restaurantID)
+	if err != nil {
+		return nil, notFoundOr(err, ErrRestaurantNotFound)
+	}
+
+	stored, err := s.store(fmt.Sprintf("restaurants/%d", restaurant.ID), r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.restaurantRepo.UpdateImage(restaurant.ID, stored.key, stored.imageURL, stored.thumbnailURL); err != nil {
+		s.remove(stored.key)
+		return nil, err
+	}
+
+	s.remove(restaurant.ImageKey)
+	restaurant.ImageKey, restaurant.ImageURL, restaurant.ThumbnailURL = stored.key, stored.imageURL, stored.thumbnailURL
+	return restaurant, nil
+}
+
+// storedImage 已保存的图片
+type storedImage struct {
+	key          string
+	imageURL     string
+	thumbnailURL string
+}
+
+// store 校验图片并保存原图和缩略图
+func (s *ImageService) store(prefix string, r io.Reader) (*storedImage, error) {
+	// 多读一个字节用于判断是否超过大小限制
+	data, err := io.ReadAll(io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, ErrImageTooLarge
+	}
+
+	ext, ok := allowedImageTypes[http.DetectContentType(data)]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+
+	thumbnail, err := makeThumbnail(data, s.thumbnailSize)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s/%s.%s", prefix, name, ext)
+
+	if err := s.storage.Save(key, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if err := s.storage.Save(thumbnailKey(key), bytes.NewReader(thumbnail)); err != nil {
+		s.remove(key)
+		return nil, err
+	}
+
+	return &storedImage{
+		key:          key,
+		imageURL:     s.storage.URL(key),
+		thumbnailURL: s.storage.URL(thumbnailKey(key)),
+	}, nil
+}
+
+// remove 删除原图和缩略图（失败只记录日志）
+func (s *ImageService) remove(key string) {
+	if key == "" {
+		return
+	}
+	for _, k := range []string{key, thumbnailKey(key)} {
+		if err := s.storage.Delete(k); err != nil {
+			logger.Warn("Failed to delete image", zap.String("key", k), zap.Error(err))
+		}
+	}
+}
+
+// makeThumbnail 生成缩略图（等比缩放到最长边不超过 size，统一输出 JPEG）
+func makeThumbnail(data []byte, size int) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageDimensionLimit
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	width, height := thumbnailBounds(src.Bounds().Dx(), src.Bounds().Dy(), size)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	// 先铺白底，透明 PNG/GIF 转 JPEG 时不会变黑
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// thumbnailBounds 计算缩略图尺寸，小于 size 的图片保持原尺寸
+func thumbnailBounds(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// thumbnailKey 根据原图存储键生成缩略图存储键
+func thumbnailKey(key string) string {
+	if i := strings.LastIndex(key, "."); i > strings.LastIndex(key, "/") {
+		key = key[:i]
+	}
+	return key + "_thumb.jpg"
+}
+
+// randomName 生成随机文件名
+func randomName() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
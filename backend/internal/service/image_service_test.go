@@ -0,0 +1,80 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestThumbnailBounds(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		wantW, wantH  int
+	}{
+		{name: "landscape", width: 1600, height: 900, wantW: 320, wantH: 180},
+		{name: "portrait", width: 900, height: 1600, wantW: 180, wantH: 320},
+		{name: "small image unchanged", width: 100, height: 50, wantW: 100, wantH: 50},
+		{name: "very thin", width: 10000, height: 1, wantW: 320, wantH: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := thumbnailBounds(tt.width, tt.height, 320)
+			if w != tt.wantW || h != tt.wantH {
+				t.Errorf("thumbnailBounds(%d, %d) = (%d, %d), want (%d, %d)", tt.width, tt.height, w, h, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestThumbnailKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "menus/1/abc.png", want: "menus/1/abc_thumb.jpg"},
+		{key: "menus/1/abc", want: "menus/1/abc_thumb.jpg"},
+		{key: "menus/v1.2/abc", want: "menus/v1.2/abc_thumb.jpg"},
+	}
+
+	for _, tt := range tests {
+		if got := thumbnailKey(tt.key); got != tt.want {
+			t.Errorf("thumbnailKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestMakeThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	for x := 0; x < 800; x++ {
+		for y := 0; y < 400; y++ {
+			src.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	thumb, err := makeThumbnail(buf.Bytes(), 200)
+	if err != nil {
+		t.Fatalf("makeThumbnail() error = %v", err)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("thumbnail is not a valid JPEG: %v", err)
+	}
+	if got := img.Bounds().Size(); got.X != 200 || got.Y != 100 {
+		t.Errorf("thumbnail size = %v, want 200x100", got)
+	}
+
+	if _, err := makeThumbnail([]byte("not an image"), 200); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("makeThumbnail(invalid) error = %v, want ErrUnsupportedImage", err)
+	}
+}
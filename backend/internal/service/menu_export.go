@@ -0,0 +1,45 @@
+package service
+
+import (
+	"what-to-eat/internal/model"
+)
+
+// Export 导出全部餐厅和菜品，结构与 Import 读取的数据集一致
+func (s *MenuService) Export() (*model.MenuDataset, error) {
+	restaurants, err := s.restaurantRepo.GetAllWithMenus()
+	if err != nil {
+		return nil, err
+	}
+
+	dataset := &model.MenuDataset{
+		Restaurants: make([]model.DatasetRestaurant, 0, len(restaurants)),
+	}
+	for _, restaurant := range restaurants {
+		dataset.Restaurants = append(dataset.Restaurants, toDatasetRestaurant(&restaurant))
+	}
+	return dataset, nil
+}
+
+// toDatasetRestaurant 将餐厅及其菜品转换为数据集条目
+func toDatasetRestaurant(restaurant *model.Restaurant) model.DatasetRestaurant {
+	entry := model.DatasetRestaurant{
+		Name:         restaurant.Name,
+		Cuisine:      restaurant.Cuisine,
+		ImageURL:     restaurant.ImageURL,
+		ThumbnailURL: restaurant.ThumbnailURL,
+	}
+	for _, menu := range restaurant.Menus {
+		dish := model.DatasetDish{
+			Name:         menu.DishName,
+			Role:         menu.Role,
+			ImageURL:     menu.ImageURL,
+			ThumbnailURL: menu.ThumbnailURL,
+		}
+		if !menu.Nutrition.IsEmpty() {
+			nutrition := menu.Nutrition
+			dish.Nutrition = &nutrition
+		}
+		entry.Dishes = append(entry.Dishes, dish)
+	}
+	return entry
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+)
+
+// 名称最大长度（与 CreateMenuRequest 的校验保持一致）
+const maxNameLength = 100
+
+// errDryRunRollback 试运行时用于回滚事务
+var errDryRunRollback = errors.New("dry run rollback")
+
+// Import 批量导入餐厅和菜品
+// 所有行在同一个事务中处理；每行使用保存点隔离，单行失败不影响其他行。
+// dryRun 为 true 时完整执行导入流程后回滚，仅返回报告。
+func (s *MenuService) Import(format DatasetFormat, r io.Reader, dryRun bool) (*model.ImportReport, error) {
+	rows, err := DecodeImportRows(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.ImportReport{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Rows:   make([]model.ImportRowResult, 0, len(rows)),
+	}
+
+	err = s.menuRepo.Transaction(func(tx *gorm.DB) error {
+		menuRepo := s.menuRepo.WithTx(tx)
+		restaurantRepo := s.restaurantRepo.WithTx(tx)
+
+		for _, row := range rows {
+			result := model.ImportRowResult{
+				Line:           row.Line,
+				RestaurantName: strings.TrimSpace(row.RestaurantName),
+				DishName:       strings.TrimSpace(row.DishName),
+			}
+
+			msg := row.Error
+			if msg == "" {
+				msg = validateImportRow(result.RestaurantName, result.DishName, row.Nutrition)
+			}
+			if msg == "" {
+				msg = validateImportImages(result.DishName, row.RestaurantImage, row.DishImage)
+			}
+			cuisine := strings.TrimSpace(row.Cuisine)
+			if msg == "" && !model.IsValidCuisine(cuisine) {
+				msg = fmt.Sprintf("未知的菜系: %s", cuisine)
+			}
+			role := strings.TrimSpace(row.Role)
+			if msg == "" && !model.IsValidMenuRole(role) {
+				msg = fmt.Sprintf("未知的菜品角色: %s", role)
+			}
+			if msg != "" {
+				result.Status = model.ImportStatusError
+				result.Message = msg
+				addImportResult(report, result)
+				continue
+			}
+
+			savepoint := fmt.Sprintf("import_row_%d", len(report.Rows))
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+
+			status, menuID, err := importRow(restaurantRepo, menuRepo, result.RestaurantName, cuisine, result.DishName, role, &row)
+			if err != nil {
+				if rbErr := tx.RollbackTo(savepoint).Error; rbErr != nil {
+					return rbErr
+				}
+				result.Status = model.ImportStatusError
+				result.Message = err.Error()
+			} else {
+				result.Status = status
+				result.MenuID = menuID
+			}
+			addImportResult(report, result)
+		}
+
+		if dryRun {
+			return errDryRunRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRunRollback) {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// importRow 导入单行，复用 GetOrCreate 和重复菜品检查（与 Create 相同的去重规则）
+func importRow(
+	restaurantRepo *repository.RestaurantRepository,
+	menuRepo *repository.MenuRepository,
+	restaurantName, cuisine, dishName, role string,
+	row *model.ImportRow,
+) (string, int64, error) {
+	restaurant, isNewRestaurant, err := restaurantRepo.GetOrCreate(restaurantName)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := applyCuisine(restaurantRepo, restaurant, cuisine); err != nil {
+		return "", 0, err
+	}
+	// 已有图片的餐厅保留原图
+	if !row.RestaurantImage.IsEmpty() && restaurant.ImageURL == "" && restaurant.ThumbnailURL == "" {
+		if err := restaurantRepo.UpdateImage(restaurant.ID, "", row.RestaurantImage.URL, row.RestaurantImage.ThumbnailURL); err != nil {
+			return "", 0, err
+		}
+	}
+
+	// 只有餐厅的行
+	if dishName == "" {
+		if isNewRestaurant {
+			return model.ImportStatusCreated, 0, nil
+		}
+		return model.ImportStatusSkipped, 0, nil
+	}
+
+	exists, err := menuRepo.ExistsByRestaurantAndDish(restaurant.ID, dishName)
+	if err != nil {
+		return "", 0, err
+	}
+	if exists {
+		return model.ImportStatusSkipped, 0, nil
+	}
+
+	menu := &model.Menu{
+		RestaurantID: restaurant.ID,
+		DishName:     dishName,
+		Role:         role,
+		ImageURL:     row.DishImage.URL,
+		ThumbnailURL: row.DishImage.ThumbnailURL,
+	}
+	if row.Nutrition != nil {
+		menu.Nutrition = *row.Nutrition
+	}
+	if err := menuRepo.Create(menu); err != nil {
+		return "", 0, err
+	}
+	return model.ImportStatusCreated, menu.ID, nil
+}
+
+// validateImportRow 校验导入行，返回错误信息（为空表示通过）
+func validateImportRow(restaurantName, dishName string, nutrition *model.Nutrition) string {
+	if restaurantName == "" {
+		return "餐厅名称不能为空"
+	}
+	if utf8.RuneCountInString(restaurantName) > maxNameLength {
+		return fmt.Sprintf("餐厅名称不能超过%d个字符", maxNameLength)
+	}
+	if utf8.RuneCountInString(dishName) > maxNameLength {
+		return fmt.Sprintf("菜品名称不能超过%d个字符", maxNameLength)
+	}
+	if !nutrition.IsEmpty() && dishName == "" {
+		return "营养信息需要对应菜品"
+	}
+	if msg := validateNutrition(nutrition); msg != "" {
+		return msg
+	}
+	return ""
+}
+
+// 图片地址最大长度（与 image_url、thumbnail_url 列一致）
+const maxImageURLLength = 255
+
+// validateImportImages 校验导入的图片地址，返回错误信息（为空表示通过）
+// 导入的图片只记录地址，不复制图片文件；没有存储键的图片在重新上传时不会删除原文件
+func validateImportImages(dishName string, restaurantImage, dishImage model.Image) string {
+	if !dishImage.IsEmpty() && dishName == "" {
+		return "菜品图片需要对应菜品"
+	}
+	for _, url := range []string{restaurantImage.URL, restaurantImage.ThumbnailURL, dishImage.URL, dishImage.ThumbnailURL} {
+		if len(url) > maxImageURLLength {
+			return fmt.Sprintf("图片地址不能超过%d个字符", maxImageURLLength)
+		}
+	}
+	return ""
+}
+
+// 营养信息取值上限（与 model.Nutrition 的 binding 校验保持一致）
+const (
+	maxKcal  = 10000
+	maxGrams = 1000
+)
+
+// validateNutrition 校验营养信息取值范围，返回错误信息（为空表示通过）
+func validateNutrition(n *model.Nutrition) string {
+	if n == nil {
+		return ""
+	}
+	if n.Kcal != nil && (*n.Kcal < 0 || *n.Kcal > maxKcal) {
+		return fmt.Sprintf("kcal 需在 0-%d 之间", maxKcal)
+	}
+	for _, f := range []struct {
+		name  string
+		value *float64
+	}{
+		{"protein", n.Protein},
+		{"carbs", n.Carbs},
+		{"fat", n.Fat},
+	} {
+		if f.value != nil && (*f.value < 0 || *f.value > maxGrams) {
+			return fmt.Sprintf("%s 需在 0-%d 之间", f.name, maxGrams)
+		}
+	}
+	return ""
+}
+
+// addImportResult 记录单行结果并更新统计
+func addImportResult(report *model.ImportReport, result model.ImportRowResult) {
+	switch result.Status {
+	case model.ImportStatusCreated:
+		report.Created++
+	case model.ImportStatusSkipped:
+		report.Skipped++
+	default:
+		report.Failed++
+	}
+	report.Rows = append(report.Rows, result)
+}
@@ -3,12 +3,16 @@ package service
 import (
 	"errors"
 
+	"gorm.io/gorm"
+
 	"what-to-eat/internal/model"
 	"what-to-eat/internal/repository"
+	"what-to-eat/pkg/normalize"
 )
 
 var (
-	ErrMenuExists = errors.New("该餐厅已有此菜品")
+	ErrMenuExists     = errors.New("该餐厅已有此菜品")
+	ErrInvalidCuisine = errors.New("未知的菜系")
 )
 
 type MenuService struct {
@@ -25,11 +29,18 @@ func NewMenuService(menuRepo *repository.MenuRepository, restaurantRepo *reposit
 
 // Create 创建菜单（同时处理餐厅）
 func (s *MenuService) Create(req *model.CreateMenuRequest) (*model.Menu, bool, error) {
+	if !model.IsValidCuisine(req.Cuisine) {
+		return nil, false, ErrInvalidCuisine
+	}
+
 	// 获取或创建餐厅
 	restaurant, isNewRestaurant, err := s.restaurantRepo.GetOrCreate(req.RestaurantName)
 	if err != nil {
 		return nil, false, err
 	}
+	if err := applyCuisine(s.restaurantRepo, restaurant, req.Cuisine); err != nil {
+		return nil, false, err
+	}
 
 	// 检查该餐厅是否已有此菜品
 	exists, err := s.menuRepo.ExistsByRestaurantAndDish(restaurant.ID, req.DishName)
@@ -44,6 +55,10 @@ func (s *MenuService) Create(req *model.CreateMenuRequest) (*model.Menu, bool, e
 	menu := &model.Menu{
 		RestaurantID: restaurant.ID,
 		DishName:     req.DishName,
+		Role:         req.Role,
+	}
+	if req.Nutrition != nil {
+		menu.Nutrition = *req.Nutrition
 	}
 
 	if err := s.menuRepo.Create(menu); err != nil {
@@ -56,6 +71,54 @@ func (s *MenuService) Create(req *model.CreateMenuRequest) (*model.Menu, bool, e
 	return menu, isNewRestaurant, nil
 }
 
+// FindSimilar 查找与待创建的餐厅/菜品名称相似的已有条目
+// 名称规范化后完全相同的条目不会返回（餐厅会被直接复用，菜品会被判定为重复）
+func (s *MenuService) FindSimilar(req *model.CreateMenuRequest) ([]model.NameSuggestion, error) {
+	var suggestions []model.NameSuggestion
+
+	restaurant, err := s.restaurantRepo.GetByName(req.RestaurantName)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if restaurant == nil {
+		// 新餐厅：检查是否与已有餐厅相似
+		restaurants, err := s.restaurantRepo.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		key := normalize.Key(req.RestaurantName)
+		for _, r := range restaurants {
+			if isNearDuplicate(key, r.NameKey) {
+				suggestions = append(suggestions, model.NameSuggestion{
+					Type: model.SearchTypeRestaurant,
+					ID:   r.ID,
+					Name: r.Name,
+				})
+			}
+		}
+		return suggestions, nil
+	}
+
+	// 已有餐厅：检查是否与该餐厅下的菜品相似
+	menus, err := s.menuRepo.GetByRestaurantID(restaurant.ID)
+	if err != nil {
+		return nil, err
+	}
+	key := normalize.Key(req.DishName)
+	for _, m := range menus {
+		if m.DishNameKey != key && isNearDuplicate(key, m.DishNameKey) {
+			suggestions = append(suggestions, model.NameSuggestion{
+				Type:         model.SearchTypeDish,
+				ID:           m.ID,
+				Name:         m.DishName,
+				RestaurantID: m.RestaurantID,
+			})
+		}
+	}
+	return suggestions, nil
+}
+
 // GetAll 获取所有菜单
 func (s *MenuService) GetAll() ([]model.Menu, error) {
 	return s.menuRepo.GetAll()
@@ -66,6 +129,32 @@ func (s *MenuService) GetByID(id int64) (*model.Menu, error) {
 	return s.menuRepo.GetByID(id)
 }
 
+// UpdateNutrition 更新菜品营养信息
+func (s *MenuService) UpdateNutrition(id int64, n *model.Nutrition) (*model.Menu, error) {
+	menu, err := s.menuRepo.GetByID(id)
+	if err != nil {
+		return nil, notFoundOr(err, ErrMenuNotFound)
+	}
+	if err := s.menuRepo.UpdateNutrition(menu.ID, *n); err != nil {
+		return nil, err
+	}
+	menu.Nutrition = *n
+	return menu, nil
+}
+
+// UpdateRole 设置菜品角色（为空表示清除）
+func (s *MenuService) UpdateRole(id int64, role string) (*model.Menu, error) {
+	menu, err := s.menuRepo.GetByID(id)
+	if err != nil {
+		return nil, notFoundOr(err, ErrMenuNotFound)
+	}
+	if err := s.menuRepo.UpdateRole(menu.ID, role); err != nil {
+		return nil, err
+	}
+	menu.Role = role
+	return menu, nil
+}
+
 // Delete 删除菜单
 func (s *MenuService) Delete(id int64) error {
 	return s.menuRepo.Delete(id)
@@ -75,3 +164,15 @@ func (s *MenuService) Delete(id int64) error {
 func (s *MenuService) GetAllRestaurants() ([]model.Restaurant, error) {
 	return s.restaurantRepo.GetAll()
 }
+
+// applyCuisine 为尚未分类的餐厅设置菜系（已分类的餐厅保持不变，修改分类请使用 UpdateCuisine）
+func applyCuisine(restaurantRepo *repository.RestaurantRepository, restaurant *model.Restaurant, cuisine string) error {
+	if cuisine == "" || restaurant.Cuisine != "" {
+		return nil
+	}
+	if err := restaurantRepo.UpdateCuisine(restaurant.ID, cuisine); err != nil {
+		return err
+	}
+	restaurant.Cuisine = cuisine
+	return nil
+}
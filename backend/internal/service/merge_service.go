@@ -0,0 +1,248 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+)
+
+var (
+	ErrRestaurantNotFound = errors.New("餐厅不存在")
+	ErrMenuNotFound       = errors.New("菜品不存在")
+	ErrMergeSelf          = errors.New("不能合并到自身")
+)
+
+// 审计操作类型
+const (
+	AuditActionMergeRestaurant = "merge_restaurant"
+	AuditActionMergeMenu       = "merge_menu"
+)
+
+// MergeService 合并重复的餐厅和菜品（管理员操作）
+type MergeService struct {
+	restaurantRepo *repository.RestaurantRepository
+	menuRepo       *repository.MenuRepository
+	decisionRepo   *repository.DecisionRepository
+	prefRepo       *repository.PreferenceRepository
+	auditRepo      *repository.AuditRepository
+}
+
+func NewMergeService(
+	restaurantRepo *repository.RestaurantRepository,
+	menuRepo *repository.MenuRepository,
+	decisionRepo *repository.DecisionRepository,
+	prefRepo *repository.PreferenceRepository,
+	auditRepo *repository.AuditRepository,
+) *MergeService {
+	return &MergeService{
+		restaurantRepo: restaurantRepo,
+		menuRepo:       menuRepo,
+		decisionRepo:   decisionRepo,
+		prefRepo:       prefRepo,
+		auditRepo:      auditRepo,
+	}
+}
+
+// mergeTx 事务内使用的仓库
+type mergeTx struct {
+	restaurantRepo *repository.RestaurantRepository
+	menuRepo       *repository.MenuRepository
+	decisionRepo   *repository.DecisionRepository
+	prefRepo       *repository.PreferenceRepository
+	auditRepo      *repository.AuditRepository
+}
+
+// transaction 在同一个事务中执行合并操作
+func (s *MergeService) transaction(fn func(repos *mergeTx) error) error {
+	return s.menuRepo.Transaction(func(tx *gorm.DB) error {
+		return fn(&mergeTx{
+			restaurantRepo: s.restaurantRepo.WithTx(tx),
+			menuRepo:       s.menuRepo.WithTx(tx),
+			decisionRepo:   s.decisionRepo.WithTx(tx),
+			prefRepo:       s.prefRepo.WithTx(tx),
+			auditRepo:      s.auditRepo.WithTx(tx),
+		})
+	})
+}
+
+// MergeRestaurants 将餐厅 sourceID 合并到 targetID
+// 源餐厅的菜品全部移动到目标餐厅；与目标餐厅同名的菜品合并到目标菜品（决策记录、收藏和屏蔽重新指向后软删除）；
+// 源餐厅的屏蔽转移到目标餐厅，最后软删除源餐厅并记录审计日志。所有操作在同一个事务中完成。
+func (s *MergeService) MergeRestaurants(actorID, targetID, sourceID int64) (*model.MergeResult, error) {
+	if targetID == sourceID {
+		return nil, ErrMergeSelf
+	}
+
+	result := &model.MergeResult{TargetID: targetID, SourceID: sourceID}
+
+	err := s.transaction(func(repos *mergeTx) error {
+		target, err := repos.restaurantRepo.GetByID(targetID)
+		if err != nil {
+			return notFoundOr(err, ErrRestaurantNotFound)
+		}
+		source, err := repos.restaurantRepo.GetByID(sourceID)
+		if err != nil {
+			return notFoundOr(err, ErrRestaurantNotFound)
+		}
+
+		// 目标餐厅现有菜品（按规范化名称索引）
+		targetMenus, err := repos.menuRepo.GetByRestaurantID(target.ID)
+		if err != nil {
+			return err
+		}
+		targetByKey := make(map[string]int64, len(targetMenus))
+		for _, m := range targetMenus {
+			targetByKey[m.DishNameKey] = m.ID
+		}
+
+		sourceMenus, err := repos.menuRepo.GetByRestaurantIDUnscoped(source.ID)
+		if err != nil {
+			return err
+		}
+		for _, m := range sourceMenus {
+			if err := repos.menuRepo.MoveToRestaurant(m.ID, target.ID); err != nil {
+				return err
+			}
+			if m.DeletedAt.Valid {
+				continue // 已删除的菜品只移动归属，保证历史记录完整
+			}
+
+			targetMenuID, collision := targetByKey[m.DishNameKey]
+			if !collision {
+				targetByKey[m.DishNameKey] = m.ID
+				result.MovedDishes++
+				continue
+			}
+
+			// 同名菜品：决策记录、收藏和屏蔽指向目标菜品，源菜品软删除
+			repointed, err := repos.decisionRepo.RepointMenu(m.ID, targetMenuID)
+			if err != nil {
+				return err
+			}
+			if err := repointMenuPreferences(repos.prefRepo, m.ID, targetMenuID); err != nil {
+				return err
+			}
+			if err := repos.menuRepo.Delete(m.ID); err != nil {
+				return err
+			}
+			result.RepointedRecords += repointed
+			result.MergedDishes = append(result.MergedDishes, model.DishCollision{
+				SourceMenuID: m.ID,
+				TargetMenuID: targetMenuID,
+				DishName:     m.DishName,
+			})
+		}
+
+		// 目标餐厅未分类时沿用源餐厅的菜系
+		if err := applyCuisine(repos.restaurantRepo, target, source.Cuisine); err != nil {
+			return err
+		}
+
+		if err := repos.prefRepo.RepointBlocks(model.BlockTargetRestaurant, source.ID, target.ID); err != nil {
+			return err
+		}
+
+		if err := repos.restaurantRepo.MarkMerged(source.ID, target.ID); err != nil {
+			return err
+		}
+
+		return writeAudit(repos.auditRepo, actorID, AuditActionMergeRestaurant, "restaurant", target.ID, source.ID, auditDetail{
+			"source_name": source.Name,
+			"target_name": target.Name,
+			"result":      result,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MergeMenus 将菜品 sourceID 合并到 targetID
+// 决策记录、收藏和屏蔽重新指向目标菜品，源菜品软删除并记录审计日志。
+func (s *MergeService) MergeMenus(actorID, targetID, sourceID int64) (*model.MergeResult, error) {
+	if targetID == sourceID {
+		return nil, ErrMergeSelf
+	}
+
+	result := &model.MergeResult{TargetID: targetID, SourceID: sourceID}
+
+	err := s.transaction(func(repos *mergeTx) error {
+		target, err := repos.menuRepo.GetByID(targetID)
+		if err != nil {
+			return notFoundOr(err, ErrMenuNotFound)
+		}
+		source, err := repos.menuRepo.GetByID(sourceID)
+		if err != nil {
+			return notFoundOr(err, ErrMenuNotFound)
+		}
+
+		repointed, err := repos.decisionRepo.RepointMenu(source.ID, target.ID)
+		if err != nil {
+			return err
+		}
+		if err := repointMenuPreferences(repos.prefRepo, source.ID, target.ID); err != nil {
+			return err
+		}
+		if err := repos.menuRepo.Delete(source.ID); err != nil {
+			return err
+		}
+		result.RepointedRecords = repointed
+		result.MergedDishes = []model.DishCollision{{
+			SourceMenuID: source.ID,
+			TargetMenuID: target.ID,
+			DishName:     source.DishName,
+		}}
+
+		return writeAudit(repos.auditRepo, actorID, AuditActionMergeMenu, "menu", target.ID, source.ID, auditDetail{
+			"source_name":       source.DishName,
+			"source_restaurant": source.Restaurant.Name,
+			"target_name":       target.DishName,
+			"target_restaurant": target.Restaurant.Name,
+			"repointed_records": repointed,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// repointMenuPreferences 将菜品的收藏和屏蔽转移到目标菜品
+func repointMenuPreferences(prefRepo *repository.PreferenceRepository, fromMenuID, toMenuID int64) error {
+	if err := prefRepo.RepointFavorites(fromMenuID, toMenuID); err != nil {
+		return err
+	}
+	return prefRepo.RepointBlocks(model.BlockTargetMenu, fromMenuID, toMenuID)
+}
+
+// auditDetail 审计详情
+type auditDetail map[string]interface{}
+
+// writeAudit 写入审计日志
+func writeAudit(auditRepo *repository.AuditRepository, actorID int64, action, targetType string, targetID, sourceID int64, detail auditDetail) error {
+	data, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+	return auditRepo.Create(&model.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		SourceID:   sourceID,
+		Detail:     string(data),
+	})
+}
+
+// notFoundOr 将记录不存在错误转换为业务错误
+func notFoundOr(err error, notFound error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	return err
+}
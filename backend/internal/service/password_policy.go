@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrWeakPassword = errors.New("密码不符合要求")
+
+// maxPasswordBytes bcrypt 只使用密码的前 72 个字节，更长的密码无法保存
+const maxPasswordBytes = 72
+
+//go:embed passwords/common.txt
+var commonPasswords string
+
+// PasswordPolicy 密码策略：最小长度、至少包含几类字符（小写字母、大写字母、数字、其他符号），且不能是常见弱密码或与用户名相同
+// 常见弱密码列表内置在程序中，可以通过文件追加
+type PasswordPolicy struct {
+	MinLength  int
+	MinClasses int
+	common     map[string]struct{}
+}
+
+// NewPasswordPolicy 创建密码策略，commonFile 为追加的常见弱密码文件（每行一个），为空表示只使用内置列表
+func NewPasswordPolicy(minLength, minClasses int, commonFile string) (*PasswordPolicy, error) {
+	p := &PasswordPolicy{MinLength: minLength, MinClasses: minClasses, common: make(map[string]struct{})}
+	if err := p.loadCommon(strings.NewReader(commonPasswords)); err != nil {
+		return nil, err
+	}
+	if commonFile != "" {
+		f, err := os.Open(commonFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := p.loadCommon(f); err != nil {
+			return nil, fmt.Errorf("读取常见密码文件失败: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// loadCommon 读取常见弱密码列表（忽略空行和 # 开头的注释）
+func (p *PasswordPolicy) loadCommon(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.common[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// Validate 检查密码是否符合策略，不符合时返回包装了 ErrWeakPassword 的错误（说明具体原因）
+// 注册、游客升级、修改和重置密码都使用同一策略；策略为 nil 时只检查长度上限
+func (p *PasswordPolicy) Validate(password, username string) error {
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("%w：不能超过 %d 个字节", ErrWeakPassword, maxPasswordBytes)
+	}
+	if p == nil {
+		return nil
+	}
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w：至少需要 %d 个字符", ErrWeakPassword, p.MinLength)
+	}
+	if classes := passwordClasses(password); classes < p.MinClasses {
+		return fmt.Errorf("%w：需要包含小写字母、大写字母、数字、符号中的至少 %d 类", ErrWeakPassword, p.MinClasses)
+	}
+	lower := strings.ToLower(password)
+	if username != "" && lower == strings.ToLower(username) {
+		return fmt.Errorf("%w：不能与用户名相同", ErrWeakPassword)
+	}
+	if _, ok := p.common[lower]; ok {
+		return fmt.Errorf("%w：过于常见", ErrWeakPassword)
+	}
+	return nil
+}
+
+// passwordClasses 密码包含的字符类别数（小写字母、大写字母、数字、其他符号）
+func passwordClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	count := 0
+	for _, ok := range []bool{lower, upper, digit, other} {
+		if ok {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy, err := NewPasswordPolicy(8, 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		username string
+		wantErr  bool
+	}{
+		{name: "ok", password: "lunch-time-42", username: "alice"},
+		{name: "too short", password: "ab1!", wantErr: true},
+		{name: "one class", password: "abcdefghij", wantErr: true},
+		{name: "common", password: "Password1", wantErr: true},
+		{name: "common numbers", password: "woaini1314", wantErr: true},
+		{name: "same as username", password: "Alice2024", username: "alice2024", wantErr: true},
+		{name: "chinese counts as other", password: "今天吃什么abc", username: "bob"},
+		{name: "longest allowed", password: "Lunch-" + strings.Repeat("x", 66)},
+		{name: "too long for bcrypt", password: "Lunch-" + strings.Repeat("x", 67), wantErr: true},
+		{name: "too long in bytes", password: "今天吃什么" + strings.Repeat("饭", 20), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, tt.username)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrWeakPassword) {
+				t.Errorf("error %v should wrap ErrWeakPassword", err)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_CommonFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "common.txt")
+	if err := os.WriteFile(file, []byte("# 公司内部常见密码\nCompany@2024\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := NewPasswordPolicy(8, 2, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := policy.Validate("company@2024", "bob"); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("Validate() error = %v, want ErrWeakPassword", err)
+	}
+	if err := policy.Validate("password", "bob"); err == nil {
+		t.Error("built-in list should still apply")
+	}
+}
+
+func TestPasswordPolicy_Nil(t *testing.T) {
+	var policy *PasswordPolicy
+	if err := policy.Validate("1", "bob"); err != nil {
+		t.Errorf("nil policy Validate() error = %v", err)
+	}
+	if err := policy.Validate(strings.Repeat("a", maxPasswordBytes+1), "bob"); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("nil policy Validate() error = %v, want ErrWeakPassword", err)
+	}
+}
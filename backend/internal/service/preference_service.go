@@ -0,0 +1,172 @@
+package service
+
+import (
+	"errors"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+)
+
+var (
+	ErrAllMenusBlocked = errors.New("候选菜品都已被屏蔽")
+)
+
+// PreferenceService 用户收藏和屏蔽（只影响该用户自己的决策）
+type PreferenceService struct {
+	prefRepo       *repository.PreferenceRepository
+	menuRepo       *repository.MenuRepository
+	restaurantRepo *repository.RestaurantRepository
+}
+
+func NewPreferenceService(
+	prefRepo *repository.PreferenceRepository,
+	menuRepo *repository.MenuRepository,
+	restaurantRepo *repository.RestaurantRepository,
+) *PreferenceService {
+	return &PreferenceService{
+		prefRepo:       prefRepo,
+		menuRepo:       menuRepo,
+		restaurantRepo: restaurantRepo,
+	}
+}
+
+// AddFavorite 收藏菜品
+func (s *PreferenceService) AddFavorite(userID, menuID int64) error {
+	if _, err := s.menuRepo.GetByID(menuID); err != nil {
+		return notFoundOr(err, ErrMenuNotFound)
+	}
+	return s.prefRepo.AddFavorite(userID, menuID)
+}
+
+// RemoveFavorite 取消收藏
+func (s *PreferenceService) RemoveFavorite(userID, menuID int64) error {
+	return s.prefRepo.RemoveFavorite(userID, menuID)
+}
+
+// ListFavorites 获取用户收藏的菜品（不含已删除的菜品）
+func (s *PreferenceService) ListFavorites(userID int64) ([]model.Menu, error) {
+	ids, err := s.prefRepo.GetFavoriteMenuIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []model.Menu{}, nil
+	}
+	return s.menuRepo.GetByIDs(ids)
+}
+
+// Block 屏蔽菜品或餐厅
+func (s *PreferenceService) Block(userID int64, targetType string, targetID int64) error {
+	switch targetType {
+	case model.BlockTargetMenu:
+		if _, err := s.menuRepo.GetByID(targetID); err != nil {
+			return notFoundOr(err, ErrMenuNotFound)
+		}
+	case model.BlockTargetRestaurant:
+		if _, err := s.restaurantRepo.GetByID(targetID); err != nil {
+			return notFoundOr(err, ErrRestaurantNotFound)
+		}
+	}
+	return s.prefRepo.AddBlock(userID, targetType, targetID)
+}
+
+// Unblock 取消屏蔽
+func (s *PreferenceService) Unblock(userID int64, targetType string, targetID int64) error {
+	return s.prefRepo.RemoveBlock(userID, targetType, targetID)
+}
+
+// ListBlocks 获取用户屏蔽的菜品和餐厅
+func (s *PreferenceService) ListBlocks(userID int64) (*model.BlockListResponse, error) {
+	blocks, err := s.prefRepo.GetBlocks(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var menuIDs, restaurantIDs []int64
+	for _, b := range blocks {
+		switch b.TargetType {
+		case model.BlockTargetMenu:
+			menuIDs = append(menuIDs, b.TargetID)
+		case model.BlockTargetRestaurant:
+			restaurantIDs = append(restaurantIDs, b.TargetID)
+		}
+	}
+
+	resp := &model.BlockListResponse{
+		Menus:       []model.Menu{},
+		Restaurants: []model.Restaurant{},
+	}
+	if len(menuIDs) > 0 {
+		if resp.Menus, err = s.menuRepo.GetByIDs(menuIDs); err != nil {
+			return nil, err
+		}
+	}
+	if len(restaurantIDs) > 0 {
+		if resp.Restaurants, err = s.restaurantRepo.GetByIDs(restaurantIDs); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// userPreferences 用户的收藏和屏蔽（决策时使用）
+type userPreferences struct {
+	favorites          map[int64]bool
+	blockedMenus       map[int64]bool
+	blockedRestaurants map[int64]bool
+}
+
+// loadUserPreferences 加载用户的收藏和屏蔽
+func loadUserPreferences(prefRepo *repository.PreferenceRepository, userID int64) (*userPreferences, error) {
+	favorites, err := prefRepo.GetFavoriteMenuIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := prefRepo.GetBlocks(userID)
+	if err != nil {
+		return nil, err
+	}
+	return newUserPreferences(favorites, blocks), nil
+}
+
+func newUserPreferences(favorites []int64, blocks []model.UserBlock) *userPreferences {
+	prefs := &userPreferences{
+		favorites:          make(map[int64]bool, len(favorites)),
+		blockedMenus:       make(map[int64]bool),
+		blockedRestaurants: make(map[int64]bool),
+	}
+	for _, id := range favorites {
+		prefs.favorites[id] = true
+	}
+	for _, b := range blocks {
+		switch b.TargetType {
+		case model.BlockTargetMenu:
+			prefs.blockedMenus[b.TargetID] = true
+		case model.BlockTargetRestaurant:
+			prefs.blockedRestaurants[b.TargetID] = true
+		}
+	}
+	return prefs
+}
+
+// filterBlocked 移除被屏蔽的菜品和屏蔽餐厅的全部菜品
+func (p *userPreferences) filterBlocked(menus []model.Menu) []model.Menu {
+	if p == nil {
+		return menus
+	}
+	filtered := make([]model.Menu, 0, len(menus))
+	for _, m := range menus {
+		if !p.blockedMenus[m.ID] && !p.blockedRestaurants[m.RestaurantID] {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+// favoriteFactor 收藏菜品的权重倍数，未收藏时为 1
+func (p *userPreferences) favoriteFactor(menuID int64, w DecisionWeights) float64 {
+	if p != nil && p.favorites[menuID] {
+		return w.Favorite
+	}
+	return 1.0
+}
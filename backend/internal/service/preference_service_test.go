@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+
+	"what-to-eat/internal/model"
+)
+
+func TestUserPreferencesFilterBlocked(t *testing.T) {
+	menus := []model.Menu{
+		{ID: 1, RestaurantID: 1},
+		{ID: 2, RestaurantID: 1},
+		{ID: 3, RestaurantID: 2},
+		{ID: 4, RestaurantID: 3},
+	}
+	prefs := newUserPreferences(nil, []model.UserBlock{
+		{TargetType: model.BlockTargetMenu, TargetID: 3},
+		{TargetType: model.BlockTargetRestaurant, TargetID: 1},
+	})
+
+	got := prefs.filterBlocked(menus)
+	if len(got) != 1 || got[0].ID != 4 {
+		t.Errorf("filterBlocked() = %+v, want only menu 4", got)
+	}
+
+	// 没有偏好时不做过滤
+	var none *userPreferences
+	if got := none.filterBlocked(menus); len(got) != len(menus) {
+		t.Errorf("nil filterBlocked() returned %d menus, want %d", len(got), len(menus))
+	}
+}
+
+func TestWeightedRandom_Favorite(t *testing.T) {
+	service := NewDecisionService(nil, nil, nil, nil, DecisionWeights{RecentCount: 3, Favorite: 3})
+	menus := []model.Menu{{ID: 1}, {ID: 2}}
+	prefs := newUserPreferences([]int64{1}, nil)
+
+	counts := make(map[int64]int)
+	iterations := 4000
+	for i := 0; i < iterations; i++ {
+		counts[service.weightedRandom(menus, nil, prefs).ID]++
+	}
+
+	// 收藏权重为 3，期望约 75% 选中收藏菜品
+	ratio := float64(counts[1]) / float64(iterations)
+	if ratio < 0.7 || ratio > 0.8 {
+		t.Errorf("favorite ratio = %.2f, want about 0.75", ratio)
+	}
+}
+
+func TestNewDecisionService_FavoriteWeightAtLeastOne(t *testing.T) {
+	s := NewDecisionService(nil, nil, nil, nil, DecisionWeights{Favorite: 0.5})
+	if s.weights.Favorite != 1 {
+		t.Errorf("Favorite = %v, want 1", s.weights.Favorite)
+	}
+}
@@ -17,8 +17,13 @@ func NewRestaurantService(restaurantRepo *repository.RestaurantRepository) *Rest
 
 // Create 创建餐厅
 func (s *RestaurantService) Create(req *model.CreateRestaurantRequest) (*model.Restaurant, error) {
+	if !model.IsValidCuisine(req.Cuisine) {
+		return nil, ErrInvalidCuisine
+	}
+
 	restaurant := &model.Restaurant{
-		Name: req.Name,
+		Name:    req.Name,
+		Cuisine: req.Cuisine,
 	}
 
 	if err := s.restaurantRepo.Create(restaurant); err != nil {
@@ -42,3 +47,20 @@ func (s *RestaurantService) GetByID(id int64) (*model.Restaurant, error) {
 func (s *RestaurantService) Delete(id int64) error {
 	return s.restaurantRepo.Delete(id)
 }
+
+// UpdateCuisine 设置餐厅菜系（为空表示清除分类）
+func (s *RestaurantService) UpdateCuisine(id int64, cuisine string) (*model.Restaurant, error) {
+	if !model.IsValidCuisine(cuisine) {
+		return nil, ErrInvalidCuisine
+	}
+
+	restaurant, err := s.restaurantRepo.GetByID(id)
+	if err != nil {
+		return nil, notFoundOr(err, ErrRestaurantNotFound)
+	}
+	if err := s.restaurantRepo.UpdateCuisine(restaurant.ID, cuisine); err != nil {
+		return nil, err
+	}
+	restaurant.Cuisine = cuisine
+	return restaurant, nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"time"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+)
+
+// RetentionPolicy 决策记录保留策略
+type RetentionPolicy struct {
+	KeepDays  int // 保留原始记录的天数，0 表示永久保留
+	BatchSize int // 每个事务汇总和删除的记录数
+}
+
+// RetentionReport 一次清理的结果
+type RetentionReport struct {
+	Users      int   `json:"users"`      // 有记录被汇总的用户数
+	Records    int64 `json:"records"`    // 汇总并删除的决策记录数（试运行时为将要删除的数量）
+	Aggregates int64 `json:"aggregates"` // 写入的月度汇总行数（同一行多次累加各计一次）
+	DryRun     bool  `json:"dry_run"`
+}
+
+// RetentionService 按保留策略把过期的决策记录汇总为月度统计后删除
+// 只处理保留期限之前的整月（按用户时区），同一个月要么全部是原始记录，要么全部已汇总；
+// 每批记录在一个短事务中完成汇总和删除，避免长时间锁表
+type RetentionService struct {
+	decisionRepo *repository.DecisionRepository
+	timeZones    *TimeZoneService
+	policy       RetentionPolicy
+}
+
+func NewRetentionService(decisionRepo *repository.DecisionRepository, timeZones *TimeZoneService, policy RetentionPolicy) *RetentionService {
+	if policy.BatchSize <= 0 {
+		policy.BatchSize = 500
+	}
+	return &RetentionService{decisionRepo: decisionRepo, timeZones: timeZones, policy: policy}
+}
+
+// Enabled 是否配置了保留期限
+func (s *RetentionService) Enabled() bool {
+	return s.policy.KeepDays > 0
+}
+
+// Prune 汇总并删除 now 时刻已超过保留期限的决策记录，dryRun 时只统计数量
+func (s *RetentionService) Prune(now time.Time, dryRun bool) (*RetentionReport, error) {
+	report := &RetentionReport{DryRun: dryRun}
+	if !s.Enabled() {
+		return report, nil
+	}
+
+	// 各用户的截止时间都不晚于 now - KeepDays，先以它筛选出需要处理的用户
+	userIDs, err := s.decisionRepo.UserIDsWithRecordsBefore(now.AddDate(0, 0, -s.policy.KeepDays))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userID := range userIDs {
+		loc, err := s.timeZones.Location(userID)
+		if err != nil {
+			return nil, err
+		}
+		cutoff := retentionCutoff(now, s.policy.KeepDays, loc)
+
+		var pruned int64
+		if dryRun {
+			pruned, err = s.decisionRepo.CountBefore(userID, cutoff)
+		} else {
+			pruned, err = s.pruneUser(userID, cutoff, loc, report)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if pruned > 0 {
+			report.Users++
+			report.Records += pruned
+		}
+	}
+	return report, nil
+}
+
+// pruneUser 分批汇总并删除用户在 cutoff 之前的记录
+func (s *RetentionService) pruneUser(userID int64, cutoff time.Time, loc *time.Location, report *RetentionReport) (int64, error) {
+	var pruned int64
+	for {
+		records, err := s.decisionRepo.GetOldestBefore(userID, cutoff, s.policy.BatchSize)
+		if err != nil {
+			return pruned, err
+		}
+		if len(records) == 0 {
+			return pruned, nil
+		}
+
+		ids := make([]int64, 0, len(records))
+		for _, r := range records {
+			ids = append(ids, r.ID)
+		}
+		aggregates := rollUpRecords(records, loc)
+		if err := s.decisionRepo.ReplaceWithAggregates(ids, aggregates); err != nil {
+			return pruned, err
+		}
+		pruned += int64(len(records))
+		report.Aggregates += int64(len(aggregates))
+
+		if len(records) < s.policy.BatchSize {
+			return pruned, nil
+		}
+	}
+}
+
+// retentionCutoff 保留期限开始的那个月的第一天（loc 时区），在此之前的记录会被汇总
+func retentionCutoff(now time.Time, keepDays int, loc *time.Location) time.Time {
+	t := now.In(loc).AddDate(0, 0, -keepDays)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+}
+
+// aggregateKey 月度汇总的维度
+type aggregateKey struct {
+	month   time.Time
+	menuID  int64
+	weekday int
+}
+
+// rollUpRecords 将决策记录按（月份、菜品、星期）汇总，月份和星期按用户时区计算
+// 主记录菜品计入 Meals 和 Repeats，组合决策的每个菜品各计入一次 Dishes
+func rollUpRecords(records []model.DecisionRecord, loc *time.Location) []model.DecisionAggregate {
+	index := make(map[aggregateKey]int)
+	var aggregates []model.DecisionAggregate
+	get := func(key aggregateKey) *model.DecisionAggregate {
+		i, ok := index[key]
+		if !ok {
+			i = len(aggregates)
+			index[key] = i
+			aggregates = append(aggregates, model.DecisionAggregate{
+				Month:   key.month,
+				MenuID:  key.menuID,
+				Weekday: key.weekday,
+			})
+		}
+		return &aggregates[i]
+	}
+
+	for _, r := range records {
+		local := r.DecidedAt.In(loc)
+		// 月份以 UTC 0 点保存，避免写入 DATE 列时因时区换算变成前一天
+		month := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, time.UTC)
+		weekday := int(local.Weekday())
+
+		meal := get(aggregateKey{month: month, menuID: r.MenuID, weekday: weekday})
+		meal.Meals++
+		if r.RecentRepeat {
+			meal.Repeats++
+		}
+
+		if len(r.Items) == 0 {
+			meal.Dishes++
+			continue
+		}
+		for _, item := range r.Items {
+			get(aggregateKey{month: month, menuID: item.MenuID, weekday: weekday}).Dishes++
+		}
+	}
+
+	for i := range aggregates {
+		aggregates[i].UserID = records[0].UserID
+	}
+	return aggregates
+}
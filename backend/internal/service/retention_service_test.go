@@ -0,0 +1,94 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"what-to-eat/internal/model"
+)
+
+func TestRetentionCutoff(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	got := retentionCutoff(now, 30, shanghai)
+	if want := time.Date(2024, 5, 1, 0, 0, 0, 0, shanghai); !got.Equal(want) {
+		t.Errorf("retentionCutoff(30 days) = %v, want %v", got, want)
+	}
+
+	// 2024-04-01 20:00 UTC 在上海已是 4 月 2 日，截止到 4 月 1 日 0 点（上海时间）
+	got = retentionCutoff(time.Date(2024, 4, 1, 20, 0, 0, 0, time.UTC), 0, shanghai)
+	if want := time.Date(2024, 3, 31, 16, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("retentionCutoff(0 days) = %v, want %v", got.UTC(), want)
+	}
+}
+
+func TestRollUpRecords(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []model.DecisionRecord{
+		// 2024-03-01 星期五（上海时间）
+		{UserID: 7, MenuID: 1, DecidedAt: time.Date(2024, 3, 1, 4, 0, 0, 0, time.UTC)},
+		{UserID: 7, MenuID: 1, DecidedAt: time.Date(2024, 3, 8, 4, 0, 0, 0, time.UTC), RecentRepeat: true},
+		// UTC 2 月 29 日晚上，上海已是 3 月 1 日星期五
+		{UserID: 7, MenuID: 2, DecidedAt: time.Date(2024, 2, 29, 17, 0, 0, 0, time.UTC)},
+		// 组合决策：主记录菜品为第一个菜品
+		{UserID: 7, MenuID: 1, Mode: model.DecideModeCombo, DecidedAt: time.Date(2024, 3, 15, 4, 0, 0, 0, time.UTC),
+			Items: []model.DecisionItem{{MenuID: 1}, {MenuID: 3}}},
+		{UserID: 7, MenuID: 2, DecidedAt: time.Date(2024, 4, 2, 4, 0, 0, 0, time.UTC)},
+	}
+
+	aggregates := rollUpRecords(records, shanghai)
+
+	type key struct {
+		month   string
+		menuID  int64
+		weekday int
+	}
+	got := make(map[key]model.DecisionAggregate)
+	for _, a := range aggregates {
+		if a.UserID != 7 {
+			t.Errorf("UserID = %d, want 7", a.UserID)
+		}
+		got[key{a.Month.Format("2006-01-02"), a.MenuID, a.Weekday}] = a
+	}
+
+	want := map[key][3]int64{ // meals, dishes, repeats
+		{"2024-03-01", 1, 5}: {3, 3, 1},
+		{"2024-03-01", 2, 5}: {1, 1, 0},
+		{"2024-03-01", 3, 5}: {0, 1, 0},
+		{"2024-04-01", 2, 2}: {1, 1, 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d aggregates, want %d: %+v", len(got), len(want), aggregates)
+	}
+	for k, w := range want {
+		a, ok := got[k]
+		if !ok {
+			t.Errorf("missing aggregate %+v", k)
+			continue
+		}
+		if a.Meals != w[0] || a.Dishes != w[1] || a.Repeats != w[2] {
+			t.Errorf("aggregate %+v = (meals %d, dishes %d, repeats %d), want %v", k, a.Meals, a.Dishes, a.Repeats, w)
+		}
+		if a.Month.Location() != time.UTC {
+			t.Errorf("Month should be stored as UTC midnight, got %v", a.Month)
+		}
+	}
+}
+
+func TestRetentionService_Disabled(t *testing.T) {
+	s := NewRetentionService(nil, nil, RetentionPolicy{})
+	if s.Enabled() {
+		t.Error("Enabled() = true, want false when KeepDays is 0")
+	}
+	report, err := s.Prune(time.Now(), false)
+	if err != nil || report.Records != 0 {
+		t.Errorf("Prune() = %+v, %v, want empty report", report, err)
+	}
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+	"what-to-eat/pkg/normalize"
+)
+
+// 搜索分页默认值
+const (
+	defaultSearchPageSize = 20
+)
+
+// 匹配方式
+const (
+	matchExact     = "exact"
+	matchPrefix    = "prefix"
+	matchSubstring = "substring"
+	matchPinyin    = "pinyin"
+	matchInitials  = "initials"
+	matchFuzzy     = "fuzzy"
+)
+
+type SearchService struct {
+	restaurantRepo *repository.RestaurantRepository
+	menuRepo       *repository.MenuRepository
+}
+
+func NewSearchService(restaurantRepo *repository.RestaurantRepository, menuRepo *repository.MenuRepository) *SearchService {
+	return &SearchService{
+		restaurantRepo: restaurantRepo,
+		menuRepo:       menuRepo,
+	}
+}
+
+// Search 搜索餐厅和菜品
+// 支持子串匹配、拼音全拼/首字母匹配以及容错的模糊匹配，结果按匹配度排序后分页；
+// 候选条目先在数据库中按名称和写入时生成的拼音键筛选，只对候选计算匹配度
+func (s *SearchService) Search(req *model.SearchRequest) (*model.SearchResponse, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultSearchPageSize
+	}
+
+	matcher := newSearchMatcher(req.Query)
+	fragments := matcher.fragments()
+	var results []model.SearchResult
+
+	if req.Type == "" || req.Type == "all" || req.Type == model.SearchTypeRestaurant {
+		restaurants, err := s.restaurantRepo.SearchByName(fragments)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range restaurants {
+			if matchType, score, ok := matcher.matchKeys(r.Name, r.NamePinyin, r.NameInitials); ok {
+				results = append(results, model.SearchResult{
+					Type:      model.SearchTypeRestaurant,
+					ID:        r.ID,
+					Name:      r.Name,
+					MatchType: matchType,
+					Score:     score,
+				})
+			}
+		}
+	}
+
+	if req.Type == "" || req.Type == "all" || req.Type == model.SearchTypeDish {
+		menus, err := s.menuRepo.SearchByName(fragments)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range menus {
+			if matchType, score, ok := matcher.matchKeys(m.DishName, m.DishPinyin, m.DishInitials); ok {
+				results = append(results, model.SearchResult{
+					Type:           model.SearchTypeDish,
+					ID:             m.ID,
+					Name:           m.DishName,
+					RestaurantID:   m.RestaurantID,
+					RestaurantName: m.Restaurant.Name,
+					MatchType:      matchType,
+					Score:          score,
+				})
+			}
+		}
+	}
+
+	rankSearchResults(results)
+
+	resp := &model.SearchResponse{
+		Items:    []model.SearchResult{},
+		Total:    int64(len(results)),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	start := (page - 1) * pageSize
+	if start < len(results) {
+		end := start + pageSize
+		if end > len(results) {
+			end = len(results)
+		}
+		resp.Items = results[start:end]
+	}
+	return resp, nil
+}
+
+// rankSearchResults 按分数降序排序，分数相同时名称更短的优先
+func rankSearchResults(results []model.SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		li, lj := utf8.RuneCountInString(results[i].Name), utf8.RuneCountInString(results[j].Name)
+		if li != lj {
+			return li < lj
+		}
+		return results[i].ID < results[j].ID
+	})
+}
+
+// ============================================================================
+// 匹配与打分
+// ============================================================================
+
+// searchMatcher 预处理后的搜索关键词
+type searchMatcher struct {
+	query       string // 规范化后的关键词（与数据库中的名称键使用相同的 normalize.Key）
+	queryPinyin string // 关键词的拼音（关键词含汉字时用于同音字容错）
+	asciiQuery  bool   // 关键词是否只包含字母数字（可以按拼音匹配）
+}
+
+func newSearchMatcher(query string) *searchMatcher {
+	q := normalize.Key(query)
+	m := &searchMatcher{
+		query:      q,
+		asciiQuery: isASCIIAlnum(q),
+	}
+	if containsHan(q) {
+		m.queryPinyin, _ = normalize.Pinyin(q)
+	}
+	return m
+}
+
+// fragments 返回候选条目的名称或拼音键中至少包含其一的片段（用于在数据库中预先筛选）
+// 允许 k 个错误时把关键词分成 k+1 段，每个错误最多影响一段，匹配的名称必然完整包含其中一段
+func (m *searchMatcher) fragments() []string {
+	if m.query == "" {
+		return nil
+	}
+	runes := []rune(m.query)
+	parts := maxTyposFor(len(runes)) + 1
+	fragments := make([]string, 0, parts+1)
+	for i := 0; i < parts; i++ {
+		fragments = append(fragments, string(runes[i*len(runes)/parts:(i+1)*len(runes)/parts]))
+	}
+	if m.queryPinyin != "" {
+		fragments = append(fragments, m.queryPinyin)
+	}
+	return fragments
+}
+
+// match 计算名称与关键词的匹配度，ok 为 false 表示不匹配
+func (m *searchMatcher) match(name string) (matchType string, score float64, ok bool) {
+	full, initials := normalize.Pinyin(normalize.Key(name))
+	return m.matchKeys(name, full, initials)
+}
+
+// matchKeys 与 match 相同，使用预先计算好的拼音全拼和首字母
+func (m *searchMatcher) matchKeys(name, full, initials string) (matchType string, score float64, ok bool) {
+	if m.query == "" {
+		return "", 0, false
+	}
+	key := normalize.Key(name)
+
+	// 1. 名称直接匹配
+	switch {
+	case key == m.query:
+		return matchExact, 100, true
+	case strings.HasPrefix(key, m.query):
+		return matchPrefix, 90, true
+	case strings.Contains(key, m.query):
+		return matchSubstring, 80, true
+	}
+
+	// 2. 拼音全拼（字母关键词，或与汉字关键词同音）
+	if m.asciiQuery {
+		switch {
+		case strings.HasPrefix(full, m.query):
+			return matchPinyin, 75, true
+		case strings.Contains(full, m.query):
+			return matchPinyin, 70, true
+		}
+	} else if m.queryPinyin != "" && strings.Contains(full, m.queryPinyin) {
+		return matchPinyin, 70, true
+	}
+
+	// 3. 拼音首字母
+	if m.asciiQuery {
+		switch {
+		case strings.HasPrefix(initials, m.query):
+			return matchInitials, 65, true
+		case strings.Contains(initials, m.query):
+			return matchInitials, 60, true
+		}
+	}
+
+	// 4. 模糊匹配（容忍少量错别字）
+	queryRunes := []rune(m.query)
+	if maxTypos := maxTyposFor(len(queryRunes)); maxTypos > 0 {
+		if d := fuzzySubstringDistance(queryRunes, []rune(key)); d <= maxTypos {
+			return matchFuzzy, 50 - 10*float64(d), true
+		}
+		if m.asciiQuery {
+			if d := fuzzySubstringDistance(queryRunes, []rune(full)); d <= maxTypos {
+				return matchFuzzy, 45 - 10*float64(d), true
+			}
+		}
+	}
+
+	return "", 0, false
+}
+
+// maxTyposFor 根据关键词长度决定允许的错误字符数
+func maxTyposFor(length int) int {
+	switch {
+	case length <= 2:
+		return 0
+	case length <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// fuzzySubstringDistance 计算 pattern 与 text 中任意子串的最小编辑距离
+func fuzzySubstringDistance(pattern, text []rune) int {
+	if len(pattern) == 0 {
+		return 0
+	}
+	// prev[j] 表示 pattern 前 i 个字符与以 text[j-1] 结尾的子串的最小编辑距离
+	prev := make([]int, len(text)+1)
+	curr := make([]int, len(text)+1)
+	for i := 1; i <= len(pattern); i++ {
+		curr[0] = i
+		for j := 1; j <= len(text); j++ {
+			cost := 1
+			if pattern[i-1] == text[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j-1]+cost, prev[j]+1, curr[j-1]+1)
+		}
+		prev, curr = curr, prev
+	}
+
+	best := prev[0]
+	for _, d := range prev[1:] {
+		best = min(best, d)
+	}
+	return best
+}
+
+// containsHan 判断字符串是否包含汉字
+func containsHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// isASCIIAlnum 判断字符串是否只包含 ASCII 字母和数字
+func isASCIIAlnum(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/pkg/normalize"
+)
+
+func TestFuzzySubstringDistance(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		want    int
+	}{
+		{pattern: "巨无霸", text: "巨无霸套餐", want: 0},
+		{pattern: "巨无八", text: "巨无霸套餐", want: 1},
+		{pattern: "maidanglao", text: "maidanglao", want: 0},
+		{pattern: "maidangloa", text: "maidanglao", want: 1},
+		{pattern: "abc", text: "", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.text, func(t *testing.T) {
+			if got := fuzzySubstringDistance([]rune(tt.pattern), []rune(tt.text)); got != tt.want {
+				t.Errorf("fuzzySubstringDistance(%q, %q) = %d, want %d", tt.pattern, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchMatcher_Match(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		target    string
+		wantType  string
+		wantMatch bool
+	}{
+		{name: "exact", query: "麦当劳", target: "麦当劳", wantType: matchExact, wantMatch: true},
+		{name: "prefix", query: "麦当", target: "麦当劳", wantType: matchPrefix, wantMatch: true},
+		{name: "substring", query: "鸡腿", target: "麦辣鸡腿堡", wantType: matchSubstring, wantMatch: true},
+		{name: "case insensitive", query: "kfc", target: "KFC", wantType: matchExact, wantMatch: true},
+		{name: "full width", query: "ＫＦＣ", target: "KFC", wantType: matchExact, wantMatch: true},
+		{name: "full width pinyin", query: "ｍａｉｄａｎｇｌａｏ", target: "麦当劳", wantType: matchPinyin, wantMatch: true},
+		{name: "pinyin full", query: "maidanglao", target: "麦当劳", wantType: matchPinyin, wantMatch: true},
+		{name: "pinyin homophone", query: "卖当劳", target: "麦当劳", wantType: matchPinyin, wantMatch: true},
+		{name: "pinyin initials", query: "mdl", target: "麦当劳", wantType: matchInitials, wantMatch: true},
+		{name: "typo in chinese", query: "黄焖几米饭", target: "黄焖鸡米饭", wantType: matchPinyin, wantMatch: true},
+		{name: "typo in pinyin", query: "maidangla", target: "麦当劳", wantType: matchPinyin, wantMatch: true},
+		{name: "typo in pinyin middle", query: "maidenglao", target: "麦当劳", wantType: matchFuzzy, wantMatch: true},
+		{name: "no match", query: "pizza", target: "麦当劳", wantMatch: false},
+		{name: "short query no fuzzy", query: "xy", target: "麦当劳", wantMatch: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matchType, _, ok := newSearchMatcher(tt.query).match(tt.target)
+			if ok != tt.wantMatch {
+				t.Fatalf("match(%q, %q) ok = %v, want %v", tt.query, tt.target, ok, tt.wantMatch)
+			}
+			if ok && matchType != tt.wantType {
+				t.Errorf("match(%q, %q) type = %q, want %q", tt.query, tt.target, matchType, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestSearchMatcher_Fragments(t *testing.T) {
+	// 能匹配的名称必须在数据库预筛选时被选中：名称键或拼音键至少包含一个片段
+	tests := []struct {
+		query  string
+		target string
+	}{
+		{query: "麦当劳", target: "麦当劳"},
+		{query: "kfc", target: "KFC"},
+		{query: "ｋｆｃ", target: "KFC"},
+		{query: "ＫＦＣ", target: "KFC"},
+		{query: "ｍａｉｄａｎｇｌａｏ", target: "麦当劳"},
+		{query: "maidanglao", target: "麦当劳"},
+		{query: "卖当劳", target: "麦当劳"},
+		{query: "mdl", target: "麦当劳"},
+		{query: "黄焖几米饭", target: "黄焖鸡米饭"},
+		{query: "maidenglao", target: "麦当劳"},
+		{query: "巨无八", target: "巨无霸套餐"},
+		{query: "pizzahtu", target: "Pizza Hut"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			m := newSearchMatcher(tt.query)
+			if _, _, ok := m.match(tt.target); !ok {
+				t.Fatalf("match(%q, %q) should match", tt.query, tt.target)
+			}
+			key := normalize.Key(tt.target)
+			full, initials := normalize.Pinyin(key)
+			found := false
+			for _, f := range m.fragments() {
+				if strings.Contains(key, f) || strings.Contains(full, f) || strings.Contains(initials, f) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("fragments %q do not select %q", m.fragments(), tt.target)
+			}
+		})
+	}
+
+	if got := newSearchMatcher("  ").fragments(); len(got) != 0 {
+		t.Errorf("fragments for empty query = %q, want none", got)
+	}
+}
+
+func TestRankSearchResults(t *testing.T) {
+	results := []model.SearchResult{
+		{ID: 1, Name: "麦辣鸡腿堡", Score: 80},
+		{ID: 2, Name: "麦当劳", Score: 100},
+		{ID: 3, Name: "鸡腿", Score: 80},
+		{ID: 4, Name: "鸡块", Score: 80},
+	}
+
+	rankSearchResults(results)
+
+	wantOrder := []int64{2, 3, 4, 1}
+	for i, id := range wantOrder {
+		if results[i].ID != id {
+			t.Errorf("results[%d].ID = %d, want %d", i, results[i].ID, id)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"what-to-eat/internal/model"
+)
+
+// seedPacks 内置的种子数据包（seeds 目录下的 YAML 文件，文件名即数据包名称）
+//
+//go:embed seeds/*.yaml
+var seedPacks embed.FS
+
+// DefaultSeedPack 默认种子数据包
+const DefaultSeedPack = "default"
+
+var ErrUnknownSeedPack = errors.New("未知的种子数据包")
+
+// SeedPacks 返回全部内置种子数据包名称（按名称排序）
+func SeedPacks() []string {
+	entries, _ := seedPacks.ReadDir("seeds")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SeedSource 种子数据来源：File 不为空时从文件读取，否则使用内置数据包 Pack
+type SeedSource struct {
+	Pack string
+	File string
+}
+
+// open 打开种子数据，返回数据格式和内容
+func (src SeedSource) open() (DatasetFormat, io.ReadCloser, error) {
+	if src.File != "" {
+		format, err := DetectDatasetFormat(src.File)
+		if err != nil {
+			return "", nil, err
+		}
+		f, err := os.Open(src.File)
+		if err != nil {
+			return "", nil, err
+		}
+		return format, f, nil
+	}
+
+	pack := src.Pack
+	if pack == "" {
+		pack = DefaultSeedPack
+	}
+	f, err := seedPacks.Open("seeds/" + pack + ".yaml")
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %s（可选: %s）", ErrUnknownSeedPack, pack, strings.Join(SeedPacks(), ", "))
+	}
+	return FormatYAML, f, nil
+}
+
+// Seed 导入种子数据
+// 复用批量导入的去重规则，已存在的餐厅和菜品会被跳过，重复执行不会产生重复数据。
+func (s *MenuService) Seed(src SeedSource, dryRun bool) (*model.ImportReport, error) {
+	format, r, err := src.open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return s.Import(format, r, dryRun)
+}
+
+// SeedIfEmpty 仅当还没有任何菜品时导入种子数据（服务首次启动时使用），已有数据时返回 nil
+func (s *MenuService) SeedIfEmpty(src SeedSource) (*model.ImportReport, error) {
+	count, err := s.menuRepo.Count()
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, nil
+	}
+	return s.Seed(src, false)
+}
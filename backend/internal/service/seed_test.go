@@ -0,0 +1,61 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"what-to-eat/internal/model"
+)
+
+func TestSeedPacks(t *testing.T) {
+	packs := SeedPacks()
+	want := map[string]bool{DefaultSeedPack: false, "chinese-fast-food": false, "western": false}
+	for _, p := range packs {
+		if _, ok := want[p]; ok {
+			want[p] = true
+		}
+	}
+	for name, found := range want {
+		if !found {
+			t.Errorf("SeedPacks() = %v, missing %q", packs, name)
+		}
+	}
+}
+
+// 内置数据包必须能被导入流程完整接受
+func TestSeedPacks_Valid(t *testing.T) {
+	for _, pack := range SeedPacks() {
+		t.Run(pack, func(t *testing.T) {
+			format, r, err := SeedSource{Pack: pack}.open()
+			if err != nil {
+				t.Fatalf("open() error = %v", err)
+			}
+			defer r.Close()
+
+			rows, err := DecodeImportRows(format, r)
+			if err != nil {
+				t.Fatalf("DecodeImportRows() error = %v", err)
+			}
+			if len(rows) == 0 {
+				t.Fatal("seed pack is empty")
+			}
+			for _, row := range rows {
+				if msg := validateImportRow(row.RestaurantName, row.DishName, row.Nutrition); msg != "" {
+					t.Errorf("row %d: %s", row.Line, msg)
+				}
+				if !model.IsValidCuisine(row.Cuisine) {
+					t.Errorf("row %d: unknown cuisine %q", row.Line, row.Cuisine)
+				}
+				if !model.IsValidMenuRole(row.Role) {
+					t.Errorf("row %d: unknown role %q", row.Line, row.Role)
+				}
+			}
+		})
+	}
+}
+
+func TestSeedSource_UnknownPack(t *testing.T) {
+	if _, _, err := (SeedSource{Pack: "nope"}).open(); !errors.Is(err, ErrUnknownSeedPack) {
+		t.Errorf("open() error = %v, want %v", err, ErrUnknownSeedPack)
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// sessionCacheTTL 会话状态的缓存时间
+// 本进程内退出登录会立即更新缓存；多实例部署时其他实例最多延迟这么久才拒绝已退出的会话
+const sessionCacheTTL = 30 * time.Second
+
+// sessionCacheSweepSize 缓存条目超过这个数量时清理过期条目
+const sessionCacheSweepSize = 10000
+
+type sessionCacheEntry struct {
+	userID    int64
+	active    bool
+	expiresAt time.Time
+}
+
+// sessionCache 会话是否有效的内存缓存，避免每个请求都查询数据库
+type sessionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]sessionCacheEntry
+}
+
+func newSessionCache(ttl time.Duration) *sessionCache {
+	return &sessionCache{ttl: ttl, entries: make(map[string]sessionCacheEntry)}
+}
+
+// get 返回缓存的会话状态，ok 为 false 表示没有缓存或已过期
+func (c *sessionCache) get(sessionID string, userID int64, now time.Time) (active, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, found := c.entries[sessionID]
+	if !found || entry.userID != userID || !now.Before(entry.expiresAt) {
+		return false, false
+	}
+	return entry.active, true
+}
+
+// set 缓存会话状态
+func (c *sessionCache) set(sessionID string, userID int64, active bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= sessionCacheSweepSize {
+		for id, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+	}
+	c.entries[sessionID] = sessionCacheEntry{userID: userID, active: active, expiresAt: now.Add(c.ttl)}
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionCache(t *testing.T) {
+	cache := newSessionCache(time.Minute)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, ok := cache.get("s1", 1, now); ok {
+		t.Fatal("empty cache should miss")
+	}
+
+	cache.set("s1", 1, true, now)
+	if active, ok := cache.get("s1", 1, now.Add(30*time.Second)); !ok || !active {
+		t.Errorf("get = %v, %v, want true, true", active, ok)
+	}
+	// 会话属于其他用户时不使用缓存
+	if _, ok := cache.get("s1", 2, now); ok {
+		t.Error("cache should miss for a different user")
+	}
+	// 过期后重新查询
+	if _, ok := cache.get("s1", 1, now.Add(time.Minute)); ok {
+		t.Error("cache should miss after ttl")
+	}
+
+	// 退出登录后立即覆盖为无效
+	cache.set("s1", 1, false, now)
+	if active, ok := cache.get("s1", 1, now); !ok || active {
+		t.Errorf("get after logout = %v, %v, want false, true", active, ok)
+	}
+}
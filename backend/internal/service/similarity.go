@@ -0,0 +1,53 @@
+package service
+
+import (
+	"strings"
+
+	"what-to-eat/pkg/normalize"
+)
+
+// levenshtein 计算两个字符序列的编辑距离
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j-1]+cost, prev[j]+1, curr[j-1]+1)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// isNearDuplicate 判断两个规范化键是否疑似重复（调用方需先排除完全相同的键）
+// 只有空白不同的名称视为重复；中文名称按拼音比较（同音字或拼音只差一个字母，如 麦当劳/麦当老）；
+// 其他名称按编辑距离比较，允许的差异随长度增加。
+func isNearDuplicate(a, b string) bool {
+	a, b = strings.ReplaceAll(a, " ", ""), strings.ReplaceAll(b, " ", "")
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b {
+		return true
+	}
+
+	if containsHan(a) || containsHan(b) {
+		pa, _ := normalize.Pinyin(a)
+		pb, _ := normalize.Pinyin(b)
+		if pa == pb {
+			return true
+		}
+		return min(len(pa), len(pb)) >= 6 && levenshtein([]rune(pa), []rune(pb)) <= 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	return levenshtein(ra, rb) <= maxTyposFor(max(len(ra), len(rb)))
+}
@@ -0,0 +1,48 @@
+package service
+
+import "testing"
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "abc", want: 3},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "麦当劳", b: "麦当老", want: 1},
+		{a: "same", b: "same", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+				t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsNearDuplicate(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{name: "homophone", a: "麦当劳", b: "麦当老", want: true},
+		{name: "pinyin one letter off", a: "黄焖鸡米饭", b: "黄焖鸡米范", want: true},
+		{name: "only spaces differ", a: "pizza hut", b: "pizzahut", want: true},
+		{name: "latin typo", a: "mcdonalds", b: "mcdonald", want: true},
+		{name: "different chinese dishes", a: "拌面", b: "炒面", want: false},
+		{name: "different restaurants", a: "麦当劳", b: "肯德基", want: false},
+		{name: "short latin words", a: "kfc", b: "bk", want: false},
+		{name: "empty", a: "", b: "麦当劳", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNearDuplicate(tt.a, tt.b); got != tt.want {
+				t.Errorf("isNearDuplicate(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
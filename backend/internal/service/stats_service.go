@@ -0,0 +1,112 @@
+package service
+
+import (
+	"fmt"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+)
+
+// 统计的默认数量
+const (
+	defaultStatsTop   = 5
+	defaultStatsWeeks = 8
+)
+
+// StatsService 个人统计
+type StatsService struct {
+	statsRepo *repository.StatsRepository
+	timeZones *TimeZoneService
+}
+
+func NewStatsService(statsRepo *repository.StatsRepository, timeZones *TimeZoneService) *StatsService {
+	return &StatsService{statsRepo: statsRepo, timeZones: timeZones}
+}
+
+// GetStats 获取用户的个人统计（按星期和周分组时使用用户时区）
+func (s *StatsService) GetStats(userID int64, req *model.StatsRequest) (*model.StatsResponse, error) {
+	loc, err := s.timeZones.Location(userID)
+	if err != nil {
+		return nil, err
+	}
+	top := req.Top
+	if top == 0 {
+		top = defaultStatsTop
+	}
+	weeks := req.Weeks
+	if weeks == 0 {
+		weeks = defaultStatsWeeks
+	}
+	scope := repository.StatsScope{UserIDs: []int64{userID}, Location: loc}
+
+	totals, err := s.statsRepo.CountMeals(scope)
+	if err != nil {
+		return nil, err
+	}
+	resp := &model.StatsResponse{
+		TotalMeals:    totals.Meals,
+		RecentRepeats: totals.Repeats,
+	}
+	if totals.Meals > 0 {
+		resp.RecentRepeatRate = float64(totals.Repeats) / float64(totals.Meals)
+	}
+
+	if resp.TopDishes, err = s.statsRepo.TopDishes(scope, top); err != nil {
+		return nil, err
+	}
+	if resp.TopRestaurants, err = s.statsRepo.TopRestaurants(scope, top); err != nil {
+		return nil, err
+	}
+
+	weekdays, err := s.statsRepo.CountByWeekday(scope)
+	if err != nil {
+		return nil, err
+	}
+	resp.Weekdays = fillWeekdays(weekdays)
+
+	variety, err := s.statsRepo.VarietyByWeek(scope, weeks)
+	if err != nil {
+		return nil, err
+	}
+	resp.Variety = toVarietyWeeks(variety)
+
+	streaks, err := s.statsRepo.RestaurantStreaks(scope)
+	if err != nil {
+		return nil, err
+	}
+	resp.LongestStreak, resp.CurrentStreak = streaks.Longest, streaks.Current
+
+	return resp, nil
+}
+
+// fillWeekdays 补齐没有记录的星期，始终返回星期日到星期六 7 项
+func fillWeekdays(counts []model.WeekdayCount) []model.WeekdayCount {
+	days := make([]model.WeekdayCount, 7)
+	for i := range days {
+		days[i].Weekday = i
+	}
+	for _, c := range counts {
+		if c.Weekday >= 0 && c.Weekday < 7 {
+			days[c.Weekday].Count = c.Count
+		}
+	}
+	return days
+}
+
+// toVarietyWeeks 计算每周的多样性得分
+func toVarietyWeeks(weeks []repository.WeekVariety) []model.VarietyWeek {
+	result := make([]model.VarietyWeek, 0, len(weeks))
+	for _, w := range weeks {
+		v := model.VarietyWeek{
+			Week:        fmt.Sprintf("%d-W%02d", w.YearWeek/100, w.YearWeek%100),
+			Meals:       w.Meals,
+			Dishes:      w.Dishes,
+			Restaurants: w.Restaurants,
+		}
+		if w.Meals > 0 {
+			v.Score = float64(w.Restaurants) / float64(w.Meals)
+		}
+		result = append(result, v)
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+//go:build mysql
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+)
+
+func TestStatsService_GetStats_IgnoresFuturePlans(t *testing.T) {
+	db := openTestDB(t)
+
+	user := model.User{Username: "alice", PasswordHash: "x"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	records := []struct {
+		restaurant string
+		origin     string
+		decidedAt  time.Time
+	}{
+		{"餐厅甲", model.DecisionOriginSpin, now.AddDate(0, 0, -2)},
+		{"餐厅乙", model.DecisionOriginSpin, now.AddDate(0, 0, -1)},
+		{"餐厅丙", model.DecisionOriginPlan, now.AddDate(0, 0, 7)}, // 下周的计划
+	}
+	for _, rec := range records {
+		restaurant := model.Restaurant{Name: rec.restaurant}
+		if err := db.Create(&restaurant).Error; err != nil {
+			t.Fatal(err)
+		}
+		menu := model.Menu{RestaurantID: restaurant.ID, DishName: "招牌菜"}
+		if err := db.Create(&menu).Error; err != nil {
+			t.Fatal(err)
+		}
+		record := model.DecisionRecord{UserID: user.ID, MenuID: menu.ID, DecidedAt: rec.decidedAt, Origin: rec.origin}
+		if err := db.Create(&record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	svc := NewStatsService(repository.NewStatsRepository(db), NewTimeZoneService(userRepo, time.UTC))
+	stats, err := svc.GetStats(user.ID, &model.StatsRequest{})
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if stats.TotalMeals != 2 {
+		t.Errorf("TotalMeals = %d, want 2 (future plan excluded)", stats.TotalMeals)
+	}
+	if len(stats.TopRestaurants) != 2 {
+		t.Errorf("TopRestaurants = %+v, want 2 restaurants", stats.TopRestaurants)
+	}
+	if stats.CurrentStreak != 2 || stats.LongestStreak != 2 {
+		t.Errorf("streaks = current %d longest %d, want 2 and 2", stats.CurrentStreak, stats.LongestStreak)
+	}
+}
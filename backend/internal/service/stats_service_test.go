@@ -0,0 +1,37 @@
+package service
+
+import (
+	"testing"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+)
+
+func TestFillWeekdays(t *testing.T) {
+	days := fillWeekdays([]model.WeekdayCount{{Weekday: 1, Count: 3}, {Weekday: 5, Count: 2}})
+	if len(days) != 7 {
+		t.Fatalf("len = %d, want 7", len(days))
+	}
+	for i, d := range days {
+		want := int64(0)
+		switch i {
+		case 1:
+			want = 3
+		case 5:
+			want = 2
+		}
+		if d.Weekday != i || d.Count != want {
+			t.Errorf("days[%d] = %+v, want weekday %d count %d", i, d, i, want)
+		}
+	}
+}
+
+func TestToVarietyWeeks(t *testing.T) {
+	weeks := toVarietyWeeks([]repository.WeekVariety{{YearWeek: 202409, Meals: 5, Dishes: 4, Restaurants: 2}})
+	if len(weeks) != 1 {
+		t.Fatalf("len = %d, want 1", len(weeks))
+	}
+	if w := weeks[0]; w.Week != "2024-W09" || w.Score != 0.4 {
+		t.Errorf("week = %+v, want 2024-W09 with score 0.4", w)
+	}
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"time"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+)
+
+var (
+	ErrTeamNotFound      = errors.New("团队不存在")
+	ErrNotTeamMember     = errors.New("不是该团队的成员")
+	ErrInvalidInviteCode = errors.New("邀请码无效")
+	ErrInvalidMonth      = errors.New("月份格式无效，应为 2006-01")
+)
+
+// 团队统计的月份格式
+const teamMonthLayout = "2006-01"
+
+// TeamService 团队和团队统计
+type TeamService struct {
+	teamRepo  *repository.TeamRepository
+	statsRepo *repository.StatsRepository
+	timeZones *TimeZoneService
+}
+
+func NewTeamService(teamRepo *repository.TeamRepository, statsRepo *repository.StatsRepository, timeZones *TimeZoneService) *TeamService {
+	return &TeamService{teamRepo: teamRepo, statsRepo: statsRepo, timeZones: timeZones}
+}
+
+// Create 创建团队（创建者自动成为成员）
+func (s *TeamService) Create(userID int64, req *model.CreateTeamRequest) (*model.Team, error) {
+	code, err := newInviteCode()
+	if err != nil {
+		return nil, err
+	}
+	team := &model.Team{Name: req.Name, InviteCode: code, OwnerID: userID}
+	if err := s.teamRepo.Create(team); err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// List 获取用户加入的团队
+func (s *TeamService) List(userID int64) ([]model.Team, error) {
+	return s.teamRepo.GetByUserID(userID)
+}
+
+// Join 凭邀请码加入团队
+func (s *TeamService) Join(userID int64, inviteCode string) (*model.Team, error) {
+	team, err := s.teamRepo.GetByInviteCode(inviteCode)
+	if err != nil {
+		return nil, notFoundOr(err, ErrInvalidInviteCode)
+	}
+	if err := s.teamRepo.AddMember(team.ID, userID); err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// Leave 退出团队
+func (s *TeamService) Leave(userID, teamID int64) error {
+	return s.teamRepo.RemoveMember(teamID, userID)
+}
+
+// Stats 团队统计：排行榜、本月最常去的餐厅和按天的热力图（只有成员可以查看）
+// 月份和日期按查看者的时区计算，同一团队的成员在不同时区时各自看到的边界可能不同
+func (s *TeamService) Stats(userID, teamID int64, req *model.TeamStatsRequest) (*model.TeamStatsResponse, error) {
+	team, err := s.teamRepo.GetByID(teamID)
+	if err != nil {
+		return nil, notFoundOr(err, ErrTeamNotFound)
+	}
+	memberIDs, err := s.teamRepo.GetMemberIDs(teamID)
+	if err != nil {
+		return nil, err
+	}
+	if !containsID(memberIDs, userID) {
+		return nil, ErrNotTeamMember
+	}
+
+	loc, err := s.timeZones.Location(userID)
+	if err != nil {
+		return nil, err
+	}
+	from, err := parseTeamMonth(req.Month, time.Now().In(loc))
+	if err != nil {
+		return nil, err
+	}
+	to := from.AddDate(0, 1, 0)
+	scope := repository.StatsScope{UserIDs: memberIDs, From: &from, To: &to, Location: loc}
+
+	top := req.Top
+	if top == 0 {
+		top = defaultStatsTop
+	}
+
+	resp := &model.TeamStatsResponse{
+		Team:    *team,
+		Month:   from.Format(teamMonthLayout),
+		Members: len(memberIDs),
+	}
+
+	totals, err := s.statsRepo.CountMeals(scope)
+	if err != nil {
+		return nil, err
+	}
+	resp.TotalMeals = totals.Meals
+
+	dishTotals, err := s.statsRepo.DishTotalsByUser(scope)
+	if err != nil {
+		return nil, err
+	}
+	resp.MostAdventurous, resp.MostLoyal = buildLeaderboards(dishTotals, top)
+
+	if resp.TopRestaurants, err = s.statsRepo.TopRestaurants(scope, top); err != nil {
+		return nil, err
+	}
+	if len(resp.TopRestaurants) > 0 {
+		resp.FavoriteRestaurant = &resp.TopRestaurants[0]
+	}
+
+	cells, err := s.statsRepo.CountByDayAndRestaurant(scope)
+	if err != nil {
+		return nil, err
+	}
+	resp.Heatmap = make([]model.HeatmapCell, 0, len(cells))
+	for _, c := range cells {
+		resp.Heatmap = append(resp.Heatmap, model.HeatmapCell{
+			Date:           c.Day.Format(summaryDateLayout),
+			RestaurantID:   c.RestaurantID,
+			RestaurantName: c.RestaurantName,
+			Count:          c.Count,
+		})
+	}
+
+	return resp, nil
+}
+
+// buildLeaderboards 生成排行榜：不同菜品最多（最爱尝鲜）和重复次数最多（最专一）
+func buildLeaderboards(totals []repository.UserDishTotals, top int) (adventurous, loyal []model.LeaderboardEntry) {
+	adventurous = make([]model.LeaderboardEntry, 0, len(totals))
+	loyal = make([]model.LeaderboardEntry, 0, len(totals))
+	for _, t := range totals {
+		adventurous = append(adventurous, model.LeaderboardEntry{UserID: t.UserID, Username: t.Username, Value: t.Unique})
+		if repeats := t.Dishes - t.Unique; repeats > 0 {
+			loyal = append(loyal, model.LeaderboardEntry{UserID: t.UserID, Username: t.Username, Value: repeats})
+		}
+	}
+	return rankLeaderboard(adventurous, top), rankLeaderboard(loyal, top)
+}
+
+// rankLeaderboard 按数值倒序排列（相同时按用户ID），只保留前 top 名
+func rankLeaderboard(entries []model.LeaderboardEntry, top int) []model.LeaderboardEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value > entries[j].Value
+		}
+		return entries[i].UserID < entries[j].UserID
+	})
+	if len(entries) > top {
+		entries = entries[:top]
+	}
+	return entries
+}
+
+// parseTeamMonth 解析统计月份，返回该月第一天，默认本月
+func parseTeamMonth(month string, now time.Time) (time.Time, error) {
+	if month == "" {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), nil
+	}
+	t, err := time.ParseInLocation(teamMonthLayout, month, now.Location())
+	if err != nil {
+		return time.Time{}, ErrInvalidMonth
+	}
+	return t, nil
+}
+
+// containsID 判断ID列表中是否包含 id
+func containsID(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// newInviteCode 生成随机邀请码
+func newInviteCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"what-to-eat/internal/repository"
+)
+
+func TestBuildLeaderboards(t *testing.T) {
+	totals := []repository.UserDishTotals{
+		{UserID: 1, Username: "alice", Dishes: 10, Unique: 3},
+		{UserID: 2, Username: "bob", Dishes: 6, Unique: 6},
+		{UserID: 3, Username: "carol", Dishes: 8, Unique: 5},
+	}
+
+	adventurous, loyal := buildLeaderboards(totals, 2)
+
+	if len(adventurous) != 2 || adventurous[0].UserID != 2 || adventurous[1].UserID != 3 {
+		t.Errorf("adventurous = %+v, want bob then carol", adventurous)
+	}
+	// bob 没有重复，不进入最专一排行榜
+	if len(loyal) != 2 || loyal[0].UserID != 1 || loyal[0].Value != 7 || loyal[1].UserID != 3 {
+		t.Errorf("loyal = %+v, want alice (7) then carol (3)", loyal)
+	}
+}
+
+func TestParseTeamMonth(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	got, err := parseTeamMonth("", now)
+	if err != nil || !got.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseTeamMonth(\"\") = %v, %v, want 2024-03-01", got, err)
+	}
+
+	got, err = parseTeamMonth("2023-12", now)
+	if err != nil || !got.Equal(time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseTeamMonth(2023-12) = %v, %v, want 2023-12-01", got, err)
+	}
+
+	if _, err := parseTeamMonth("2023-13", now); !errors.Is(err, ErrInvalidMonth) {
+		t.Errorf("parseTeamMonth(2023-13) error = %v, want %v", err, ErrInvalidMonth)
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"time"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+	"what-to-eat/pkg/timezone"
+)
+
+// TimeZoneService 用户时区：所有按天、按月的日期边界都按用户时区计算
+type TimeZoneService struct {
+	userRepo *repository.UserRepository
+	fallback *time.Location // 用户未设置时区时使用的默认时区
+}
+
+func NewTimeZoneService(userRepo *repository.UserRepository, fallback *time.Location) *TimeZoneService {
+	return &TimeZoneService{userRepo: userRepo, fallback: fallback}
+}
+
+// Location 获取用户的时区（未设置或已失效时使用默认时区）
+func (s *TimeZoneService) Location(userID int64) (*time.Location, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, notFoundOr(err, ErrUserNotFound)
+	}
+	return s.resolve(user.TimeZone), nil
+}
+
+// Get 获取用户的时区设置
+func (s *TimeZoneService) Get(userID int64) (*model.TimeZoneResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, notFoundOr(err, ErrUserNotFound)
+	}
+	return s.response(user.TimeZone), nil
+}
+
+// Update 设置用户的时区，空字符串表示恢复为默认时区
+func (s *TimeZoneService) Update(userID int64, name string) (*model.TimeZoneResponse, error) {
+	if name != "" {
+		if _, err := timezone.Load(name); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.userRepo.UpdateTimeZone(userID, name); err != nil {
+		return nil, err
+	}
+	return s.response(name), nil
+}
+
+// resolve 将用户保存的时区名称转换为时区
+func (s *TimeZoneService) resolve(name string) *time.Location {
+	if name == "" {
+		return s.fallback
+	}
+	loc, err := timezone.Load(name)
+	if err != nil {
+		return s.fallback
+	}
+	return loc
+}
+
+// response 生成时区设置的响应（同时返回实际生效的时区和当前的 UTC 偏移）
+func (s *TimeZoneService) response(name string) *model.TimeZoneResponse {
+	loc := s.resolve(name)
+	return &model.TimeZoneResponse{
+		TimeZone:  name,
+		Effective: loc.String(),
+		Offset:    timezone.Offset(time.Now(), loc),
+	}
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeZoneService_Resolve(t *testing.T) {
+	fallback, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewTimeZoneService(nil, fallback)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "", want: "Asia/Shanghai"},
+		{name: "America/New_York", want: "America/New_York"},
+		{name: "Not/AZone", want: "Asia/Shanghai"},
+	}
+
+	for _, tt := range tests {
+		if got := s.resolve(tt.name); got.String() != tt.want {
+			t.Errorf("resolve(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	resp := s.response("")
+	if resp.TimeZone != "" || resp.Effective != "Asia/Shanghai" || resp.Offset != "+08:00" {
+		t.Errorf("response(\"\") = %+v", resp)
+	}
+}
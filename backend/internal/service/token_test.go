@@ -0,0 +1,29 @@
+package service
+
+import "testing"
+
+func TestHashToken(t *testing.T) {
+	if hashToken("a") == hashToken("b") {
+		t.Error("different tokens should have different hashes")
+	}
+	if got := len(hashToken("token")); got != 64 {
+		t.Errorf("len(hashToken) = %d, want 64", got)
+	}
+}
+
+func TestNewSecretToken(t *testing.T) {
+	a, err := newSecretToken(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newSecretToken(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Error("tokens should be random")
+	}
+	if len(a) != 43 {
+		t.Errorf("len(token) = %d, want 43", len(a))
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+
+	"what-to-eat/internal/model"
+	"what-to-eat/internal/repository"
+)
+
+var (
+	ErrChangeOwnRole = errors.New("不能修改自己的角色")
+	ErrGuestRole     = errors.New("游客需要先升级为注册用户")
+)
+
+const defaultUserPageSize = 20
+
+// 审计操作类型
+const AuditActionPasswordReset = "password_reset"
+
+// UserService 用户管理（管理员操作）
+type UserService struct {
+	userRepo    *repository.UserRepository
+	authService *AuthService
+	auditRepo   *repository.AuditRepository
+}
+
+func NewUserService(
+	userRepo *repository.UserRepository,
+	authService *AuthService,
+	auditRepo *repository.AuditRepository,
+) *UserService {
+	return &UserService{userRepo: userRepo, authService: authService, auditRepo: auditRepo}
+}
+
+// List 分页查询用户
+func (s *UserService) List(req *model.UserListRequest) (*model.UserListResponse, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultUserPageSize
+	}
+
+	users, total, err := s.userRepo.List(req.Role, req.Query, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &model.UserListResponse{Users: users, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// UpdateRole 设置用户角色（管理员或成员）
+// 角色记录在访问令牌中，修改后结束该用户的全部会话，重新登录后按新角色签发令牌
+func (s *UserService) UpdateRole(actorID, userID int64, role string) (*model.User, error) {
+	if actorID == userID {
+		return nil, ErrChangeOwnRole
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, notFoundOr(err, ErrUserNotFound)
+	}
+	if user.IsGuest() {
+		return nil, ErrGuestRole
+	}
+	if user.Role == role {
+		return user, nil
+	}
+
+	if err := s.userRepo.UpdateRole(user.ID, role); err != nil {
+		return nil, err
+	}
+	if _, err := s.authService.LogoutAll(user.ID); err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return user, nil
+}
+
+// RevokeSessions 结束用户在所有设备上的登录会话，返回结束的会话数
+func (s *UserService) RevokeSessions(userID int64) (int, error) {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return 0, notFoundOr(err, ErrUserNotFound)
+	}
+	return s.authService.LogoutAll(userID)
+}
+
+// CreatePasswordReset 管理员为用户签发一次性密码重置令牌并记录审计日志
+func (s *UserService) CreatePasswordReset(actorID, userID int64) (*model.PasswordResetResponse, error) {
+	token, expires, err := s.authService.CreatePasswordReset(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeAudit(s.auditRepo, actorID, AuditActionPasswordReset, "user", userID, 0, auditDetail{
+		"expires_at": expires,
+	}); err != nil {
+		return nil, err
+	}
+	return &model.PasswordResetResponse{Token: token, ExpiresAt: expires}, nil
+}
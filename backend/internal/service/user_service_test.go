@@ -0,0 +1,15 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"what-to-eat/internal/model"
+)
+
+func TestUserService_UpdateRole_Self(t *testing.T) {
+	s := &UserService{}
+	if _, err := s.UpdateRole(1, 1, model.RoleMember); !errors.Is(err, ErrChangeOwnRole) {
+		t.Errorf("UpdateRole() error = %v, want ErrChangeOwnRole", err)
+	}
+}
@@ -0,0 +1,132 @@
+// Package ical 生成 iCalendar（RFC 5545）日历订阅内容
+// 只实现只读订阅需要的 VCALENDAR/VEVENT 子集，时间统一以 UTC 输出
+package ical
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 事件状态
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusTentative = "TENTATIVE"
+)
+
+// 单行最多 75 个字节，超出后折行
+const maxLineOctets = 75
+
+// Event 日历事件
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	Status      string // StatusConfirmed 或 StatusTentative，为空时不输出
+	Stamp       time.Time
+}
+
+// Calendar 日历
+type Calendar struct {
+	ProdID  string
+	Name    string
+	Refresh time.Duration // 建议的刷新间隔，为 0 时不输出
+	Events  []Event
+}
+
+// Marshal 生成 .ics 内容
+func (c *Calendar) Marshal() []byte {
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		writeLine(&buf, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", escape(c.ProdID))
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escape(c.Name))
+	}
+	if c.Refresh > 0 {
+		line("REFRESH-INTERVAL;VALUE=DURATION", duration(c.Refresh))
+		line("X-PUBLISHED-TTL", duration(c.Refresh))
+	}
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", escape(e.UID))
+		line("DTSTAMP", formatTime(e.Stamp))
+		line("DTSTART", formatTime(e.Start))
+		line("DTEND", formatTime(e.End))
+		line("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION", escape(e.Location))
+		}
+		if e.Status != "" {
+			line("STATUS", e.Status)
+		}
+		line("TRANSP", "TRANSPARENT") // 不占用忙碌时间
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return buf.Bytes()
+}
+
+// formatTime 以 UTC 格式输出时间，如 20240310T120000Z
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// duration 输出 DURATION 类型的值（精确到分钟），如 PT1H30M
+func duration(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	var b strings.Builder
+	b.WriteString("PT")
+	if h := minutes / 60; h > 0 {
+		b.WriteString(strconv.Itoa(h) + "H")
+	}
+	if m := minutes % 60; m > 0 || minutes == 0 {
+		b.WriteString(strconv.Itoa(m) + "M")
+	}
+	return b.String()
+}
+
+// escape 转义 TEXT 类型的值：反斜杠、分号、逗号和换行
+func escape(s string) string {
+	return textEscaper.Replace(s)
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// writeLine 写入一行内容，超过 75 个字节时折行（续行以空格开头），不拆分 UTF-8 字符
+func writeLine(buf *bytes.Buffer, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		buf.WriteString(s[:cut])
+		buf.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1 // 续行开头的空格占一个字节
+	}
+	buf.WriteString(s)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,89 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendar_Marshal(t *testing.T) {
+	stamp := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	shanghai := time.FixedZone("CST", 8*3600)
+	cal := &Calendar{
+		ProdID:  "-//what-to-eat//CN",
+		Name:    "午餐",
+		Refresh: time.Hour,
+		Events: []Event{{
+			UID:         "decision-1@what-to-eat",
+			Start:       time.Date(2024, 3, 1, 10, 0, 0, 0, shanghai),
+			End:         time.Date(2024, 3, 1, 15, 0, 0, 0, shanghai),
+			Summary:     "兰州拉面, 牛肉面; 加蛋",
+			Description: "第一行\n第二行",
+			Status:      StatusConfirmed,
+			Stamp:       stamp,
+		}},
+	}
+	out := string(cal.Marshal())
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:午餐\r\n",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H\r\n",
+		"DTSTART:20240301T020000Z\r\n",
+		"DTEND:20240301T070000Z\r\n",
+		`SUMMARY:兰州拉面\, 牛肉面\; 加蛋` + "\r\n",
+		`DESCRIPTION:第一行\n第二行` + "\r\n",
+		"STATUS:CONFIRMED\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "LOCATION") {
+		t.Error("empty LOCATION should be omitted")
+	}
+}
+
+func TestWriteLine_Folding(t *testing.T) {
+	var buf bytes.Buffer
+	long := "SUMMARY:" + strings.Repeat("麻辣香锅", 10) // 8 + 120 字节
+	writeLine(&buf, long)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected folded lines, got %q", lines)
+	}
+	var joined strings.Builder
+	for i, l := range lines {
+		if len(l) > maxLineOctets {
+			t.Errorf("line %d has %d octets, want <= %d", i, len(l), maxLineOctets)
+		}
+		if i > 0 {
+			if !strings.HasPrefix(l, " ") {
+				t.Errorf("continuation line %d should start with a space: %q", i, l)
+			}
+			l = l[1:]
+		}
+		joined.WriteString(l)
+	}
+	if joined.String() != long {
+		t.Errorf("unfolded = %q, want %q", joined.String(), long)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Hour:                     "PT1H",
+		90 * time.Minute:              "PT1H30M",
+		15 * time.Minute:              "PT15M",
+		0:                             "PT0M",
+		12*time.Hour + 59*time.Second: "PT12H",
+	}
+	for d, want := range tests {
+		if got := duration(d); got != want {
+			t.Errorf("duration(%v) = %s, want %s", d, got, want)
+		}
+	}
+}
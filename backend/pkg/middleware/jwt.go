@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -13,8 +14,14 @@ import (
 	"what-to-eat/pkg/logger"
 )
 
+// SessionChecker 检查访问令牌所属的登录会话是否仍然有效（退出登录后会话失效）
+type SessionChecker interface {
+	SessionActive(userID int64, sessionID string) (bool, error)
+}
+
 // JWTAuth JWT 认证中间件
-func JWTAuth(jwtSecret string) gin.HandlerFunc {
+// 除了校验签名和有效期，还通过 sessions 检查令牌所属的会话是否已经退出登录
+func JWTAuth(jwtSecret string, sessions SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -40,6 +47,12 @@ func JWTAuth(jwtSecret string) gin.HandlerFunc {
 			return []byte(jwtSecret), nil
 		})
 
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			// 客户端凭刷新令牌换取新的访问令牌后重试
+			c.JSON(http.StatusUnauthorized, model.Error(401, "认证已过期"))
+			c.Abort()
+			return
+		}
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, model.Error(401, "认证失败"))
 			c.Abort()
@@ -61,8 +74,27 @@ func JWTAuth(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
+		// 会话 ID（sid）和令牌 ID（jti）
+		sessionID, _ := claims["sid"].(string)
+		tokenID, _ := claims["jti"].(string)
+		active, err := sessions.SessionActive(int64(userID), sessionID)
+		if err != nil {
+			logger.Error("Failed to check session", zap.Int64("user_id", int64(userID)), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, model.Error(500, "认证失败"))
+			c.Abort()
+			return
+		}
+		if !active {
+			c.JSON(http.StatusUnauthorized, model.Error(401, "登录已失效，请重新登录"))
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", int64(userID))
 		c.Set("username", claims["username"])
+		c.Set("role", claims["role"])
+		c.Set("session_id", sessionID)
+		c.Set("token_id", tokenID)
 		c.Next()
 	}
 }
@@ -76,6 +108,13 @@ func GetUserID(c *gin.Context) int64 {
 	return userID.(int64)
 }
 
+// GetSessionID 从上下文获取当前登录会话的 ID
+func GetSessionID(c *gin.Context) string {
+	sessionID, _ := c.Get("session_id")
+	id, _ := sessionID.(string)
+	return id
+}
+
 // CORS 跨域中间件
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"what-to-eat/internal/model"
+)
+
+// RequireRole 角色权限中间件（需在 JWTAuth 之后使用）
+// 只有角色在 roles 中的用户可以访问；角色来自访问令牌，修改角色时会结束该用户的全部会话
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]struct{}, len(roles))
+	for _, role := range roles {
+		allowed[role] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := allowed[GetRole(c)]; !ok {
+			message := "权限不足"
+			if len(roles) == 1 && roles[0] == model.RoleAdmin {
+				message = "需要管理员权限"
+			}
+			c.JSON(http.StatusForbidden, model.Error(403, message))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetRole 从上下文获取当前用户的角色
+func GetRole(c *gin.Context) string {
+	role, _ := c.Get("role")
+	name, _ := role.(string)
+	return name
+}
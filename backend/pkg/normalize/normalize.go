@@ -0,0 +1,42 @@
+package normalize
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Name 规范化名称（用于存储和展示）
+// NFKC 归一化（全角字母数字、全角空格等转为半角），去除首尾空白并合并连续空白
+func Name(s string) string {
+	return strings.Join(strings.Fields(norm.NFKC.String(s)), " ")
+}
+
+// Key 生成名称的比较键（用于查重和查找）
+// 在 Name 的基础上做大小写折叠，"KFC"、"ｋｆｃ " 与 "kfc" 得到相同的键
+func Key(s string) string {
+	return cases.Fold().String(Name(s))
+}
+
+// Pinyin 返回名称的拼音全拼和首字母（用于拼音搜索），非汉字字符转为小写后原样保留，空白被忽略
+func Pinyin(s string) (full string, initials string) {
+	args := pinyin.NewArgs()
+	args.Fallback = func(r rune, a pinyin.Args) []string {
+		if unicode.IsSpace(r) {
+			return nil
+		}
+		return []string{string(unicode.ToLower(r))}
+	}
+
+	var fullBuilder, initialsBuilder strings.Builder
+	for _, syllable := range pinyin.LazyPinyin(s, args) {
+		fullBuilder.WriteString(syllable)
+		r, _ := utf8.DecodeRuneInString(syllable)
+		initialsBuilder.WriteRune(r)
+	}
+	return fullBuilder.String(), initialsBuilder.String()
+}
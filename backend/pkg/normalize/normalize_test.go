@@ -0,0 +1,65 @@
+package normalize
+
+import "testing"
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "trim spaces", input: "  麦当劳 ", want: "麦当劳"},
+		{name: "full-width letters", input: "ＫＦＣ", want: "KFC"},
+		{name: "full-width space", input: "兰州　拉面", want: "兰州 拉面"},
+		{name: "collapse inner spaces", input: "Pizza   Hut", want: "Pizza Hut"},
+		{name: "keep case", input: "McDonald's", want: "McDonald's"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Name(tt.input); got != tt.want {
+				t.Errorf("Name(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{a: "麦当劳 ", b: "麦当劳"},
+		{a: "ＫＦＣ", b: "kfc"},
+		{a: "Pizza  HUT", b: "pizza hut"},
+		{a: "Straße", b: "STRASSE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a, func(t *testing.T) {
+			if Key(tt.a) != Key(tt.b) {
+				t.Errorf("Key(%q) = %q, Key(%q) = %q, want equal", tt.a, Key(tt.a), tt.b, Key(tt.b))
+			}
+		})
+	}
+}
+
+func TestPinyin(t *testing.T) {
+	tests := []struct {
+		input        string
+		wantFull     string
+		wantInitials string
+	}{
+		{input: "麦当劳", wantFull: "maidanglao", wantInitials: "mdl"},
+		{input: "KFC肯德基", wantFull: "kfckendeji", wantInitials: "kfckdj"},
+		{input: "兰州 拉面", wantFull: "lanzhoulamian", wantInitials: "lzlm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			full, initials := Pinyin(tt.input)
+			if full != tt.wantFull || initials != tt.wantInitials {
+				t.Errorf("Pinyin(%q) = (%q, %q), want (%q, %q)", tt.input, full, initials, tt.wantFull, tt.wantInitials)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorage_SaveAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStorage(dir, "/uploads/")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+
+	key := "menus/1/photo.jpg"
+	if err := s.Save(key, strings.NewReader("image-data")); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "menus", "1", "photo.jpg"))
+	if err != nil {
+		t.Fatalf("saved file not found: %v", err)
+	}
+	if string(data) != "image-data" {
+		t.Errorf("saved content = %q, want %q", data, "image-data")
+	}
+
+	if got := s.URL(key); got != "/uploads/menus/1/photo.jpg" {
+		t.Errorf("URL() = %q, want %q", got, "/uploads/menus/1/photo.jpg")
+	}
+
+	if err := s.Delete(key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := s.Delete(key); err != nil {
+		t.Errorf("Delete() of missing file error = %v, want nil", err)
+	}
+}
+
+func TestLocalStorage_InvalidKey(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "menus/../../secret", `menus\1.jpg`} {
+		t.Run(key, func(t *testing.T) {
+			if err := s.Save(key, strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Save(%q) error = %v, want ErrInvalidKey", key, err)
+			}
+		})
+	}
+}
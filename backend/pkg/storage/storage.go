@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrInvalidKey 非法的存储键
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage 文件存储接口
+// key 使用 "/" 分隔的相对路径，如 "menus/12/abc.jpg"
+type Storage interface {
+	// Save 保存文件，已存在时覆盖
+	Save(key string, r io.Reader) error
+	// Delete 删除文件，文件不存在时不报错
+	Delete(key string) error
+	// URL 返回文件的访问地址
+	URL(key string) string
+}
+
+// cleanKey 校验并清理存储键，拒绝绝对路径和 ".." 跳出存储目录
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,50 @@
+// Package timezone 按用户时区计算日期边界
+// 数据库中的时间统一以 UTC 存储，"今天"、"本月"、按天/按周统计等都需要先换算到用户所在的时区
+package timezone
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalid 时区名称无效
+var ErrInvalid = errors.New("时区无效")
+
+// Load 按 IANA 名称（如 Asia/Shanghai）加载时区，"Local" 表示服务器所在时区
+// 空字符串不是有效的时区（time.LoadLocation 会把它当作 UTC）
+func Load(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, ErrInvalid
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalid, name)
+	}
+	return loc, nil
+}
+
+// StartOfDay 返回 t 在 loc 中所在日期的 0 点
+// 夏令时从 0 点开始的地区当天没有 0 点，返回当天最早的时刻
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// DayBounds 返回 t 在 loc 中所在日期的 [start, end)
+// 夏令时切换当天不是 24 小时，end 按日历日计算而不是 start 加 24 小时
+func DayBounds(t time.Time, loc *time.Location) (start, end time.Time) {
+	start = StartOfDay(t, loc)
+	return start, StartOfDay(start.AddDate(0, 0, 1), loc)
+}
+
+// Offset 返回 t 时刻 loc 相对 UTC 的偏移，格式为 "+08:00"（用于 MySQL CONVERT_TZ）
+func Offset(t time.Time, loc *time.Location) string {
+	_, seconds := t.In(loc).Zone()
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%c%02d:%02d", sign, seconds/3600, seconds%3600/60)
+}
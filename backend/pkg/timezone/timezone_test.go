@@ -0,0 +1,112 @@
+package timezone
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := Load(name)
+	if err != nil {
+		t.Fatalf("Load(%q) error = %v", name, err)
+	}
+	return loc
+}
+
+func TestLoad(t *testing.T) {
+	for _, name := range []string{"", "Mars/Olympus", "utc+8"} {
+		if _, err := Load(name); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Load(%q) error = %v, want %v", name, err, ErrInvalid)
+		}
+	}
+	if loc := mustLoad(t, "Asia/Shanghai"); loc.String() != "Asia/Shanghai" {
+		t.Errorf("Load(Asia/Shanghai) = %v", loc)
+	}
+}
+
+func TestDayBounds(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	shanghai := mustLoad(t, "Asia/Shanghai")
+
+	tests := []struct {
+		name      string
+		at        time.Time
+		loc       *time.Location
+		wantStart time.Time
+		wantHours float64
+	}{
+		{
+			name:      "utc instant belongs to next day in shanghai",
+			at:        time.Date(2024, 3, 15, 17, 30, 0, 0, time.UTC),
+			loc:       shanghai,
+			wantStart: time.Date(2024, 3, 15, 16, 0, 0, 0, time.UTC),
+			wantHours: 24,
+		},
+		{
+			name:      "utc instant belongs to previous day in new york",
+			at:        time.Date(2024, 3, 15, 2, 0, 0, 0, time.UTC),
+			loc:       newYork,
+			wantStart: time.Date(2024, 3, 14, 4, 0, 0, 0, time.UTC),
+			wantHours: 24,
+		},
+		{
+			name:      "spring forward day is 23 hours",
+			at:        time.Date(2024, 3, 10, 12, 0, 0, 0, newYork),
+			loc:       newYork,
+			wantStart: time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC),
+			wantHours: 23,
+		},
+		{
+			name:      "fall back day is 25 hours",
+			at:        time.Date(2024, 11, 3, 23, 59, 0, 0, newYork),
+			loc:       newYork,
+			wantStart: time.Date(2024, 11, 3, 4, 0, 0, 0, time.UTC),
+			wantHours: 25,
+		},
+		{
+			name:      "repeated hour after fall back",
+			at:        time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC), // 01:30 EST，第二次出现的 1 点
+			loc:       newYork,
+			wantStart: time.Date(2024, 11, 3, 4, 0, 0, 0, time.UTC),
+			wantHours: 25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := DayBounds(tt.at, tt.loc)
+			if !start.Equal(tt.wantStart) {
+				t.Errorf("start = %v, want %v", start.UTC(), tt.wantStart)
+			}
+			if got := end.Sub(start).Hours(); got != tt.wantHours {
+				t.Errorf("day length = %vh, want %vh", got, tt.wantHours)
+			}
+			if tt.at.Before(start) || !tt.at.Before(end) {
+				t.Errorf("%v not in [%v, %v)", tt.at, start, end)
+			}
+		})
+	}
+}
+
+func TestOffset(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	tests := []struct {
+		at   time.Time
+		loc  *time.Location
+		want string
+	}{
+		{at: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), loc: newYork, want: "-05:00"},
+		{at: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), loc: newYork, want: "-04:00"},
+		{at: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), loc: mustLoad(t, "Asia/Shanghai"), want: "+08:00"},
+		{at: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), loc: mustLoad(t, "Asia/Kolkata"), want: "+05:30"},
+		{at: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), loc: time.UTC, want: "+00:00"},
+	}
+
+	for _, tt := range tests {
+		if got := Offset(tt.at, tt.loc); got != tt.want {
+			t.Errorf("Offset(%v, %v) = %s, want %s", tt.at, tt.loc, got, tt.want)
+		}
+	}
+}
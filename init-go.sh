@@ -192,7 +192,7 @@ main() {
     echo ""
     log_success "Go 语言环境初始化完成！"
     log_info "可以运行以下命令启动后端："
-    echo "  cd backend && go run cmd/server/main.go"
+    echo "  cd backend && go run ./cmd/server"
     echo "  或使用项目启动脚本："
     echo "  ./start.sh backend"
 }
@@ -18,16 +18,40 @@ CREATE TABLE IF NOT EXISTS users (
     id BIGINT PRIMARY KEY AUTO_INCREMENT,
     username VARCHAR(50) NOT NULL UNIQUE COMMENT '用户名',
     password_hash VARCHAR(255) NOT NULL COMMENT '密码哈希',
+    time_zone VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'IANA 时区名称，为空表示使用服务器默认时区',
+    calendar_key VARCHAR(64) NOT NULL DEFAULT '' COMMENT '日历订阅令牌的 SHA-256，为空表示未开启订阅',
+    role VARCHAR(16) NOT NULL DEFAULT 'member' COMMENT '角色（admin, member, guest）',
+    device_key VARCHAR(64) NULL COMMENT '游客绑定的设备标识的 SHA-256',
+    reset_key VARCHAR(64) NULL COMMENT '一次性密码重置令牌的 SHA-256',
+    reset_expires DATETIME(3) NULL COMMENT '密码重置令牌的过期时间',
+    last_seen_at DATETIME(3) NULL COMMENT '最近一次登录或刷新令牌的时间',
     created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
-    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3)
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    INDEX idx_calendar_key (calendar_key),
+    INDEX idx_users_role (role),
+    UNIQUE INDEX idx_users_device_key (device_key),
+    UNIQUE INDEX idx_users_reset_key (reset_key)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户表';
 
 -- 餐厅表
 CREATE TABLE IF NOT EXISTS restaurants (
     id BIGINT PRIMARY KEY AUTO_INCREMENT,
     name VARCHAR(100) NOT NULL UNIQUE COMMENT '餐厅名称',
+    name_key VARCHAR(100) NOT NULL DEFAULT '' COMMENT '规范化名称（查重用）',
+    name_pinyin VARCHAR(600) NOT NULL DEFAULT '' COMMENT '名称的拼音全拼（搜索用）',
+    name_initials VARCHAR(100) NOT NULL DEFAULT '' COMMENT '名称的拼音首字母（搜索用）',
+    cuisine VARCHAR(32) NOT NULL DEFAULT '' COMMENT '菜系（fast_food, sichuan, noodles, hotpot 等），为空表示未分类',
+    merged_into_id BIGINT NULL COMMENT '被合并到的餐厅ID',
+    image_key VARCHAR(255) NOT NULL DEFAULT '' COMMENT '图片存储键',
+    image_url VARCHAR(255) NOT NULL DEFAULT '' COMMENT '图片地址',
+    thumbnail_url VARCHAR(255) NOT NULL DEFAULT '' COMMENT '缩略图地址',
     created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
-    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3)
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3) NULL COMMENT '软删除时间',
+    INDEX idx_name_key (name_key),
+    INDEX idx_restaurants_cuisine (cuisine),
+    INDEX idx_restaurants_merged_into_id (merged_into_id),
+    INDEX idx_restaurants_deleted_at (deleted_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='餐厅表';
 
 -- 菜单表（菜品）- 支持软删除
@@ -35,10 +59,22 @@ CREATE TABLE IF NOT EXISTS menus (
     id BIGINT PRIMARY KEY AUTO_INCREMENT,
     restaurant_id BIGINT NOT NULL COMMENT '所属餐厅ID',
     dish_name VARCHAR(100) NOT NULL COMMENT '菜品名称',
+    dish_name_key VARCHAR(100) NOT NULL DEFAULT '' COMMENT '规范化菜品名称（查重用）',
+    dish_pinyin VARCHAR(600) NOT NULL DEFAULT '' COMMENT '菜品名的拼音全拼（搜索用）',
+    dish_initials VARCHAR(100) NOT NULL DEFAULT '' COMMENT '菜品名的拼音首字母（搜索用）',
+    image_key VARCHAR(255) NOT NULL DEFAULT '' COMMENT '图片存储键',
+    image_url VARCHAR(255) NOT NULL DEFAULT '' COMMENT '图片地址',
+    thumbnail_url VARCHAR(255) NOT NULL DEFAULT '' COMMENT '缩略图地址',
+    role VARCHAR(16) NOT NULL DEFAULT '' COMMENT '菜品角色（main, side, drink, dessert）',
+    kcal INT NULL COMMENT '热量（千卡）',
+    protein DOUBLE NULL COMMENT '蛋白质（克）',
+    carbs DOUBLE NULL COMMENT '碳水化合物（克）',
+    fat DOUBLE NULL COMMENT '脂肪（克）',
     created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
     updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
     deleted_at DATETIME(3) NULL COMMENT '软删除时间',
     INDEX idx_restaurant (restaurant_id),
+    INDEX idx_restaurant_dish_key (restaurant_id, dish_name_key),
     INDEX idx_deleted_at (deleted_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='菜单表';
 
@@ -46,57 +82,121 @@ CREATE TABLE IF NOT EXISTS menus (
 CREATE TABLE IF NOT EXISTS decision_records (
     id BIGINT PRIMARY KEY AUTO_INCREMENT,
     user_id BIGINT NOT NULL COMMENT '用户ID',
-    menu_id BIGINT NOT NULL COMMENT '菜单ID',
+    menu_id BIGINT NOT NULL COMMENT '菜单ID（组合决策为第一个菜品）',
+    mode VARCHAR(16) NOT NULL DEFAULT 'single' COMMENT '决策模式（single, combo）',
     decided_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3) COMMENT '决策时间',
+    confirmed_at DATETIME(3) NULL COMMENT '确认时间（确认后计入营养统计）',
+    recent_repeat TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否选中了最近决策中出现过的菜品',
+    origin VARCHAR(16) NOT NULL DEFAULT 'spin' COMMENT '来源（spin, manual, plan, group）',
+    note VARCHAR(255) NOT NULL DEFAULT '' COMMENT '手动记录的原因备注',
     INDEX idx_user_decided (user_id, decided_at DESC)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='决策记录表';
 
+-- 组合决策菜品表
+CREATE TABLE IF NOT EXISTS decision_items (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    decision_id BIGINT NOT NULL COMMENT '决策记录ID',
+    menu_id BIGINT NOT NULL COMMENT '菜单ID',
+    role VARCHAR(16) NOT NULL DEFAULT '' COMMENT '按哪个角色要求选出，为空表示补足数量时选出',
+    INDEX idx_decision_items_decision_id (decision_id),
+    INDEX idx_decision_items_menu_id (menu_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='组合决策菜品表';
+
+-- 决策月度汇总表（超过保留期限的决策记录汇总到这里后删除）
+CREATE TABLE IF NOT EXISTS decision_aggregates (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    month DATE NOT NULL COMMENT '月份（当月第一天，按用户时区）',
+    menu_id BIGINT NOT NULL COMMENT '菜单ID',
+    weekday TINYINT NOT NULL COMMENT '星期（0 表示星期日，按用户时区）',
+    meals BIGINT NOT NULL DEFAULT 0 COMMENT '以该菜品为主记录菜品的用餐次数',
+    dishes BIGINT NOT NULL DEFAULT 0 COMMENT '吃过该菜品的次数（组合决策的每个菜品各计一次）',
+    repeats BIGINT NOT NULL DEFAULT 0 COMMENT '选中了最近吃过的菜品的次数',
+    UNIQUE KEY idx_user_month_menu_weekday (user_id, month, menu_id, weekday),
+    INDEX idx_decision_aggregates_menu_id (menu_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='决策月度汇总表';
+
+-- 用户收藏表
+CREATE TABLE IF NOT EXISTS user_favorites (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    menu_id BIGINT NOT NULL COMMENT '菜单ID',
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    UNIQUE INDEX idx_user_favorite (user_id, menu_id),
+    INDEX idx_user_favorites_menu_id (menu_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户收藏表';
+
+-- 用户屏蔽表
+CREATE TABLE IF NOT EXISTS user_blocks (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    target_type VARCHAR(16) NOT NULL COMMENT '屏蔽类型（menu, restaurant）',
+    target_id BIGINT NOT NULL COMMENT '菜单ID或餐厅ID',
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    UNIQUE INDEX idx_user_block (user_id, target_type, target_id),
+    INDEX idx_block_target (target_type, target_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户屏蔽表';
+
+-- 团队表
+CREATE TABLE IF NOT EXISTS teams (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(100) NOT NULL COMMENT '团队名称',
+    invite_code VARCHAR(32) NOT NULL COMMENT '邀请码',
+    owner_id BIGINT NOT NULL COMMENT '创建者ID',
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    UNIQUE INDEX idx_teams_invite_code (invite_code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='团队表';
+
+-- 团队成员表
+CREATE TABLE IF NOT EXISTS team_members (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    team_id BIGINT NOT NULL COMMENT '团队ID',
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    joined_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3) COMMENT '加入时间',
+    UNIQUE INDEX idx_team_member (team_id, user_id),
+    INDEX idx_team_members_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='团队成员表';
+
+-- 审计日志表
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    actor_id BIGINT NOT NULL COMMENT '操作人ID',
+    action VARCHAR(50) NOT NULL COMMENT '操作类型',
+    target_type VARCHAR(50) NOT NULL COMMENT '目标类型',
+    target_id BIGINT NOT NULL COMMENT '目标ID',
+    source_id BIGINT COMMENT '源ID（合并操作）',
+    detail TEXT COMMENT '操作详情（JSON）',
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    INDEX idx_audit_logs_actor_id (actor_id),
+    INDEX idx_audit_logs_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='审计日志表';
+
+-- 刷新令牌表
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    family_id VARCHAR(64) NOT NULL COMMENT '令牌家族（同一次登录轮换出的令牌）',
+    token_hash VARCHAR(64) NOT NULL COMMENT '令牌的 SHA-256',
+    expires_at DATETIME(3) NOT NULL COMMENT '过期时间',
+    used_at DATETIME(3) NULL COMMENT '已轮换的时间',
+    revoked_at DATETIME(3) NULL COMMENT '作废的时间',
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    UNIQUE INDEX idx_refresh_tokens_token_hash (token_hash),
+    INDEX idx_refresh_tokens_user_id (user_id),
+    INDEX idx_refresh_tokens_family_id (family_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='刷新令牌表';
+
+-- 数据迁移记录表
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version VARCHAR(64) PRIMARY KEY COMMENT '迁移标记',
+    applied_at DATETIME(3) NULL COMMENT '执行时间'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='数据迁移记录表';
+
 -- ============================================================================
 -- 默认数据（可选，后端启动时会自动初始化）
 -- ============================================================================
 
--- 插入游客用户（id=1）
--- 密码哈希为 "guest123" 使用 bcrypt 生成
-INSERT INTO users (id, username, password_hash, created_at, updated_at) 
-VALUES (1, 'guest', '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', NOW(3), NOW(3))
-ON DUPLICATE KEY UPDATE username = username;
-
--- 插入默认餐厅
-INSERT IGNORE INTO restaurants (name) VALUES 
-    ('麦当劳'),
-    ('肯德基'),
-    ('沙县小吃'),
-    ('兰州拉面'),
-    ('黄焖鸡米饭'),
-    ('海底捞');
-
--- 插入默认菜品
-INSERT IGNORE INTO menus (restaurant_id, dish_name) 
-SELECT r.id, d.dish_name FROM restaurants r
-JOIN (
-    SELECT '麦当劳' as restaurant, '巨无霸' as dish_name UNION ALL
-    SELECT '麦当劳', '麦辣鸡腿堡' UNION ALL
-    SELECT '麦当劳', '薯条' UNION ALL
-    SELECT '麦当劳', '麦旋风' UNION ALL
-    SELECT '肯德基', '原味鸡' UNION ALL
-    SELECT '肯德基', '香辣鸡腿堡' UNION ALL
-    SELECT '肯德基', '上校鸡块' UNION ALL
-    SELECT '肯德基', '蛋挞' UNION ALL
-    SELECT '沙县小吃', '拌面' UNION ALL
-    SELECT '沙县小吃', '蒸饺' UNION ALL
-    SELECT '沙县小吃', '炖罐' UNION ALL
-    SELECT '沙县小吃', '扁肉' UNION ALL
-    SELECT '兰州拉面', '牛肉面' UNION ALL
-    SELECT '兰州拉面', '刀削面' UNION ALL
-    SELECT '兰州拉面', '炒面' UNION ALL
-    SELECT '兰州拉面', '凉面' UNION ALL
-    SELECT '黄焖鸡米饭', '黄焖鸡米饭' UNION ALL
-    SELECT '黄焖鸡米饭', '黄焖排骨' UNION ALL
-    SELECT '黄焖鸡米饭', '黄焖豆腐' UNION ALL
-    SELECT '海底捞', '麻辣锅底' UNION ALL
-    SELECT '海底捞', '番茄锅底' UNION ALL
-    SELECT '海底捞', '菌汤锅底'
-) d ON r.name = d.restaurant
-WHERE NOT EXISTS (
-    SELECT 1 FROM menus m WHERE m.restaurant_id = r.id AND m.dish_name = d.dish_name
-);
+-- 游客登录时按设备自动创建匿名用户，不需要预置账号
+-- 餐厅和菜品的种子数据不在此处维护，由后端首次启动时导入（见 config.yaml 的 seed 配置），
+-- 或手动执行: go run ./cmd/server seed -pack default
@@ -108,7 +108,7 @@ start_backend() {
     
     # 编译后端
     log_info "Building backend..."
-    go build -o server ./cmd/server/main.go
+    go build -o server ./cmd/server
     
     # 启动后端（后台运行）
     ./server > ../backend.log 2>&1 &